@@ -0,0 +1,57 @@
+// Command ledgercheck verifies the double-entry invariants of the database:
+// every account balance equals the sum of its entries and every transfer has
+// a matching debit and credit. It exits with status 1 when a discrepancy is
+// found and 2 when the check couldn't run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/begenov/backend/internal/config"
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/db"
+)
+
+func main() {
+	configPath := flag.String("config", ".", "directory holding app.env")
+	batchSize := flag.Int("batch", 1000, "accounts or transfers read per query")
+	flag.Parse()
+
+	report, err := run(*configPath, *batchSize)
+	if err != nil {
+		log.Printf("[ERROR] ledger check: %v\n", err)
+		os.Exit(2)
+	}
+
+	for _, d := range report.Discrepancies {
+		fmt.Printf("%s account=%d transfer=%d: %s\n", d.Kind, d.AccountID, d.TransferID, d.Detail)
+	}
+	fmt.Printf("checked %d accounts and %d transfers, skipped %d transfers without linked entries, %d discrepancies\n",
+		report.AccountsChecked, report.TransfersChecked, report.TransfersSkipped, len(report.Discrepancies))
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, batchSize int) (domain.LedgerReport, error) {
+	cfg, err := config.Init(configPath)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+
+	db, err := db.NewDB(cfg.Postgres.Driver, cfg.Postgres.DSN)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+	defer db.Close()
+
+	ledger := service.NewLedgerService(repository.NewLedgerRepo(db))
+	return ledger.CheckLedger(context.Background(), domain.LedgerCheckParams{BatchSize: batchSize})
+}
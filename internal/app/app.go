@@ -2,11 +2,12 @@ package app
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,18 +21,38 @@ import (
 	"github.com/begenov/backend/pb"
 	"github.com/begenov/backend/pkg/auth"
 	"github.com/begenov/backend/pkg/db"
+	"github.com/begenov/backend/pkg/exchange"
 	"github.com/begenov/backend/pkg/hash"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/metrics"
+	"github.com/begenov/backend/pkg/publisher"
+	"github.com/begenov/backend/pkg/tracing"
+	"github.com/begenov/backend/pkg/webhook"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func Run(cfg *config.Config) error {
-	db, err := db.NewDB(cfg.Postgres.Driver, cfg.Postgres.DSN)
+	log, err := logger.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
 	if err != nil {
 		return err
 	}
+	// whatever runs outside of a request logs through the default logger
+	slog.SetDefault(log)
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.OTLPEndpoint, cfg.Tracing.ServiceName)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.NewDB(cfg.Postgres.Driver, cfg.Postgres.DSN)
+	if err != nil {
+		return err
+	}
+	metrics.Registry.MustRegister(db.NewStatsCollector(conn, cfg.Postgres.Driver))
 
 	hash := hash.NewHash()
 
@@ -40,24 +61,74 @@ func Run(cfg *config.Config) error {
 		return err
 	}
 
-	repo := repository.NewRepository(db)
+	rates, err := exchange.NewFileProvider(cfg.Exchange.RatesFile)
+	if err != nil {
+		return err
+	}
+
+	repo := repository.NewRepository(conn)
+
+	var events publisher.Publisher
+	if cfg.Outbox.RedisAddr != "" {
+		events = publisher.NewRedisPublisher(cfg.Outbox.RedisAddr, cfg.Outbox.Stream)
+	} else {
+		log.Warn("EVENTS_REDIS_ADDR is not set, events are only kept in memory")
+		events = publisher.NewMemoryPublisher()
+	}
+	defer events.Close()
+
+	service := service.NewService(repo, hash, token, rates, cfg.Scheduler.BatchSize, events, cfg.Outbox.BatchSize, webhook.NewSender(cfg.Webhook.Timeout), cfg.Webhook.BatchSize, cfg.Idempotency.BatchSize, cfg.JWT.AccessTokenDuration, cfg.JWT.RefreshTokenDuration)
 
-	service := service.NewService(repo, hash, token, cfg.JWT.AccessTokenDuration)
+	handler := httpv1.NewHandler(service, token, log)
 
-	handler := httpv1.NewHandler(service, token)
+	router, err := handler.Init(cfg)
+	if err != nil {
+		return err
+	}
 
-	srv := server.NewServer(cfg, handler.Init(cfg))
+	srv := server.NewServer(cfg, router)
 
-	go runGatewayServer(cfg, service, token)
-	runGrpcServer(cfg, service, token)
+	go runGrpcServer(cfg, service, token, log)
+	go runGatewayServer(cfg, log)
 
 	go func() {
 		if err = srv.Run(); err != nil {
-			log.Fatalf("error occurred while running http server: %s\n", err.Error())
+			log.Error("error occurred while running http server", "err", err)
+			os.Exit(1)
+		}
+	}()
+
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/metrics", metrics.Handler())
+	admin := server.NewAdminServer(cfg, adminMux)
+	go func() {
+		if err := admin.Run(); err != nil && err != http.ErrServerClosed {
+			log.Error("error occurred while running admin server", "err", err)
+			os.Exit(1)
 		}
 	}()
 
-	log.Println("Server started")
+	workerCtx, stopWorkers := context.WithCancel(logger.WithContext(context.Background(), log))
+	var workers sync.WaitGroup
+	workers.Add(4)
+	go func() {
+		defer workers.Done()
+		service.Scheduler.Run(workerCtx, cfg.Scheduler.Interval)
+	}()
+	go func() {
+		defer workers.Done()
+		service.Relay.Run(workerCtx, cfg.Outbox.Interval)
+	}()
+	go func() {
+		defer workers.Done()
+		service.WebhookDispatcher.Run(workerCtx, cfg.Webhook.Interval)
+	}()
+	go func() {
+		defer workers.Done()
+		service.Sweeper.Run(workerCtx, cfg.Idempotency.Interval)
+	}()
+
+	log.Info("Server started", "http_addr", cfg.Server.Addr)
 
 	quit := make(chan os.Signal, 1)
 
@@ -71,33 +142,60 @@ func Run(cfg *config.Config) error {
 	defer shutdown()
 
 	if err := srv.Stop(ctx); err != nil {
-		log.Printf("failed to stop server: %v", err)
+		log.Error("failed to stop server", "err", err)
+	}
+	if err := admin.Stop(ctx); err != nil {
+		log.Error("failed to stop admin server", "err", err)
+	}
+
+	// let the workers finish the batches they have claimed
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		log.Error("failed to stop background workers", "err", ctx.Err())
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("failed to flush traces", "err", err)
 	}
 
 	return nil
 }
 
-func runGrpcServer(cfg *config.Config, service *service.Service, token auth.TokenManager) {
+func runGrpcServer(cfg *config.Config, service *service.Service, token auth.TokenManager, log *slog.Logger) {
 	server := gapi.NewHandler(service, token)
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(gapi.UnaryTracingInterceptor(), gapi.UnaryLoggingInterceptor(log), gapi.UnaryMetricsInterceptor, server.UnaryAuthInterceptor, server.UnaryAuditInterceptor, server.UnaryRoleInterceptor),
+		grpc.ChainStreamInterceptor(gapi.StreamTracingInterceptor(), gapi.StreamLoggingInterceptor(log), gapi.StreamMetricsInterceptor, server.StreamAuthInterceptor, server.StreamAuditInterceptor, server.StreamRoleInterceptor),
+	)
 	pb.RegisterSimpleBankServer(grpcServer, server)
 	reflection.Register(grpcServer)
 
 	listener, err := net.Listen("tcp", "localhost:"+cfg.Server.GrpcAddr)
 	if err != nil {
-		log.Fatal("cannot create listener", err)
+		log.Error("cannot create listener", "err", err)
+		os.Exit(1)
 	}
-	log.Println("starting grpc server:" + cfg.Server.GrpcAddr)
+	log.Info("starting grpc server", "addr", cfg.Server.GrpcAddr)
 	err = grpcServer.Serve(listener)
 	if err != nil {
-		log.Fatal("cannot start gRPC server", err)
+		log.Error("cannot start gRPC server", "err", err)
+		os.Exit(1)
 	}
 }
 
-func runGatewayServer(cfg *config.Config, service *service.Service, token auth.TokenManager) {
-	server := gapi.NewHandler(service, token)
-
+// runGatewayServer proxies HTTP calls to the gRPC server over the network so
+// that every request passes through the same interceptors. The Authorization
+// header is forwarded as the authorization metadata and the trace context as
+// traceparent.
+func runGatewayServer(cfg *config.Config, log *slog.Logger) {
 	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
 		MarshalOptions: protojson.MarshalOptions{
 			UseProtoNames: true,
@@ -107,25 +205,30 @@ func runGatewayServer(cfg *config.Config, service *service.Service, token auth.T
 		},
 	})
 
-	grpcMux := runtime.NewServeMux(jsonOption)
+	grpcMux := runtime.NewServeMux(jsonOption, runtime.WithIncomingHeaderMatcher(gapi.IncomingHeaderMatcher))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	err := pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, server)
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, gapi.GatewayDialOptions()...)
+	err := pb.RegisterSimpleBankHandlerFromEndpoint(ctx, grpcMux, "localhost:"+cfg.Server.GrpcAddr, opts)
 	if err != nil {
-		log.Fatal("error ", err)
+		log.Error("cannot register gateway handler", "err", err)
+		os.Exit(1)
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", grpcMux)
-	listener, err := net.Listen("tcp", "localhost:"+cfg.Server.Addr)
+	listener, err := net.Listen("tcp", "localhost:"+cfg.Server.GatewayAddr)
 	if err != nil {
-		log.Fatal("cannot create listener", err)
+		log.Error("cannot create listener", "err", err)
+		os.Exit(1)
 	}
 
-	err = http.Serve(listener, mux)
+	log.Info("starting gateway server", "addr", cfg.Server.GatewayAddr)
+	err = http.Serve(listener, gapi.GatewayTracing(gapi.GatewayLogger(log, mux)))
 	if err != nil {
-		log.Fatal(err)
+		log.Error("cannot start gateway server", "err", err)
+		os.Exit(1)
 	}
 
 }
@@ -9,15 +9,39 @@ import (
 const (
 	defaultHTTPServerPort           = "8080"
 	defaultGRPCServerPort           = "9090"
+	defaultGatewayServerPort        = "8081"
+	defaultAdminServerAddr          = "localhost:8082"
 	defaultServerRWTimeout          = 10 * time.Second
 	defaultServerMaxHeaderMegabytes = 1
 	defaultAccessTokenDuration      = 15 * time.Minute
+	defaultRefreshTokenDuration     = 24 * time.Hour
+	defaultSchedulerInterval        = 30 * time.Second
+	defaultSchedulerBatchSize       = 50
+	defaultOutboxInterval           = time.Second
+	defaultOutboxBatchSize          = 100
+	defaultEventsStream             = "bank.events"
+	defaultWebhookInterval          = 5 * time.Second
+	defaultWebhookBatchSize         = 50
+	defaultWebhookTimeout           = 10 * time.Second
+	defaultIdempotencyInterval      = time.Minute
+	defaultIdempotencyBatchSize     = 1000
+	defaultLogLevel                 = "info"
+	defaultLogFormat                = "json"
+	defaultTracingExporter          = "none"
+	defaultServiceName              = "simple-bank"
 )
 
 type Config struct {
-	Postgres DBConfig
-	Server   HTTPConfig
-	JWT      JWTConfig
+	Postgres    DBConfig
+	Server      HTTPConfig
+	JWT         JWTConfig
+	Exchange    ExchangeConfig
+	Scheduler   SchedulerConfig
+	Outbox      OutboxConfig
+	Webhook     WebhookConfig
+	Idempotency IdempotencyConfig
+	Log         LogConfig
+	Tracing     TracingConfig
 }
 
 type DBConfig struct {
@@ -26,16 +50,72 @@ type DBConfig struct {
 }
 
 type HTTPConfig struct {
-	Addr           string
-	GrpcAddr       string
+	Addr        string
+	GrpcAddr    string
+	GatewayAddr string
+	// host and port of the server of /metrics, apart from the API and only
+	// on loopback unless ADMIN_ADDR says otherwise
+	AdminAddr string `mapstructure:"ADMIN_ADDR"`
+	// proxies whose X-Forwarded-For is believed, none by default
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	MaxHeaderBytes int
 }
 
 type JWTConfig struct {
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+}
+
+type ExchangeConfig struct {
+	RatesFile string `mapstructure:"EXCHANGE_RATES_FILE"`
+}
+
+type SchedulerConfig struct {
+	// how often the worker looks for due scheduled transfers
+	Interval  time.Duration
+	BatchSize int
+}
+
+type OutboxConfig struct {
+	// how often the relay looks for events to publish
+	Interval  time.Duration
+	BatchSize int
+	// address of the Redis compatible broker the events are published to,
+	// without one they are only kept in memory
+	RedisAddr string `mapstructure:"EVENTS_REDIS_ADDR"`
+	Stream    string `mapstructure:"EVENTS_STREAM"`
+}
+
+type WebhookConfig struct {
+	// how often the worker looks for deliveries that are due
+	Interval  time.Duration
+	BatchSize int
+	// how long a receiver gets to respond
+	Timeout time.Duration
+}
+
+type IdempotencyConfig struct {
+	// how often expired idempotency keys are deleted
+	Interval  time.Duration
+	BatchSize int
+}
+
+type LogConfig struct {
+	// debug, info, warn or error
+	Level string `mapstructure:"LOG_LEVEL"`
+	// json or text
+	Format string `mapstructure:"LOG_FORMAT"`
+}
+
+type TracingConfig struct {
+	// none, stdout or otlp
+	Exporter string `mapstructure:"TRACING_EXPORTER"`
+	// host:port of the OTLP collector
+	OTLPEndpoint string `mapstructure:"OTLP_ENDPOINT"`
+	ServiceName  string
 }
 
 func Init(path string) (*Config, error) {
@@ -64,13 +144,80 @@ func Init(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := viper.UnmarshalKey("EXCHANGE_RATES_FILE", &cfg.Exchange.RatesFile); err != nil {
+		return nil, err
+	}
+
+	if err := viper.UnmarshalKey("EVENTS_REDIS_ADDR", &cfg.Outbox.RedisAddr); err != nil {
+		return nil, err
+	}
+
+	if err := viper.UnmarshalKey("EVENTS_STREAM", &cfg.Outbox.Stream); err != nil {
+		return nil, err
+	}
+
+	if err := viper.UnmarshalKey("LOG_LEVEL", &cfg.Log.Level); err != nil {
+		return nil, err
+	}
+
+	if err := viper.UnmarshalKey("LOG_FORMAT", &cfg.Log.Format); err != nil {
+		return nil, err
+	}
+
+	if err := viper.UnmarshalKey("TRACING_EXPORTER", &cfg.Tracing.Exporter); err != nil {
+		return nil, err
+	}
+
+	if err := viper.UnmarshalKey("OTLP_ENDPOINT", &cfg.Tracing.OTLPEndpoint); err != nil {
+		return nil, err
+	}
+
 	cfg.Server = HTTPConfig{
 		Addr:           defaultHTTPServerPort,
 		GrpcAddr:       defaultGRPCServerPort,
+		GatewayAddr:    defaultGatewayServerPort,
 		ReadTimeout:    defaultServerRWTimeout,
 		WriteTimeout:   defaultServerRWTimeout,
 		MaxHeaderBytes: defaultServerMaxHeaderMegabytes,
 	}
+	if err := viper.UnmarshalKey("TRUSTED_PROXIES", &cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+	if err := viper.UnmarshalKey("ADMIN_ADDR", &cfg.Server.AdminAddr); err != nil {
+		return nil, err
+	}
+	if cfg.Server.AdminAddr == "" {
+		cfg.Server.AdminAddr = defaultAdminServerAddr
+	}
 	cfg.JWT.AccessTokenDuration = defaultAccessTokenDuration
+	cfg.JWT.RefreshTokenDuration = defaultRefreshTokenDuration
+	cfg.Scheduler = SchedulerConfig{
+		Interval:  defaultSchedulerInterval,
+		BatchSize: defaultSchedulerBatchSize,
+	}
+	cfg.Outbox.Interval = defaultOutboxInterval
+	cfg.Outbox.BatchSize = defaultOutboxBatchSize
+	if cfg.Outbox.Stream == "" {
+		cfg.Outbox.Stream = defaultEventsStream
+	}
+	cfg.Webhook = WebhookConfig{
+		Interval:  defaultWebhookInterval,
+		BatchSize: defaultWebhookBatchSize,
+		Timeout:   defaultWebhookTimeout,
+	}
+	cfg.Idempotency = IdempotencyConfig{
+		Interval:  defaultIdempotencyInterval,
+		BatchSize: defaultIdempotencyBatchSize,
+	}
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = defaultLogLevel
+	}
+	if cfg.Log.Format == "" {
+		cfg.Log.Format = defaultLogFormat
+	}
+	if cfg.Tracing.Exporter == "" {
+		cfg.Tracing.Exporter = defaultTracingExporter
+	}
+	cfg.Tracing.ServiceName = defaultServiceName
 	return &cfg, nil
 }
@@ -0,0 +1,101 @@
+package gapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// auditedMethods maps the state-changing methods to the action recorded in
+// the audit log.
+var auditedMethods = map[string]string{
+	pb.SimpleBank_CreateUser_FullMethodName:        domain.AuditUserCreate,
+	pb.SimpleBank_LoginUser_FullMethodName:         domain.AuditUserLogin,
+	pb.SimpleBank_RenewAccessToken_FullMethodName:  domain.AuditTokenRenew,
+	pb.SimpleBank_LogoutUser_FullMethodName:        domain.AuditUserLogout,
+	pb.SimpleBank_CreateAccount_FullMethodName:     domain.AuditAccountCreate,
+	pb.SimpleBank_SetOverdraftLimit_FullMethodName: domain.AuditAccountOverdraftLimit,
+	pb.SimpleBank_FreezeAccount_FullMethodName:     domain.AuditAccountFreeze,
+	pb.SimpleBank_UnfreezeAccount_FullMethodName:   domain.AuditAccountUnfreeze,
+	pb.SimpleBank_CloseAccount_FullMethodName:      domain.AuditAccountClose,
+	pb.SimpleBank_Deposit_FullMethodName:           domain.AuditAccountDeposit,
+	pb.SimpleBank_Withdraw_FullMethodName:          domain.AuditAccountWithdraw,
+	pb.SimpleBank_CreateTransfer_FullMethodName:    domain.AuditTransferCreate,
+	pb.SimpleBank_BatchTransfer_FullMethodName:     domain.AuditTransferBatch,
+	pb.SimpleBank_ReverseTransfer_FullMethodName:   domain.AuditTransferReverse,
+}
+
+type auditEventKey struct{}
+
+// UnaryAuditInterceptor records an audit event for every state-changing
+// method. It must run after UnaryAuthInterceptor so the caller is known, and
+// before UnaryRoleInterceptor so denied calls are recorded too.
+func (h *Handler) UnaryAuditInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	action, ok := auditedMethods[info.FullMethod]
+	if !ok || h.service.Audit == nil {
+		return handler(ctx, req)
+	}
+
+	event := newAuditEvent(ctx, action)
+	resp, err := handler(context.WithValue(ctx, auditEventKey{}, event), req)
+	h.recordAuditEvent(ctx, event, err)
+	return resp, err
+}
+
+func (h *Handler) StreamAuditInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	action, ok := auditedMethods[info.FullMethod]
+	if !ok || h.service.Audit == nil {
+		return handler(srv, ss)
+	}
+
+	event := newAuditEvent(ss.Context(), action)
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), auditEventKey{}, event)})
+	h.recordAuditEvent(ss.Context(), event, err)
+	return err
+}
+
+func newAuditEvent(ctx context.Context, action string) *domain.CreateAuditEventParams {
+	mtdt := extractMetadata(ctx)
+	return &domain.CreateAuditEventParams{
+		Actor:     getUsernameFromContext(ctx),
+		Action:    action,
+		Targets:   domain.AuditTargets{},
+		RequestID: logger.RequestIDFromContext(ctx),
+		ClientIP:  mtdt.ClientIP,
+		UserAgent: mtdt.UserAgent,
+	}
+}
+
+func (h *Handler) recordAuditEvent(ctx context.Context, event *domain.CreateAuditEventParams, err error) {
+	event.Outcome = domain.AuditSuccess
+	if err != nil {
+		event.Outcome = domain.AuditFailure
+		event.Error = status.Convert(err).Message()
+	}
+
+	// the client may already be gone, the event is recorded regardless
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := h.service.Audit.Record(ctx, *event); err != nil {
+		logger.FromContext(ctx).Error("record audit event", "action", event.Action, "err", err)
+	}
+}
+
+// auditTarget adds ids of kind to the event of an audited method.
+func auditTarget(ctx context.Context, kind string, ids ...int) {
+	if event, ok := ctx.Value(auditEventKey{}).(*domain.CreateAuditEventParams); ok {
+		event.Targets.Add(kind, ids...)
+	}
+}
+
+// auditActor names the caller of a public method, like logging in.
+func auditActor(ctx context.Context, username string) {
+	if event, ok := ctx.Value(auditEventKey{}).(*domain.CreateAuditEventParams); ok {
+		event.Actor = username
+	}
+}
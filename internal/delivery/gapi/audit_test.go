@@ -0,0 +1,101 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryAuditInterceptor(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		handlerErr error
+		buildStubs func(store *mock_repository.MockAudit)
+	}{
+		{
+			name:   "Success",
+			method: pb.SimpleBank_FreezeAccount_FullMethodName,
+			buildStubs: func(store *mock_repository.MockAudit) {
+				store.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+						require.Equal(t, domain.CreateAuditEventParams{
+							Actor:     "banker",
+							Action:    domain.AuditAccountFreeze,
+							Targets:   domain.AuditTargets{domain.AuditTargetAccount: {5}},
+							RequestID: "req-1",
+							ClientIP:  "10.0.0.2",
+							UserAgent: "test-agent",
+							Outcome:   domain.AuditSuccess,
+						}, arg)
+						return domain.AuditEvent{}, nil
+					})
+			},
+		},
+		{
+			name:       "Failure",
+			method:     pb.SimpleBank_FreezeAccount_FullMethodName,
+			handlerErr: status.Errorf(codes.PermissionDenied, "role is not allowed"),
+			buildStubs: func(store *mock_repository.MockAudit) {
+				store.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+						require.Equal(t, domain.AuditFailure, arg.Outcome)
+						require.Equal(t, "role is not allowed", arg.Error)
+						return domain.AuditEvent{}, nil
+					})
+			},
+		},
+		{
+			name:   "NotAudited",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			buildStubs: func(store *mock_repository.MockAudit) {
+				store.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockAudit(ctrl)
+			tc.buildStubs(store)
+
+			handler := NewHandler(&service.Service{Audit: service.NewAuditService(store)}, nil)
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+				grpcGatewayUserAgentHeader, "test-agent",
+				xForwardedForHeader, "10.0.0.1, 10.0.0.2",
+				gatewayTokenHeader, gatewayToken,
+			))
+			ctx = logger.WithRequestID(ctx, slog.Default(), "req-1")
+			ctx = auth.WithIdentity(ctx, auth.Identity{Username: "banker", Role: util.BankerRole})
+
+			next := func(ctx context.Context, req interface{}) (interface{}, error) {
+				auditTarget(ctx, domain.AuditTargetAccount, 5)
+				return nil, tc.handlerErr
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			_, err := handler.UnaryAuditInterceptor(ctx, nil, info, next)
+			require.Equal(t, tc.handlerErr, err)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package gapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeaderKey = "authorization"
+	authorizationBearer    = "bearer"
+)
+
+// publicMethods can be called without an access token.
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName: true,
+	pb.SimpleBank_LoginUser_FullMethodName:  true,
+	// authenticated by the refresh token in the request
+	pb.SimpleBank_RenewAccessToken_FullMethodName: true,
+	pb.SimpleBank_LogoutUser_FullMethodName:       true,
+}
+
+func (h *Handler) UnaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	ctx, err := h.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (h *Handler) StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if publicMethods[info.FullMethod] {
+		return handler(srv, ss)
+	}
+
+	ctx, err := h.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate parses the bearer token from the incoming metadata and
+// stores the caller identity in the returned context.
+func (h *Handler) authenticate(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get(authorizationHeaderKey)
+	if len(values) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "missing authorization header")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) != 2 || strings.ToLower(fields[0]) != authorizationBearer {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header")
+	}
+
+	identity, err := h.token.Parse(fields[1])
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid access token: %v", err)
+	}
+
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+// methodRoles lists the roles allowed to call a method. Methods missing from
+// the map are open to every authenticated user.
+var methodRoles = map[string][]string{
+	pb.SimpleBank_ListCustomerAccounts_FullMethodName: {util.BankerRole},
+	pb.SimpleBank_SetOverdraftLimit_FullMethodName:    {util.BankerRole},
+	pb.SimpleBank_FreezeAccount_FullMethodName:        {util.BankerRole},
+	pb.SimpleBank_UnfreezeAccount_FullMethodName:      {util.BankerRole},
+	pb.SimpleBank_ReverseTransfer_FullMethodName:      {util.BankerRole},
+	pb.SimpleBank_Deposit_FullMethodName:              {util.BankerRole, util.ProcessorRole},
+	pb.SimpleBank_Withdraw_FullMethodName:             {util.BankerRole, util.ProcessorRole},
+}
+
+// UnaryRoleInterceptor must run after UnaryAuthInterceptor, since it relies
+// on the identity stored in the context.
+func (h *Handler) UnaryRoleInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (h *Handler) StreamRoleInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func authorize(ctx context.Context, method string) error {
+	roles, ok := methodRoles[method]
+	if !ok {
+		return nil
+	}
+
+	identity, _ := auth.IdentityFromContext(ctx)
+	for _, role := range roles {
+		if identity.Role == role {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "role %q is not allowed to call %s", identity.Role, method)
+}
+
+// serverStream overrides the context of the wrapped stream so handlers see
+// the authenticated identity.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
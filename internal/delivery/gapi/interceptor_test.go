@@ -0,0 +1,187 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func addAuthorization(t *testing.T, ctx context.Context, token auth.TokenManager, authorizationType string, username string, role string, duration time.Duration) context.Context {
+	accessToken, err := token.NewJWT(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, accessToken)
+
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, accessToken)
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(authorizationHeaderKey, authorizationHeader))
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	testCases := []struct {
+		name         string
+		method       string
+		buildContext func(t *testing.T) context.Context
+		checkResult  func(t *testing.T, identity auth.Identity, called bool, err error)
+	}{
+		{
+			name:   "OK",
+			method: pb.SimpleBank_CreateAccount_FullMethodName,
+			buildContext: func(t *testing.T) context.Context {
+				return addAuthorization(t, context.Background(), token, "Bearer", "user", util.DepositorRole, time.Minute)
+			},
+			checkResult: func(t *testing.T, identity auth.Identity, called bool, err error) {
+				require.NoError(t, err)
+				require.True(t, called)
+				require.Equal(t, "user", identity.Username)
+				require.Equal(t, util.DepositorRole, identity.Role)
+			},
+		},
+		{
+			name:   "PublicMethod",
+			method: pb.SimpleBank_LoginUser_FullMethodName,
+			buildContext: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			checkResult: func(t *testing.T, identity auth.Identity, called bool, err error) {
+				require.NoError(t, err)
+				require.True(t, called)
+				require.Empty(t, identity.Username)
+			},
+		},
+		{
+			name:   "NoMetadata",
+			method: pb.SimpleBank_CreateAccount_FullMethodName,
+			buildContext: func(t *testing.T) context.Context {
+				return context.Background()
+			},
+			checkResult: func(t *testing.T, identity auth.Identity, called bool, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.False(t, called)
+			},
+		},
+		{
+			name:   "InvalidAuthorizationType",
+			method: pb.SimpleBank_CreateAccount_FullMethodName,
+			buildContext: func(t *testing.T) context.Context {
+				return addAuthorization(t, context.Background(), token, "Basic", "user", util.DepositorRole, time.Minute)
+			},
+			checkResult: func(t *testing.T, identity auth.Identity, called bool, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.False(t, called)
+			},
+		},
+		{
+			name:   "ExpiredToken",
+			method: pb.SimpleBank_CreateTransfer_FullMethodName,
+			buildContext: func(t *testing.T) context.Context {
+				return addAuthorization(t, context.Background(), token, "Bearer", "user", util.DepositorRole, -time.Minute)
+			},
+			checkResult: func(t *testing.T, identity auth.Identity, called bool, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.False(t, called)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(nil, token)
+
+			var identity auth.Identity
+			called := false
+			next := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				identity, _ = auth.IdentityFromContext(ctx)
+				return nil, nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			_, err := handler.UnaryAuthInterceptor(tc.buildContext(t), nil, info, next)
+			tc.checkResult(t, identity, called, err)
+		})
+	}
+}
+
+func TestUnaryRoleInterceptor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		identity auth.Identity
+		code     codes.Code
+	}{
+		{
+			name:     "Banker",
+			method:   pb.SimpleBank_ListCustomerAccounts_FullMethodName,
+			identity: auth.Identity{Username: "banker", Role: util.BankerRole},
+			code:     codes.OK,
+		},
+		{
+			name:     "Depositor",
+			method:   pb.SimpleBank_ListCustomerAccounts_FullMethodName,
+			identity: auth.Identity{Username: "user", Role: util.DepositorRole},
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "ProcessorDeposit",
+			method:   pb.SimpleBank_Deposit_FullMethodName,
+			identity: auth.Identity{Username: "processor", Role: util.ProcessorRole},
+			code:     codes.OK,
+		},
+		{
+			name:     "DepositorWithdraw",
+			method:   pb.SimpleBank_Withdraw_FullMethodName,
+			identity: auth.Identity{Username: "user", Role: util.DepositorRole},
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:   "NoIdentity",
+			method: pb.SimpleBank_ListCustomerAccounts_FullMethodName,
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:     "UnrestrictedMethod",
+			method:   pb.SimpleBank_ListAccounts_FullMethodName,
+			identity: auth.Identity{Username: "user", Role: util.DepositorRole},
+			code:     codes.OK,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(nil, nil)
+
+			called := false
+			next := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			}
+
+			ctx := context.Background()
+			if tc.identity.Username != "" {
+				ctx = auth.WithIdentity(ctx, tc.identity)
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			_, err := handler.UnaryRoleInterceptor(ctx, nil, info, next)
+			require.Equal(t, tc.code, status.Code(err))
+			require.Equal(t, tc.code == codes.OK, called)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/begenov/backend/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestIDHeader = "x-request-id"
+	// maxRequestIDLength keeps ids sent by clients from bloating the logs.
+	maxRequestIDLength = 128
+)
+
+// UnaryLoggingInterceptor takes the request id from the x-request-id
+// metadata, or makes one up, and sends it back in the response header. The
+// id and a logger carrying it are stored in the context for the layers
+// below. Every call is logged once it returns. It goes first in the chain so
+// the other interceptors see the id.
+func UnaryLoggingInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		ctx = withRequestID(ctx, log)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, logger.RequestIDFromContext(ctx)))
+
+		resp, err := handler(ctx, req)
+		logCall(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func StreamLoggingInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		ctx := withRequestID(ss.Context(), log)
+		_ = ss.SetHeader(metadata.Pairs(requestIDHeader, logger.RequestIDFromContext(ctx)))
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func withRequestID(ctx context.Context, log *slog.Logger) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDHeader); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" || len(id) > maxRequestIDLength {
+		id = logger.NewRequestID()
+	}
+	return logger.WithRequestID(ctx, log, id)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("client_ip", extractMetadata(ctx).ClientIP),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("err", status.Convert(err).Message()))
+	}
+	logger.FromContext(ctx).LogAttrs(ctx, level, "grpc request", attrs...)
+}
+
+// GatewayLogger does for the gateway what the interceptors do for gRPC. The
+// request id it settles on is put back in the X-Request-ID header, which
+// IncomingHeaderMatcher forwards, so the gateway and the gRPC server log the
+// same id for a call.
+func GatewayLogger(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = logger.NewRequestID()
+		}
+		r.Header.Set(requestIDHeader, id)
+		w.Header().Set(requestIDHeader, id)
+
+		ctx := logger.WithRequestID(r.Context(), log, id)
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.FromContext(ctx).LogAttrs(ctx, level, "gateway request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rw.status),
+			slog.Duration("latency", time.Since(start)),
+		)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
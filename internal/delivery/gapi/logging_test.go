@@ -0,0 +1,110 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryLoggingInterceptor(t *testing.T) {
+	testCases := []struct {
+		name       string
+		md         metadata.MD
+		handlerErr error
+		checkLine  func(t *testing.T, id string, line map[string]interface{})
+	}{
+		{
+			name: "FromMetadata",
+			md:   metadata.Pairs(requestIDHeader, "req-1"),
+			checkLine: func(t *testing.T, id string, line map[string]interface{}) {
+				require.Equal(t, "req-1", id)
+				require.Equal(t, "INFO", line["level"])
+				require.Equal(t, codes.OK.String(), line["code"])
+			},
+		},
+		{
+			name:       "Generated",
+			handlerErr: status.Errorf(codes.Internal, "boom"),
+			checkLine: func(t *testing.T, id string, line map[string]interface{}) {
+				require.Len(t, id, 32)
+				require.Equal(t, "ERROR", line["level"])
+				require.Equal(t, codes.Internal.String(), line["code"])
+				require.Equal(t, "boom", line["err"])
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log, err := logger.New(&buf, "info", logger.FormatJSON)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			if tc.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tc.md)
+			}
+
+			var seen string
+			next := func(ctx context.Context, req interface{}) (interface{}, error) {
+				seen = logger.RequestIDFromContext(ctx)
+				return nil, tc.handlerErr
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_GetAccount_FullMethodName}
+			_, err = UnaryLoggingInterceptor(log)(ctx, nil, info, next)
+			require.Equal(t, tc.handlerErr, err)
+
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+			require.Equal(t, "grpc request", line["msg"])
+			require.Equal(t, pb.SimpleBank_GetAccount_FullMethodName, line["method"])
+			require.Equal(t, seen, line[logger.RequestIDKey])
+			require.Contains(t, line, "latency")
+			tc.checkLine(t, seen, line)
+		})
+	}
+}
+
+func TestGatewayLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := logger.New(&buf, "info", logger.FormatJSON)
+	require.NoError(t, err)
+
+	var forwarded string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// what grpc-gateway turns into metadata
+		forwarded = r.Header.Get(requestIDHeader)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	request, err := http.NewRequest(http.MethodGet, "/v1/accounts/1", nil)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	GatewayLogger(log, next).ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	id := recorder.Header().Get(requestIDHeader)
+	require.Len(t, id, 32)
+	require.Equal(t, id, forwarded)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "gateway request", line["msg"])
+	require.Equal(t, id, line[logger.RequestIDKey])
+	require.EqualValues(t, http.StatusNotFound, line["status"])
+}
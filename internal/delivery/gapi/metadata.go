@@ -0,0 +1,85 @@
+package gapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	grpcGatewayUserAgentHeader = "grpcgateway-user-agent"
+	userAgentHeader            = "user-agent"
+	xForwardedForHeader        = "x-forwarded-for"
+	gatewayTokenHeader         = "x-gateway-token"
+)
+
+// gatewayToken is attached by the in-process gateway to every call, so that
+// the server can tell its calls apart from ones made by clients directly.
+var gatewayToken = newGatewayToken()
+
+func newGatewayToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func gatewayUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, gatewayTokenHeader, gatewayToken), method, req, reply, cc, opts...)
+}
+
+func gatewayStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(metadata.AppendToOutgoingContext(ctx, gatewayTokenHeader, gatewayToken), desc, cc, method, opts...)
+}
+
+// fromGateway reports whether the call carries the token of the gateway.
+func fromGateway(md metadata.MD) bool {
+	for _, token := range md.Get(gatewayTokenHeader) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(gatewayToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+type clientMetadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+// extractMetadata returns the user agent and address of the client. Calls
+// coming through the gateway carry the original HTTP values in metadata.
+// X-Forwarded-For is only believed on those calls and only its last entry,
+// the one the gateway appended, counts; the rest came from the client.
+func extractMetadata(ctx context.Context) clientMetadata {
+	var mtdt clientMetadata
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		} else if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		}
+
+		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 && fromGateway(md) {
+			// the gateway adds its value after the ones it forwards
+			entries := strings.Split(clientIPs[len(clientIPs)-1], ",")
+			mtdt.ClientIP = strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+
+	if mtdt.ClientIP == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			mtdt.ClientIP = p.Addr.String()
+		}
+	}
+
+	return mtdt
+}
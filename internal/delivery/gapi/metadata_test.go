@@ -0,0 +1,69 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	clientAddr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}
+
+	testCases := []struct {
+		name     string
+		md       metadata.MD
+		clientIP string
+	}{
+		{
+			name:     "Gateway",
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.9, 203.0.113.7", gatewayTokenHeader, gatewayToken),
+			clientIP: "203.0.113.7",
+		},
+		{
+			name: "GatewayForwardedHeader",
+			md: metadata.Pairs(
+				xForwardedForHeader, "203.0.113.9",
+				xForwardedForHeader, "203.0.113.7",
+				gatewayTokenHeader, gatewayToken,
+			),
+			clientIP: "203.0.113.7",
+		},
+		{
+			name:     "Direct",
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.9"),
+			clientIP: clientAddr.String(),
+		},
+		{
+			name:     "WrongToken",
+			md:       metadata.Pairs(xForwardedForHeader, "203.0.113.9", gatewayTokenHeader, "guess"),
+			clientIP: clientAddr.String(),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: clientAddr})
+			ctx = metadata.NewIncomingContext(ctx, tc.md)
+			require.Equal(t, tc.clientIP, extractMetadata(ctx).ClientIP)
+		})
+	}
+}
+
+func TestGatewayClientInterceptor(t *testing.T) {
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		require.True(t, ok)
+		require.True(t, fromGateway(md))
+		return nil
+	}
+
+	err := gatewayUnaryClientInterceptor(context.Background(), "/test", nil, nil, nil, invoker)
+	require.NoError(t, err)
+}
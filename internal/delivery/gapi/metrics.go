@@ -0,0 +1,31 @@
+package gapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/begenov/backend/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryMetricsInterceptor counts every call and how long it took by method
+// and status code. Calls proxied by the gateway are counted here too.
+func UnaryMetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeCall(info.FullMethod, start, err)
+	return resp, err
+}
+
+func StreamMetricsInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeCall(info.FullMethod, start, err)
+	return err
+}
+
+func observeCall(method string, start time.Time, err error) {
+	metrics.GRPCRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.GRPCRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
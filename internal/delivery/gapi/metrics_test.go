@@ -0,0 +1,35 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryMetricsInterceptor(t *testing.T) {
+	method := pb.SimpleBank_GetAccount_FullMethodName
+	ok := metrics.GRPCRequests.WithLabelValues(method, codes.OK.String())
+	notFound := metrics.GRPCRequests.WithLabelValues(method, codes.NotFound.String())
+	okBefore, notFoundBefore := testutil.ToFloat64(ok), testutil.ToFloat64(notFound)
+
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	for _, handlerErr := range []error{nil, status.Errorf(codes.NotFound, "account not found")} {
+		handlerErr := handlerErr
+		next := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, handlerErr
+		}
+
+		_, err := UnaryMetricsInterceptor(context.Background(), nil, info, next)
+		require.Equal(t, handlerErr, err)
+	}
+
+	require.Equal(t, okBefore+1, testutil.ToFloat64(ok))
+	require.Equal(t, notFoundBefore+1, testutil.ToFloat64(notFound))
+}
@@ -2,20 +2,23 @@ package gapi
 
 import (
 	"context"
-	"log"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/begenov/backend/internal/domain"
 	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/auth"
 	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (h *Handler) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.ResponseAccount, error) {
 	username := getUsernameFromContext(ctx)
-	log.Println(username)
 
 	arg := domain.CreateAccountParams{
 		Owner:    username,
@@ -27,11 +30,12 @@ func (h *Handler) CreateAccount(ctx context.Context, req *pb.CreateAccountReques
 	if err != nil {
 
 		if e.ErrorCode(err) == e.UniqueViolation {
-			return nil, status.Errorf(codes.AlreadyExists, "method CreateUser Already Exists", err)
+			return nil, status.Errorf(codes.AlreadyExists, "method CreateUser Already Exists: %v", err)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
 
 	}
+	auditTarget(ctx, domain.AuditTargetAccount, account.ID)
 
 	createdTime := time.Now().Unix()
 	response := &pb.ResponseAccount{
@@ -48,9 +52,195 @@ func (h *Handler) CreateAccount(ctx context.Context, req *pb.CreateAccountReques
 	return response, nil
 }
 
+func (h *Handler) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.ResponseAccount, error) {
+	if req.GetId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid account id: %d", req.GetId())
+	}
+
+	account, err := h.accountOwnedByUser(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return convertAccount(account), nil
+}
+
+func (h *Handler) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	if err := validatePage(req.GetPageId(), req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	return h.listAccounts(ctx, getUsernameFromContext(ctx), req.GetPageId(), req.GetPageSize())
+}
+
+// ListCustomerAccounts lets bankers list the accounts of any customer.
+func (h *Handler) ListCustomerAccounts(ctx context.Context, req *pb.ListCustomerAccountsRequest) (*pb.ListAccountsResponse, error) {
+	if req.GetOwner() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "owner is required")
+	}
+	if err := validatePage(req.GetPageId(), req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	return h.listAccounts(ctx, req.GetOwner(), req.GetPageId(), req.GetPageSize())
+}
+
+// SetOverdraftLimit lets bankers allow an account to go negative.
+func (h *Handler) SetOverdraftLimit(ctx context.Context, req *pb.SetOverdraftLimitRequest) (*pb.ResponseAccount, error) {
+	if req.GetAccountId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid account id: %d", req.GetAccountId())
+	}
+	if req.GetOverdraftLimit() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "overdraft_limit must not be negative")
+	}
+	auditTarget(ctx, domain.AuditTargetAccount, int(req.GetAccountId()))
+
+	account, err := h.service.Account.SetOverdraftLimit(ctx, domain.SetOverdraftLimitParams{
+		ID:             int(req.GetAccountId()),
+		OverdraftLimit: int(req.GetOverdraftLimit()),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "account [%d] not found", req.GetAccountId())
+		}
+		return nil, status.Errorf(codes.Internal, "failed to set overdraft limit: %v", err)
+	}
+
+	return convertAccount(account), nil
+}
+
+// FreezeAccount lets bankers block all money movement on an account.
+func (h *Handler) FreezeAccount(ctx context.Context, req *pb.AccountStatusRequest) (*pb.ResponseAccount, error) {
+	if req.GetReason() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "reason is required")
+	}
+	return h.changeAccountStatus(ctx, req, domain.AccountFrozen)
+}
+
+// UnfreezeAccount lets bankers lift a freeze.
+func (h *Handler) UnfreezeAccount(ctx context.Context, req *pb.AccountStatusRequest) (*pb.ResponseAccount, error) {
+	if req.GetReason() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "reason is required")
+	}
+	return h.changeAccountStatus(ctx, req, domain.AccountActive)
+}
+
+// CloseAccount closes one of the caller's accounts for good. The balance has
+// to be brought to zero first.
+func (h *Handler) CloseAccount(ctx context.Context, req *pb.AccountStatusRequest) (*pb.ResponseAccount, error) {
+	if req.GetAccountId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid account id: %d", req.GetAccountId())
+	}
+	account, err := h.accountOwnedByUser(ctx, int(req.GetAccountId()))
+	if err != nil {
+		return nil, err
+	}
+
+	// bankers freeze accounts, only the owner may close one
+	identity, _ := auth.IdentityFromContext(ctx)
+	if account.Owner != identity.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account [%d] doesn't belong to the authenticated user", account.ID)
+	}
+	return h.changeAccountStatus(ctx, req, domain.AccountClosed)
+}
+
+func (h *Handler) changeAccountStatus(ctx context.Context, req *pb.AccountStatusRequest, to string) (*pb.ResponseAccount, error) {
+	if req.GetAccountId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid account id: %d", req.GetAccountId())
+	}
+	if len(req.GetReason()) > 255 {
+		return nil, status.Errorf(codes.InvalidArgument, "reason is longer than 255 characters")
+	}
+	auditTarget(ctx, domain.AuditTargetAccount, int(req.GetAccountId()))
+
+	account, err := h.service.Account.ChangeAccountStatus(ctx, domain.ChangeAccountStatusParams{
+		ID:        int(req.GetAccountId()),
+		Status:    to,
+		Reason:    req.GetReason(),
+		ChangedBy: getUsernameFromContext(ctx),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, status.Errorf(codes.NotFound, "account [%d] not found", req.GetAccountId())
+		case errors.Is(err, e.ErrInvalidStatusTransition), errors.Is(err, e.ErrNonZeroBalance):
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to change account status: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to change account status: %v", err)
+		}
+	}
+
+	return convertAccount(account), nil
+}
+
+func (h *Handler) listAccounts(ctx context.Context, owner string, pageID, pageSize int32) (*pb.ListAccountsResponse, error) {
+	arg := domain.ListAccountsParams{
+		Owner:  owner,
+		Limit:  int(pageSize),
+		Offset: int((pageID - 1) * pageSize),
+	}
+
+	accounts, err := h.service.Account.ListAccounts(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list accounts: %v", err)
+	}
+
+	rsp := &pb.ListAccountsResponse{
+		Accounts: make([]*pb.ResponseAccount, 0, len(accounts)),
+	}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+
+	return rsp, nil
+}
+
+// accountOwnedByUser loads the account and checks that it belongs to the
+// authenticated user. Bankers may access any account.
+func (h *Handler) accountOwnedByUser(ctx context.Context, accountID int) (domain.Account, error) {
+	auditTarget(ctx, domain.AuditTargetAccount, accountID)
+
+	account, err := h.service.Account.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %v", err)
+	}
+
+	identity, _ := auth.IdentityFromContext(ctx)
+	if account.Owner != identity.Username && identity.Role != util.BankerRole {
+		return account, status.Errorf(codes.PermissionDenied, "account [%d] doesn't belong to the authenticated user", accountID)
+	}
+
+	return account, nil
+}
+
+func convertAccount(account domain.Account) *pb.ResponseAccount {
+	return &pb.ResponseAccount{
+		ID:             int32(account.ID),
+		Owner:          account.Owner,
+		Balance:        int32(account.Balance),
+		Currency:       account.Currency,
+		CreatedAt:      timestamppb.New(account.CreatedAt),
+		OverdraftLimit: int32(account.OverdraftLimit),
+		Status:         account.Status,
+	}
+}
+
+func validatePage(pageID, pageSize int32) error {
+	if pageID < 1 {
+		return status.Errorf(codes.InvalidArgument, "page_id must be at least 1")
+	}
+	if pageSize < 5 || pageSize > 10 {
+		return status.Errorf(codes.InvalidArgument, "page_size must be between 5 and 10")
+	}
+	return nil
+}
+
 func getUsernameFromContext(ctx context.Context) string {
-	if username, ok := ctx.Value("user").(string); ok {
-		return username
+	if identity, ok := auth.IdentityFromContext(ctx); ok {
+		return identity.Username
 	}
 	return ""
 }
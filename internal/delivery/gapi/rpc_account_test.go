@@ -0,0 +1,259 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetAccountRPC(t *testing.T) {
+	user := util.RandomOwner()
+	account := randomAccount(user)
+
+	testCases := []struct {
+		name          string
+		req           *pb.GetAccountRequest
+		buildContext  func() context.Context
+		buildStubs    func(store *mock_repository.MockAccount)
+		checkResponse func(t *testing.T, rsp *pb.ResponseAccount, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.GetAccountRequest{Id: int32(account.ID)},
+			buildContext: func() context.Context {
+				return contextWithUser(user)
+			},
+			buildStubs: func(store *mock_repository.MockAccount) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(account.ID), rsp.GetID())
+				require.Equal(t, account.Owner, rsp.GetOwner())
+				require.Equal(t, int32(account.Balance), rsp.GetBalance())
+				require.Equal(t, account.Currency, rsp.GetCurrency())
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			req:  &pb.GetAccountRequest{Id: int32(account.ID)},
+			buildContext: func() context.Context {
+				return contextWithUser("unauthorized_user")
+			},
+			buildStubs: func(store *mock_repository.MockAccount) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+				require.Nil(t, rsp)
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.GetAccountRequest{Id: int32(account.ID)},
+			buildContext: func() context.Context {
+				return contextWithUser(user)
+			},
+			buildStubs: func(store *mock_repository.MockAccount) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.GetAccountRequest{Id: 0},
+			buildContext: func() context.Context {
+				return contextWithUser(user)
+			},
+			buildStubs: func(store *mock_repository.MockAccount) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockAccount(ctrl)
+			tc.buildStubs(store)
+
+			handler := NewHandler(&service.Service{
+				Account: service.NewAccountService(store, nil),
+			}, nil)
+
+			rsp, err := handler.GetAccount(tc.buildContext(), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestListAccountsRPC(t *testing.T) {
+	user := util.RandomOwner()
+	accounts := []domain.Account{randomAccount(user), randomAccount(user)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock_repository.NewMockAccount(ctrl)
+	arg := domain.ListAccountsParams{
+		Owner:  user,
+		Limit:  5,
+		Offset: 5,
+	}
+	store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
+
+	handler := NewHandler(&service.Service{
+		Account: service.NewAccountService(store, nil),
+	}, nil)
+
+	rsp, err := handler.ListAccounts(contextWithUser(user), &pb.ListAccountsRequest{PageId: 2, PageSize: 5})
+	require.NoError(t, err)
+	require.Len(t, rsp.GetAccounts(), len(accounts))
+	for _, account := range rsp.GetAccounts() {
+		require.Equal(t, user, account.GetOwner())
+	}
+}
+
+func TestAccountStatusRPC(t *testing.T) {
+	user := util.RandomOwner()
+	account := randomAccount(user)
+
+	frozen := account
+	frozen.Status = domain.AccountFrozen
+
+	testCases := []struct {
+		name          string
+		call          func(h *Handler, ctx context.Context, req *pb.AccountStatusRequest) (*pb.ResponseAccount, error)
+		req           *pb.AccountStatusRequest
+		username      string
+		role          string
+		buildStubs    func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx)
+		checkResponse func(t *testing.T, rsp *pb.ResponseAccount, err error)
+	}{
+		{
+			name:     "Freeze",
+			call:     (*Handler).FreezeAccount,
+			req:      &pb.AccountStatusRequest{AccountId: int32(account.ID), Reason: "fraud"},
+			username: "banker",
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				arg := domain.ChangeAccountStatusParams{
+					ID:        account.ID,
+					Status:    domain.AccountFrozen,
+					Reason:    "fraud",
+					ChangedBy: "banker",
+				}
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.AccountFrozen, rsp.GetStatus())
+			},
+		},
+		{
+			name:     "FreezeWithoutReason",
+			call:     (*Handler).FreezeAccount,
+			req:      &pb.AccountStatusRequest{AccountId: int32(account.ID)},
+			username: "banker",
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:     "UnfreezeActive",
+			call:     (*Handler).UnfreezeAccount,
+			req:      &pb.AccountStatusRequest{AccountId: int32(account.ID), Reason: "cleared"},
+			username: "banker",
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, e.ErrInvalidStatusTransition)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name:     "Close",
+			call:     (*Handler).CloseAccount,
+			req:      &pb.AccountStatusRequest{AccountId: int32(account.ID)},
+			username: user,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, e.ErrNonZeroBalance)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name:     "CloseBanker",
+			call:     (*Handler).CloseAccount,
+			req:      &pb.AccountStatusRequest{AccountId: int32(account.ID)},
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:     "CloseNotOwner",
+			call:     (*Handler).CloseAccount,
+			req:      &pb.AccountStatusRequest{AccountId: int32(account.ID)},
+			username: "someone_else",
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ResponseAccount, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_repository.NewMockAccount(ctrl)
+			store := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(accounts, store)
+
+			handler := NewHandler(&service.Service{
+				Account: service.NewAccountService(accounts, store),
+			}, nil)
+
+			ctx := auth.WithIdentity(context.Background(), auth.Identity{Username: tc.username, Role: tc.role})
+			rsp, err := tc.call(handler, ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package gapi
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BatchTransfer reads transfers from the client stream and books them once
+// the client closes it. Every from account has to belong to the caller and
+// hold the given currency, otherwise the whole batch is rejected before
+// anything is booked.
+func (h *Handler) BatchTransfer(stream pb.SimpleBank_BatchTransferServer) error {
+	ctx := stream.Context()
+	username := getUsernameFromContext(ctx)
+
+	var arg domain.BatchTransferParams
+	// payroll batches repeat the same from account, look each one up once
+	checked := make(map[int32]string)
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(arg.Transfers) == 0 {
+			arg.Mode = req.GetMode()
+			if arg.Mode != domain.BatchAtomic && arg.Mode != domain.BatchBestEffort {
+				return status.Errorf(codes.InvalidArgument, "unsupported mode: %q", arg.Mode)
+			}
+		}
+		if len(arg.Transfers) == domain.MaxBatchTransfers {
+			return status.Errorf(codes.InvalidArgument, "batch holds more than %d transfers", domain.MaxBatchTransfers)
+		}
+
+		transfer := req.GetTransfer()
+		if transfer.GetFromAccountId() < 1 || transfer.GetToAccountId() < 1 {
+			return status.Errorf(codes.InvalidArgument, "transfer %d: invalid account id", len(arg.Transfers))
+		}
+		auditTarget(ctx, domain.AuditTargetAccount, int(transfer.GetFromAccountId()), int(transfer.GetToAccountId()))
+		if transfer.GetAmount() <= 0 {
+			return status.Errorf(codes.InvalidArgument, "transfer %d: amount must be positive", len(arg.Transfers))
+		}
+		if !util.IsSupportedCurrency(transfer.GetCurrency()) {
+			return status.Errorf(codes.InvalidArgument, "transfer %d: unsupported currency: %s", len(arg.Transfers), transfer.GetCurrency())
+		}
+
+		if currency, ok := checked[transfer.GetFromAccountId()]; !ok || currency != transfer.GetCurrency() {
+			account, err := h.validAccount(ctx, int(transfer.GetFromAccountId()), transfer.GetCurrency())
+			if err != nil {
+				return err
+			}
+
+			if account.Owner != username {
+				return status.Errorf(codes.PermissionDenied, "from account [%d] doesn't belong to the authenticated user", account.ID)
+			}
+			checked[transfer.GetFromAccountId()] = transfer.GetCurrency()
+		}
+
+		arg.Transfers = append(arg.Transfers, domain.TransferTxParams{
+			FromAccountID: int(transfer.GetFromAccountId()),
+			ToAccountID:   int(transfer.GetToAccountId()),
+			Amount:        int(transfer.GetAmount()),
+		})
+	}
+
+	if len(arg.Transfers) == 0 {
+		return status.Errorf(codes.InvalidArgument, "batch holds no transfers")
+	}
+
+	result, err := h.service.TransferTx.BatchTransfer(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrInsufficientFunds), errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+			return status.Errorf(codes.FailedPrecondition, "failed to book batch: %v", err)
+		case errors.Is(err, sql.ErrNoRows):
+			return status.Errorf(codes.NotFound, "failed to book batch: %v", err)
+		case errors.Is(err, e.ErrRateNotFound), errors.Is(err, e.ErrAmountTooSmall):
+			return status.Errorf(codes.InvalidArgument, "failed to book batch: %v", err)
+		case errors.Is(err, e.ErrSettlementAccount):
+			return status.Errorf(codes.PermissionDenied, "failed to book batch: %v", err)
+		default:
+			return status.Errorf(codes.Internal, "failed to book batch: %v", err)
+		}
+	}
+
+	for _, item := range result.Items {
+		if item.Result != nil {
+			auditTarget(ctx, domain.AuditTargetTransfer, item.Result.Transfer.ID)
+		}
+	}
+
+	return stream.SendAndClose(convertBatchTransferResult(result))
+}
+
+func convertBatchTransferResult(result domain.BatchTransferResult) *pb.BatchTransferResponse {
+	rsp := &pb.BatchTransferResponse{
+		Mode:      result.Mode,
+		Items:     make([]*pb.BatchTransferItem, 0, len(result.Items)),
+		Succeeded: int32(result.Succeeded),
+		Failed:    int32(result.Failed),
+	}
+
+	for _, item := range result.Items {
+		converted := &pb.BatchTransferItem{
+			Index: int32(item.Index),
+			Error: item.Error,
+		}
+		if item.Result != nil {
+			converted.Result = &pb.CreateTransferResponse{
+				Transfer:    convertTransfer(item.Result.Transfer),
+				FromAccount: convertAccount(item.Result.FromAccount),
+				ToAccount:   convertAccount(item.Result.ToAccount),
+				FromEntry:   convertEntry(item.Result.FromEntry),
+				ToEntry:     convertEntry(item.Result.ToEntry),
+			}
+		}
+		rsp.Items = append(rsp.Items, converted)
+	}
+
+	return rsp
+}
@@ -0,0 +1,226 @@
+package gapi
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatchTransferRPC(t *testing.T) {
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+
+	account1 := randomAccount(user1)
+	account2 := randomAccount(user2)
+	account3 := randomAccount(user2)
+	account1.ID, account2.ID, account3.ID = 1, 2, 3
+	account1.Currency, account2.Currency, account3.Currency = util.CAD, util.CAD, util.CAD
+
+	settlement := randomAccount(domain.SettlementOwner)
+	settlement.ID, settlement.Currency = 4, util.CAD
+
+	transfer := func(mode string, from, to domain.Account, amount int32) *pb.BatchTransferRequest {
+		return &pb.BatchTransferRequest{
+			Mode: mode,
+			Transfer: &pb.CreateTransferRequest{
+				FromAccountId: int32(from.ID),
+				ToAccountId:   int32(to.ID),
+				Amount:        amount,
+				Currency:      util.CAD,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		reqs          []*pb.BatchTransferRequest
+		username      string
+		buildStubs    func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx)
+		checkResponse func(t *testing.T, rsp *pb.BatchTransferResponse, err error)
+	}{
+		{
+			name: "Atomic",
+			reqs: []*pb.BatchTransferRequest{
+				transfer(domain.BatchAtomic, account1, account2, 10),
+				transfer("", account1, account3, 20),
+			},
+			username: user1,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(3).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+
+				arg := []domain.TransferTxParams{
+					{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, ToAmount: 10, ExchangeRate: 1},
+					{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 20, ToAmount: 20, ExchangeRate: 1},
+				}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]domain.TransferTxResult{
+					{Transfer: domain.Transfer{ID: 1, Amount: 10}},
+					{Transfer: domain.Transfer{ID: 2, Amount: 20}},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.BatchAtomic, rsp.GetMode())
+				require.Equal(t, int32(2), rsp.GetSucceeded())
+				require.Len(t, rsp.GetItems(), 2)
+				require.Equal(t, int32(2), rsp.GetItems()[1].GetResult().GetTransfer().GetId())
+			},
+		},
+		{
+			name: "BestEffort",
+			reqs: []*pb.BatchTransferRequest{
+				transfer(domain.BatchBestEffort, account1, account2, 10),
+				transfer("", account1, account3, 20),
+			},
+			username: user1,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(3).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+
+				gomock.InOrder(
+					store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{Transfer: domain.Transfer{ID: 1}}, nil),
+					store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, e.ErrInsufficientFunds),
+				)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(1), rsp.GetSucceeded())
+				require.Equal(t, int32(1), rsp.GetFailed())
+				require.Equal(t, int32(1), rsp.GetItems()[0].GetResult().GetTransfer().GetId())
+				require.Equal(t, e.ErrInsufficientFunds.Error(), rsp.GetItems()[1].GetError())
+				require.Nil(t, rsp.GetItems()[1].GetResult())
+			},
+		},
+		{
+			name: "AtomicInsufficientFunds",
+			reqs: []*pb.BatchTransferRequest{
+				transfer(domain.BatchAtomic, account1, account2, 10),
+			},
+			username: user1,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(nil, &e.BatchTransferError{Index: 0, Err: e.ErrInsufficientFunds})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "SettlementAccount",
+			reqs: []*pb.BatchTransferRequest{
+				transfer(domain.BatchAtomic, settlement, account2, 10),
+			},
+			username: domain.SettlementOwner,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(settlement.ID)).Times(2).Return(settlement, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "NotOwner",
+			reqs: []*pb.BatchTransferRequest{
+				transfer(domain.BatchAtomic, account2, account3, 10),
+				transfer("", account1, account3, 20),
+			},
+			username: user2,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidMode",
+			reqs: []*pb.BatchTransferRequest{
+				transfer("sometimes", account1, account2, 10),
+			},
+			username: user1,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:     "Empty",
+			username: user1,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.BatchTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_repository.NewMockAccount(ctrl)
+			store := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(accounts, store)
+
+			handler := NewHandler(&service.Service{
+				Account:    service.NewAccountService(accounts, nil),
+				TransferTx: service.NewTransferService(store, accounts, testRates),
+			}, nil)
+
+			stream := &batchTransferStream{ctx: contextWithUser(tc.username), reqs: tc.reqs}
+			err := handler.BatchTransfer(stream)
+			tc.checkResponse(t, stream.rsp, err)
+		})
+	}
+}
+
+// batchTransferStream feeds reqs to the handler and keeps the response.
+type batchTransferStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	reqs []*pb.BatchTransferRequest
+	rsp  *pb.BatchTransferResponse
+}
+
+func (s *batchTransferStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *batchTransferStream) Recv() (*pb.BatchTransferRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *batchTransferStream) SendAndClose(rsp *pb.BatchTransferResponse) error {
+	s.rsp = rsp
+	return nil
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/e"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *Handler) Deposit(ctx context.Context, req *pb.CashRequest) (*pb.CashResponse, error) {
+	arg, err := h.cashParams(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.service.Cash.Deposit(ctx, arg)
+	if err != nil {
+		return nil, cashError("deposit", err)
+	}
+	auditTarget(ctx, domain.AuditTargetTransfer, result.Transfer.ID)
+	return convertCashResult(result), nil
+}
+
+func (h *Handler) Withdraw(ctx context.Context, req *pb.CashRequest) (*pb.CashResponse, error) {
+	arg, err := h.cashParams(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.service.Cash.Withdraw(ctx, arg)
+	if err != nil {
+		return nil, cashError("withdraw", err)
+	}
+	auditTarget(ctx, domain.AuditTargetTransfer, result.Transfer.ID)
+	return convertCashResult(result), nil
+}
+
+func (h *Handler) cashParams(ctx context.Context, req *pb.CashRequest) (domain.CashParams, error) {
+	if req.GetAmount() <= 0 {
+		return domain.CashParams{}, status.Errorf(codes.InvalidArgument, "amount must be positive")
+	}
+	if req.GetExternalRef() == "" || len(req.GetExternalRef()) > 255 {
+		return domain.CashParams{}, status.Errorf(codes.InvalidArgument, "external_ref must be 1 to 255 characters")
+	}
+
+	// the role interceptor only lets bankers and the processor through, who
+	// book cash on any customer's account
+	auditTarget(ctx, domain.AuditTargetAccount, int(req.GetAccountId()))
+	account, err := h.validAccount(ctx, int(req.GetAccountId()), req.GetCurrency())
+	if err != nil {
+		return domain.CashParams{}, err
+	}
+
+	return domain.CashParams{
+		AccountID:   account.ID,
+		Amount:      int(req.GetAmount()),
+		ExternalRef: req.GetExternalRef(),
+	}, nil
+}
+
+func cashError(op string, err error) error {
+	switch {
+	case errors.Is(err, e.ErrDuplicateExternalRef):
+		return status.Errorf(codes.AlreadyExists, "failed to %s: %v", op, err)
+	case errors.Is(err, e.ErrInsufficientFunds), errors.Is(err, e.ErrNoSettlementAccount),
+		errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+		return status.Errorf(codes.FailedPrecondition, "failed to %s: %v", op, err)
+	default:
+		return status.Errorf(codes.Internal, "failed to %s: %v", op, err)
+	}
+}
+
+func convertCashResult(result domain.CashTxResult) *pb.CashResponse {
+	return &pb.CashResponse{
+		Transfer: convertTransfer(result.Transfer),
+		Account:  convertAccount(result.Account),
+		Entry:    convertEntry(result.Entry),
+	}
+}
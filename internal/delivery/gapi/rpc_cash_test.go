@@ -0,0 +1,201 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCashRPC(t *testing.T) {
+	amount := 100
+	account := randomAccount(util.RandomOwner())
+	account.Currency = util.CAD
+
+	// no settlement account exists for EUR
+	account2 := randomAccount(account.Owner)
+	account2.Currency = util.EUR
+
+	settlement := domain.Account{ID: 1001, Owner: domain.SettlementOwner, Currency: util.CAD}
+	externalRef := util.RandomString(16)
+
+	testCases := []struct {
+		name          string
+		withdraw      bool
+		req           *pb.CashRequest
+		buildContext  func() context.Context
+		buildStubs    func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx)
+		checkResponse func(t *testing.T, rsp *pb.CashResponse, err error)
+	}{
+		{
+			name: "Deposit",
+			req: &pb.CashRequest{
+				AccountId:   int32(account.ID),
+				Amount:      int32(amount),
+				Currency:    util.CAD,
+				ExternalRef: externalRef,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser("processor")
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.CAD)).Times(1).Return(settlement, nil)
+
+				arg := domain.CashTxParams{
+					AccountID:           account.ID,
+					SettlementAccountID: 1001,
+					Amount:              amount,
+					ExternalRef:         externalRef,
+				}
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.CashTxResult{
+					Transfer: domain.Transfer{ID: 1, Amount: amount, Kind: domain.TransferKindDeposit, ExternalRef: externalRef},
+					Account:  account,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.TransferKindDeposit, rsp.GetTransfer().GetKind())
+				require.Equal(t, externalRef, rsp.GetTransfer().GetExternalRef())
+				require.Equal(t, int32(account.ID), rsp.GetAccount().GetID())
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			withdraw: true,
+			req: &pb.CashRequest{
+				AccountId:   int32(account.ID),
+				Amount:      int32(amount),
+				Currency:    util.USD,
+				ExternalRef: externalRef,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser("processor")
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "MissingExternalRef",
+			req: &pb.CashRequest{
+				AccountId: int32(account.ID),
+				Amount:    int32(amount),
+				Currency:  util.CAD,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser("processor")
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "DuplicateExternalRef",
+			req: &pb.CashRequest{
+				AccountId:   int32(account.ID),
+				Amount:      int32(amount),
+				Currency:    util.CAD,
+				ExternalRef: externalRef,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser("processor")
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.CAD)).Times(1).Return(settlement, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.CashTxResult{}, e.ErrDuplicateExternalRef)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				require.Equal(t, codes.AlreadyExists, status.Code(err))
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			withdraw: true,
+			req: &pb.CashRequest{
+				AccountId:   int32(account.ID),
+				Amount:      int32(amount),
+				Currency:    util.CAD,
+				ExternalRef: externalRef,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser("processor")
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.CAD)).Times(1).Return(settlement, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.CashTxResult{}, e.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "NoSettlementAccount",
+			req: &pb.CashRequest{
+				AccountId:   int32(account2.ID),
+				Amount:      int32(amount),
+				Currency:    util.EUR,
+				ExternalRef: externalRef,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser("processor")
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.EUR)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CashResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_repository.NewMockAccount(ctrl)
+			store := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(accounts, store)
+
+			handler := NewHandler(&service.Service{
+				Account: service.NewAccountService(accounts, nil),
+				Cash:    service.NewCashService(store, accounts),
+			}, nil)
+
+			var rsp *pb.CashResponse
+			var err error
+			if tc.withdraw {
+				rsp, err = handler.Withdraw(tc.buildContext(), tc.req)
+			} else {
+				rsp, err = handler.Deposit(tc.buildContext(), tc.req)
+			}
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
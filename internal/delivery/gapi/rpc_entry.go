@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *Handler) ListEntries(ctx context.Context, req *pb.ListEntriesRequest) (*pb.ListEntriesResponse, error) {
+	if err := validatePage(req.GetPageId(), req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	if _, err := h.accountOwnedByUser(ctx, int(req.GetAccountId())); err != nil {
+		return nil, err
+	}
+
+	arg := domain.ListEntriesParams{
+		AccountID: int(req.GetAccountId()),
+		Limit:     int(req.GetPageSize()),
+		Offset:    int((req.GetPageId() - 1) * req.GetPageSize()),
+	}
+
+	entries, err := h.service.Entry.ListEntries(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list entries: %v", err)
+	}
+
+	rsp := &pb.ListEntriesResponse{
+		Entries: make([]*pb.Entry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		rsp.Entries = append(rsp.Entries, convertEntry(entry))
+	}
+
+	return rsp, nil
+}
+
+func convertEntry(entry domain.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:        int32(entry.ID),
+		AccountId: int32(entry.AccountID),
+		Amount:    int32(entry.Amount),
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+}
@@ -0,0 +1,61 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *Handler) GetAccountStatement(ctx context.Context, req *pb.GetAccountStatementRequest) (*pb.AccountStatement, error) {
+	if req.GetFrom() == nil || req.GetTo() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "from and to are required")
+	}
+
+	arg := domain.StatementParams{
+		AccountID: int(req.GetAccountId()),
+		From:      req.GetFrom().AsTime(),
+		To:        req.GetTo().AsTime(),
+	}
+	if !arg.To.After(arg.From) {
+		return nil, status.Errorf(codes.InvalidArgument, "to must be after from")
+	}
+
+	if _, err := h.accountOwnedByUser(ctx, arg.AccountID); err != nil {
+		return nil, err
+	}
+
+	statement, err := h.service.Entry.GetStatement(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get statement: %v", err)
+	}
+
+	return convertStatement(statement), nil
+}
+
+func convertStatement(statement domain.Statement) *pb.AccountStatement {
+	rsp := &pb.AccountStatement{
+		AccountId:      int32(statement.AccountID),
+		Currency:       statement.Currency,
+		From:           timestamppb.New(statement.From),
+		To:             timestamppb.New(statement.To),
+		OpeningBalance: int32(statement.OpeningBalance),
+		ClosingBalance: int32(statement.ClosingBalance),
+		Entries:        make([]*pb.StatementEntry, 0, len(statement.Entries)),
+	}
+	for _, entry := range statement.Entries {
+		rsp.Entries = append(rsp.Entries, &pb.StatementEntry{
+			Id:                    int32(entry.ID),
+			Amount:                int32(entry.Amount),
+			Balance:               int32(entry.Balance),
+			TransferId:            int32(entry.TransferID),
+			CounterpartyAccountId: int32(entry.CounterpartyAccountID),
+			CounterpartyOwner:     entry.CounterpartyOwner,
+			CreatedAt:             timestamppb.New(entry.CreatedAt),
+		})
+	}
+	return rsp
+}
@@ -0,0 +1,266 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	idempotencyKeyHeader     = "idempotency-key"
+	idempotentReplayedHeader = "idempotent-replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+func (h *Handler) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	if req.GetFromAccountId() < 1 || req.GetToAccountId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid account id")
+	}
+	if req.GetAmount() <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "amount must be positive")
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported currency: %s", req.GetCurrency())
+	}
+	auditTarget(ctx, domain.AuditTargetAccount, int(req.GetFromAccountId()), int(req.GetToAccountId()))
+
+	idempotencyKey := getIdempotencyKeyFromContext(ctx)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key is too long")
+	}
+
+	account, err := h.validAccount(ctx, int(req.GetFromAccountId()), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Owner != getUsernameFromContext(ctx) {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	// the to account may hold another currency, the amount is converted
+	if _, err := h.getAccount(ctx, int(req.GetToAccountId())); err != nil {
+		return nil, err
+	}
+
+	arg := domain.TransferTxParams{
+		FromAccountID:  int(req.GetFromAccountId()),
+		ToAccountID:    int(req.GetToAccountId()),
+		Amount:         int(req.GetAmount()),
+		Currency:       req.GetCurrency(),
+		IdempotencyKey: idempotencyKey,
+		Owner:          account.Owner,
+	}
+
+	result, err := h.service.TransferTx.TransferTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrIdempotencyKeyConflict), errors.Is(err, e.ErrInsufficientFunds),
+			errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to create transfer: %v", err)
+		case errors.Is(err, e.ErrIdempotencyKeyInProgress):
+			return nil, status.Errorf(codes.Aborted, "failed to create transfer: %v", err)
+		case errors.Is(err, e.ErrRateNotFound), errors.Is(err, e.ErrAmountTooSmall):
+			return nil, status.Errorf(codes.InvalidArgument, "failed to create transfer: %v", err)
+		case errors.Is(err, e.ErrSettlementAccount):
+			return nil, status.Errorf(codes.PermissionDenied, "failed to create transfer: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to create transfer: %v", err)
+		}
+	}
+	auditTarget(ctx, domain.AuditTargetTransfer, result.Transfer.ID)
+	// tells the client that the transfer was booked by an earlier call
+	if result.Replayed {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(idempotentReplayedHeader, "true"))
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+
+	return rsp, nil
+}
+
+// ReverseTransfer lets support undo a transfer, fully or in part.
+func (h *Handler) ReverseTransfer(ctx context.Context, req *pb.ReverseTransferRequest) (*pb.CreateTransferResponse, error) {
+	if req.GetId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transfer id: %d", req.GetId())
+	}
+	if req.GetAmount() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "amount must not be negative")
+	}
+	auditTarget(ctx, domain.AuditTargetTransfer, int(req.GetId()))
+
+	result, err := h.service.TransferTx.ReverseTransfer(ctx, domain.ReverseTransferParams{
+		TransferID: int(req.GetId()),
+		Amount:     int(req.GetAmount()),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, status.Errorf(codes.NotFound, "transfer not found: %v", err)
+		case errors.Is(err, e.ErrTransferNotReversible), errors.Is(err, e.ErrReversalExceedsAmount), errors.Is(err, e.ErrInsufficientFunds),
+			errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to reverse transfer: %v", err)
+		case errors.Is(err, e.ErrAmountTooSmall):
+			return nil, status.Errorf(codes.InvalidArgument, "failed to reverse transfer: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to reverse transfer: %v", err)
+		}
+	}
+	auditTarget(ctx, domain.AuditTargetTransfer, result.Transfer.ID)
+
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}, nil
+}
+
+func (h *Handler) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.Transfer, error) {
+	if req.GetId() < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid transfer id: %d", req.GetId())
+	}
+
+	transfer, err := h.service.Transfer.GetTransfer(ctx, int(req.GetId()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "transfer not found: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get transfer: %v", err)
+	}
+
+	if err := h.transferOwnedByUser(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	return convertTransfer(transfer), nil
+}
+
+func (h *Handler) ListTransfers(ctx context.Context, req *pb.ListTransfersRequest) (*pb.ListTransfersResponse, error) {
+	if err := validatePage(req.GetPageId(), req.GetPageSize()); err != nil {
+		return nil, err
+	}
+
+	if _, err := h.accountOwnedByUser(ctx, int(req.GetAccountId())); err != nil {
+		return nil, err
+	}
+
+	arg := domain.ListTransfersParams{
+		FromAccountID: int(req.GetAccountId()),
+		ToAccountID:   int(req.GetAccountId()),
+		Limit:         int(req.GetPageSize()),
+		Offset:        int((req.GetPageId() - 1) * req.GetPageSize()),
+	}
+
+	transfers, err := h.service.Transfer.ListTransfers(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list transfers: %v", err)
+	}
+
+	rsp := &pb.ListTransfersResponse{
+		Transfers: make([]*pb.Transfer, 0, len(transfers)),
+	}
+	for _, transfer := range transfers {
+		rsp.Transfers = append(rsp.Transfers, convertTransfer(transfer))
+	}
+
+	return rsp, nil
+}
+
+func (h *Handler) validAccount(ctx context.Context, accountID int, currency string) (domain.Account, error) {
+	account, err := h.getAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Currency != currency {
+		return account, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	}
+
+	return account, nil
+}
+
+func (h *Handler) getAccount(ctx context.Context, accountID int) (domain.Account, error) {
+	account, err := h.service.Account.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return account, status.Errorf(codes.Internal, "failed to get account: %v", err)
+	}
+
+	return account, nil
+}
+
+// transferOwnedByUser allows access when the authenticated user owns either
+// side of the transfer, or is a banker.
+func (h *Handler) transferOwnedByUser(ctx context.Context, transfer domain.Transfer) error {
+	for _, accountID := range []int{transfer.FromAccountID, transfer.ToAccountID} {
+		_, err := h.accountOwnedByUser(ctx, accountID)
+		if err == nil {
+			return nil
+		}
+		if status.Code(err) != codes.PermissionDenied {
+			return err
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "transfer doesn't belong to the authenticated user")
+}
+
+func convertTransfer(transfer domain.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            int32(transfer.ID),
+		FromAccountId: int32(transfer.FromAccountID),
+		ToAccountId:   int32(transfer.ToAccountID),
+		Amount:        int32(transfer.Amount),
+		ToAmount:      int32(transfer.ToAmount),
+		ExchangeRate:  transfer.ExchangeRate,
+		Kind:          transfer.Kind,
+		ExternalRef:   transfer.ExternalRef,
+		ReversalOf:    int32(transfer.ReversalOf),
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+	}
+}
+
+// IncomingHeaderMatcher forwards the Idempotency-Key and X-Request-ID
+// headers to the gRPC handlers in addition to the headers grpc-gateway passes
+// by default.
+func IncomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, idempotencyKeyHeader) {
+		return idempotencyKeyHeader, true
+	}
+	if strings.EqualFold(key, requestIDHeader) {
+		return requestIDHeader, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func getIdempotencyKeyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(idempotencyKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
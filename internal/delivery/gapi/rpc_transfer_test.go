@@ -0,0 +1,428 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pb"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/exchange"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTransferRPC(t *testing.T) {
+	amount := 10
+	user1 := util.RandomOwner()
+	user2 := util.RandomOwner()
+
+	account1 := randomAccount(user1)
+	account2 := randomAccount(user2)
+	account3 := randomAccount(user2)
+
+	account1.Currency = util.CAD
+	account2.Currency = util.CAD
+	account3.Currency = util.EUR
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateTransferRequest
+		buildContext  func() context.Context
+		buildStubs    func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx)
+		checkResponse func(t *testing.T, rsp *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account1.ID),
+				ToAccountId:   int32(account2.ID),
+				Amount:        int32(amount),
+				Currency:      util.CAD,
+			},
+			buildContext: func() context.Context {
+				ctx := contextWithUser(user1)
+				return metadata.NewIncomingContext(ctx, metadata.Pairs(idempotencyKeyHeader, "key"))
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+
+				arg := domain.TransferTxParams{
+					FromAccountID:  account1.ID,
+					ToAccountID:    account2.ID,
+					Amount:         amount,
+					ToAmount:       amount,
+					ExchangeRate:   1,
+					IdempotencyKey: "key",
+					Currency:       util.CAD,
+					Owner:          user1,
+				}
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{
+					Transfer: domain.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(account1.ID), rsp.GetTransfer().GetFromAccountId())
+				require.Equal(t, int32(account2.ID), rsp.GetTransfer().GetToAccountId())
+				require.Equal(t, int32(amount), rsp.GetTransfer().GetAmount())
+			},
+		},
+		{
+			name: "NotOwner",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account1.ID),
+				ToAccountId:   int32(account2.ID),
+				Amount:        int32(amount),
+				Currency:      util.CAD,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser(user2)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "FromAccountNotFound",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account1.ID),
+				ToAccountId:   int32(account2.ID),
+				Amount:        int32(amount),
+				Currency:      util.CAD,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser(user1)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "CrossCurrency",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account2.ID),
+				ToAccountId:   int32(account3.ID),
+				Amount:        int32(amount),
+				Currency:      util.CAD,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser(user2)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(2).Return(account3, nil)
+
+				arg := domain.TransferTxParams{
+					FromAccountID: account2.ID,
+					ToAccountID:   account3.ID,
+					Amount:        amount,
+					ToAmount:      7,
+					ExchangeRate:  0.666667,
+					Currency:      util.CAD,
+					Owner:         user2,
+				}
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{
+					Transfer: domain.Transfer{ID: 1, FromAccountID: account2.ID, ToAccountID: account3.ID, Amount: amount, ToAmount: 7, ExchangeRate: 0.666667},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(7), rsp.GetTransfer().GetToAmount())
+				require.Equal(t, 0.666667, rsp.GetTransfer().GetExchangeRate())
+			},
+		},
+		{
+			name: "FromAccountCurrencyMismatch",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account2.ID),
+				ToAccountId:   int32(account3.ID),
+				Amount:        int32(amount),
+				Currency:      util.EUR,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser(user2)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(0)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InvalidAmount",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account1.ID),
+				ToAccountId:   int32(account2.ID),
+				Amount:        0,
+				Currency:      util.CAD,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser(user1)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account1.ID),
+				ToAccountId:   int32(account2.ID),
+				Amount:        int32(amount),
+				Currency:      util.CAD,
+			},
+			buildContext: func() context.Context {
+				return contextWithUser(user1)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, e.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "IdempotencyKeyConflict",
+			req: &pb.CreateTransferRequest{
+				FromAccountId: int32(account1.ID),
+				ToAccountId:   int32(account2.ID),
+				Amount:        int32(amount),
+				Currency:      util.CAD,
+			},
+			buildContext: func() context.Context {
+				ctx := contextWithUser(user1)
+				return metadata.NewIncomingContext(ctx, metadata.Pairs(idempotencyKeyHeader, "key"))
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, e.ErrIdempotencyKeyConflict)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store1 := mock_repository.NewMockAccount(ctrl)
+			store2 := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(store1, store2)
+
+			handler := NewHandler(&service.Service{
+				Account:    service.NewAccountService(store1, nil),
+				TransferTx: service.NewTransferService(store2, store1, testRates),
+			}, nil)
+
+			rsp, err := handler.CreateTransfer(tc.buildContext(), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestCreateTransferReplayedRPC(t *testing.T) {
+	user := util.RandomOwner()
+	account1 := randomAccount(user)
+	account2 := randomAccount(util.RandomOwner())
+	account1.Currency, account2.Currency = util.CAD, util.CAD
+
+	testCases := []struct {
+		name     string
+		replayed bool
+		header   metadata.MD
+	}{
+		{
+			name: "Booked",
+		},
+		{
+			name:     "Replayed",
+			replayed: true,
+			header:   metadata.Pairs(idempotentReplayedHeader, "true"),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store1 := mock_repository.NewMockAccount(ctrl)
+			store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).AnyTimes().Return(account1, nil)
+			store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).AnyTimes().Return(account2, nil)
+			store2 := mock_repository.NewMockTx(ctrl)
+			store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{
+				Transfer: domain.Transfer{ID: 1},
+				Replayed: tc.replayed,
+			}, nil)
+
+			handler := NewHandler(&service.Service{
+				Account:    service.NewAccountService(store1, nil),
+				TransferTx: service.NewTransferService(store2, store1, testRates),
+			}, nil)
+
+			stream := &headerStream{}
+			ctx := metadata.NewIncomingContext(contextWithUser(user), metadata.Pairs(idempotencyKeyHeader, "key"))
+			ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+			_, err := handler.CreateTransfer(ctx, &pb.CreateTransferRequest{
+				FromAccountId: int32(account1.ID),
+				ToAccountId:   int32(account2.ID),
+				Amount:        10,
+				Currency:      util.CAD,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.header, stream.header)
+		})
+	}
+}
+
+// headerStream keeps the headers a handler sets.
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string {
+	return pb.SimpleBank_CreateTransfer_FullMethodName
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *headerStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
+
+var testRates = exchange.NewStaticProvider(util.USD, map[string]float64{
+	util.EUR: 0.9,
+	util.CAD: 1.35,
+})
+
+func randomAccount(owner string) domain.Account {
+	return domain.Account{
+		ID:       int(util.RandomInt(1, 1000)),
+		Owner:    owner,
+		Balance:  int(util.RandomMany()),
+		Currency: util.RandomCurrency(),
+	}
+}
+
+func contextWithUser(username string) context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{Username: username})
+}
+
+func TestReverseTransferRPC(t *testing.T) {
+	testCases := []struct {
+		name          string
+		req           *pb.ReverseTransferRequest
+		buildStubs    func(store *mock_repository.MockTx)
+		checkResponse func(t *testing.T, rsp *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ReverseTransferRequest{Id: 42, Amount: 5},
+			buildStubs: func(store *mock_repository.MockTx) {
+				arg := domain.ReverseTransferParams{TransferID: 42, Amount: 5}
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{
+					Transfer: domain.Transfer{ID: 43, Amount: 5, Kind: domain.TransferKindReversal, ReversalOf: 42},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(42), rsp.GetTransfer().GetReversalOf())
+				require.Equal(t, domain.TransferKindReversal, rsp.GetTransfer().GetKind())
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.ReverseTransferRequest{},
+			buildStubs: func(store *mock_repository.MockTx) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name: "NotReversible",
+			req:  &pb.ReverseTransferRequest{Id: 42},
+			buildStubs: func(store *mock_repository.MockTx) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, e.ErrTransferNotReversible)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.ReverseTransferRequest{Id: 42},
+			buildStubs: func(store *mock_repository.MockTx) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(store)
+
+			handler := NewHandler(&service.Service{
+				TransferTx: service.NewTransferService(store, mock_repository.NewMockAccount(ctrl), testRates),
+			}, nil)
+
+			rsp, err := handler.ReverseTransfer(context.Background(), tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
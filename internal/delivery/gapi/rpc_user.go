@@ -13,6 +13,8 @@ import (
 )
 
 func (h *Handler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	auditActor(ctx, req.GetUsername())
+
 	arg := domain.CreateUserParams{
 		Username:       req.Username,
 		HashedPassword: req.Password,
@@ -23,7 +25,7 @@ func (h *Handler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*p
 	if err != nil {
 
 		if e.ErrorCode(err) == e.UniqueViolation {
-			return nil, status.Errorf(codes.AlreadyExists, "method CreateUser Already Exists", err)
+			return nil, status.Errorf(codes.AlreadyExists, "method CreateUser Already Exists: %v", err)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
 
@@ -37,8 +39,17 @@ func (h *Handler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*p
 }
 
 func (h *Handler) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	auditActor(ctx, req.GetUsername())
+
+	mtdt := extractMetadata(ctx)
+	arg := domain.LoginUserParams{
+		Username:  req.Username,
+		Password:  req.Password,
+		UserAgent: mtdt.UserAgent,
+		ClientIP:  mtdt.ClientIP,
+	}
 
-	res, err := h.service.User.GetUserByUsername(ctx, req.Username, req.Password)
+	res, err := h.service.User.GetUserByUsername(ctx, arg)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -51,6 +62,7 @@ func (h *Handler) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.
 			return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
 		}
 	}
+	auditTarget(ctx, domain.AuditTargetSession, res.SessionID)
 
 	rq := &pb.LoginUserResponse{
 		User: &pb.User{
@@ -59,12 +71,50 @@ func (h *Handler) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.
 			PasswordChangedAt: timestamppb.New(res.User.PasswordChangedAt),
 			CreatedAt:         timestamppb.New(res.User.CreatedAt),
 		},
-		AccessToken: res.AccessToken,
+		AccessToken:           res.AccessToken,
+		SessionId:             int64(res.SessionID),
+		AccessTokenExpiresAt:  timestamppb.New(res.AccessTokenExpiresAt),
+		RefreshToken:          res.RefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(res.RefreshTokenExpiresAt),
 	}
 
 	return rq, nil
 }
 
+func (h *Handler) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	res, err := h.service.User.RenewAccessToken(ctx, req.GetRefreshToken())
+	if err != nil {
+		switch err {
+		case e.ErrInvalidToken, e.ErrExpiredToken, e.ErrBlockedSession:
+			return nil, status.Errorf(codes.Unauthenticated, "failed to renew access token: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to renew access token: %v", err)
+		}
+	}
+
+	rsp := &pb.RenewAccessTokenResponse{
+		AccessToken:           res.AccessToken,
+		AccessTokenExpiresAt:  timestamppb.New(res.AccessTokenExpiresAt),
+		RefreshToken:          res.RefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(res.RefreshTokenExpiresAt),
+	}
+
+	return rsp, nil
+}
+
+func (h *Handler) LogoutUser(ctx context.Context, req *pb.LogoutUserRequest) (*pb.LogoutUserResponse, error) {
+	if err := h.service.User.Logout(ctx, req.GetRefreshToken()); err != nil {
+		switch err {
+		case e.ErrInvalidToken, e.ErrExpiredToken, e.ErrBlockedSession:
+			return nil, status.Errorf(codes.Unauthenticated, "failed to logout user: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to logout user: %v", err)
+		}
+	}
+
+	return &pb.LogoutUserResponse{}, nil
+}
+
 func convertUser(user domain.User) *pb.User {
 	return &pb.User{
 		Username:          user.Username,
@@ -0,0 +1,39 @@
+package gapi
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+)
+
+// UnaryTracingInterceptor starts a span for every call, continuing the trace
+// of the caller when the metadata carries a W3C traceparent. It goes first in
+// the chain so that the other interceptors run inside the span.
+func UnaryTracingInterceptor() grpc.UnaryServerInterceptor {
+	return otelgrpc.UnaryServerInterceptor()
+}
+
+func StreamTracingInterceptor() grpc.StreamServerInterceptor {
+	return otelgrpc.StreamServerInterceptor()
+}
+
+// GatewayTracing starts the span of a gateway call, continuing the trace of
+// the client when it sends a traceparent header.
+func GatewayTracing(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "gateway", otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+		return operation + " " + r.Method
+	}))
+}
+
+// GatewayDialOptions make the gateway send the trace context of its span to
+// the gRPC server as traceparent metadata, so that the gRPC span continues
+// the gateway's trace, and mark its calls with the gateway token so that the
+// forwarded client address is believed.
+func GatewayDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor(), gatewayUnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor(), gatewayStreamClientInterceptor),
+	}
+}
@@ -0,0 +1,84 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/begenov/backend/pkg/tracing"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGatewayTracePropagation(t *testing.T) {
+	exporter := tracing.InitInMemory()
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryTracingInterceptor()),
+		grpc.ChainStreamInterceptor(StreamTracingInterceptor()),
+	)
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, GatewayDialOptions()...)
+	conn, err := grpc.Dial("bufnet", opts...)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	// stands in for the grpc-gateway mux, which calls the server with the
+	// context of the request
+	gateway := GatewayTracing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := client.Check(r.Context(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+	}))
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	request, err := http.NewRequest(http.MethodGet, "/v1/health", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+
+	recorder := httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	var gatewaySpan, clientSpan, serverSpan tracetest.SpanStub
+	for _, span := range spans {
+		require.Equal(t, traceID, span.SpanContext.TraceID().String())
+		switch {
+		case span.Name == "gateway GET":
+			gatewaySpan = span
+		case span.SpanKind == trace.SpanKindClient:
+			clientSpan = span
+		case span.SpanKind == trace.SpanKindServer:
+			serverSpan = span
+		}
+	}
+
+	// client -> gateway -> gateway's gRPC client -> gRPC server
+	require.Equal(t, spanID, gatewaySpan.Parent.SpanID().String())
+	require.Equal(t, gatewaySpan.SpanContext.SpanID(), clientSpan.Parent.SpanID())
+	require.Equal(t, clientSpan.SpanContext.SpanID(), serverSpan.Parent.SpanID())
+	require.True(t, serverSpan.Parent.IsRemote())
+}
@@ -1,31 +1,47 @@
 package http
 
 import (
+	"log/slog"
+
 	"github.com/begenov/backend/internal/config"
 	v1 "github.com/begenov/backend/internal/delivery/http/v1"
 	"github.com/begenov/backend/internal/service"
 	"github.com/begenov/backend/pkg/auth"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Handler struct {
 	service *service.Service
 	token   auth.TokenManager
+	logger  *slog.Logger
 }
 
-func NewHandler(service *service.Service, token auth.TokenManager) *Handler {
+func NewHandler(service *service.Service, token auth.TokenManager, logger *slog.Logger) *Handler {
 	return &Handler{
 		service: service,
 		token:   token,
+		logger:  logger,
 	}
 }
 
-func (h *Handler) Init(cfg *config.Config) *gin.Engine {
-	router := gin.Default()
+func (h *Handler) Init(cfg *config.Config) (*gin.Engine, error) {
+	router := gin.New()
+	// handlers pass the gin context on, let it reach the request id and
+	// logger stored in the request context
+	router.ContextWithFallback = true
+	// ClientIP ends up in sessions and audit events, X-Forwarded-For is only
+	// believed when set by one of the configured proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+	// the span wraps everything else, recovery goes last so that panics are
+	// logged and counted as 500s
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName), requestLogger(h.logger), requestMetrics, gin.Recovery())
 
 	h.init(router)
 
-	return router
+	return router, nil
 }
 
 func (h *Handler) init(router *gin.Engine) {
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/begenov/backend/internal/config"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteTracing(t *testing.T) {
+	exporter := tracing.InitInMemory()
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	cfg := &config.Config{Tracing: config.TracingConfig{ServiceName: "simple-bank"}}
+	router, err := NewHandler(&service.Service{}, token, slog.Default()).Init(cfg)
+	require.NoError(t, err)
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/accounts/1", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	// named after the route, not the path
+	require.Equal(t, "/api/v1/accounts/:id", spans[0].Name)
+	require.Equal(t, traceID, spans[0].SpanContext.TraceID().String())
+	require.Equal(t, spanID, spans[0].Parent.SpanID().String())
+}
+
+func TestTrustedProxies(t *testing.T) {
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		proxies  []string
+		remote   string
+		clientIP string
+	}{
+		{
+			name:     "NoProxies",
+			remote:   "10.0.0.1:1234",
+			clientIP: "10.0.0.1",
+		},
+		{
+			name:     "TrustedProxy",
+			proxies:  []string{"10.0.0.0/8"},
+			remote:   "10.0.0.1:1234",
+			clientIP: "203.0.113.7",
+		},
+		{
+			name:     "UntrustedProxy",
+			proxies:  []string{"10.0.0.0/8"},
+			remote:   "192.0.2.1:1234",
+			clientIP: "192.0.2.1",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.HTTPConfig{TrustedProxies: tc.proxies}}
+			router, err := NewHandler(&service.Service{}, token, slog.Default()).Init(cfg)
+			require.NoError(t, err)
+			router.GET("/ip", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, ctx.ClientIP())
+			})
+
+			request, err := http.NewRequest(http.MethodGet, "/ip", nil)
+			require.NoError(t, err)
+			request.RemoteAddr = tc.remote
+			request.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.clientIP, recorder.Body.String())
+		})
+	}
+
+	cfg := &config.Config{Server: config.HTTPConfig{TrustedProxies: []string{"not-an-ip"}}}
+	_, err = NewHandler(&service.Service{}, token, slog.Default()).Init(cfg)
+	require.Error(t, err)
+}
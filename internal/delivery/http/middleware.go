@@ -0,0 +1,71 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength keeps ids sent by clients from bloating the logs.
+const maxRequestIDLength = 128
+
+// requestLogger takes the request id from the X-Request-ID header, or makes
+// one up, and echoes it back. The id and a logger carrying it are stored in
+// the request context for the layers below. Every request is logged once it
+// is served.
+func requestLogger(log *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		id := ctx.GetHeader(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = logger.NewRequestID()
+		}
+		ctx.Header(requestIDHeader, id)
+		ctx.Request = ctx.Request.WithContext(logger.WithRequestID(ctx.Request.Context(), log, id))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logger.FromContext(ctx.Request.Context()).LogAttrs(ctx.Request.Context(), level, "http request",
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", routeOf(ctx)),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", ctx.Writer.Size()),
+			slog.String("client_ip", ctx.ClientIP()),
+		)
+	}
+}
+
+// requestMetrics counts every request and how long it took by route, not by
+// path, so that ids in the path don't turn into a label each.
+func requestMetrics(ctx *gin.Context) {
+	start := time.Now()
+
+	ctx.Next()
+
+	route := routeOf(ctx)
+	metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(ctx.Request.Method, route).Observe(time.Since(start).Seconds())
+}
+
+func routeOf(ctx *gin.Context) string {
+	if route := ctx.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		checkID   func(t *testing.T, id string)
+	}{
+		{
+			name:      "FromHeader",
+			requestID: "req-1",
+			checkID: func(t *testing.T, id string) {
+				require.Equal(t, "req-1", id)
+			},
+		},
+		{
+			name: "Generated",
+			checkID: func(t *testing.T, id string) {
+				require.Len(t, id, 32)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log, err := logger.New(&buf, "info", logger.FormatJSON)
+			require.NoError(t, err)
+
+			var seen string
+			router := gin.New()
+			router.ContextWithFallback = true
+			router.Use(requestLogger(log))
+			router.GET("/accounts/:id", func(ctx *gin.Context) {
+				// the id reaches whatever the gin context is passed to
+				seen = logger.RequestIDFromContext(ctx)
+				ctx.Status(http.StatusTeapot)
+			})
+
+			request, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusTeapot, recorder.Code)
+
+			id := recorder.Header().Get(requestIDHeader)
+			tc.checkID(t, id)
+			require.Equal(t, id, seen)
+
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+			require.Equal(t, "http request", line["msg"])
+			require.Equal(t, id, line[logger.RequestIDKey])
+			require.Equal(t, "/accounts/:id", line["route"])
+			require.EqualValues(t, http.StatusTeapot, line["status"])
+			require.Contains(t, line, "latency")
+		})
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	router := gin.New()
+	router.Use(requestMetrics)
+	router.GET("/accounts/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNotFound)
+	})
+
+	requests := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/accounts/:id", "404")
+	before := testutil.ToFloat64(requests)
+
+	for _, path := range []string{"/accounts/1", "/accounts/2"} {
+		request, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	}
+
+	// both paths are counted under the route
+	require.Equal(t, before+2, testutil.ToFloat64(requests))
+}
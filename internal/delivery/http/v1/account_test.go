@@ -15,6 +15,7 @@ import (
 	mock_store "github.com/begenov/backend/internal/repository/mocks"
 	"github.com/begenov/backend/internal/service"
 	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/e"
 	"github.com/begenov/backend/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -39,7 +40,34 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "Ok",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockAccount) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+				requiredBodyMatchAccount(t, recoder.Body, account)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockAccount) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name:      "Banker",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
 			},
 			buildStubs: func(store *mock_store.MockAccount) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
@@ -53,7 +81,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "NotFound",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock_store.MockAccount) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
@@ -66,7 +94,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "InternalServer",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock_store.MockAccount) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(domain.Account{}, sql.ErrConnDone)
@@ -79,7 +107,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "InvalidID",
 			accountID: 0,
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock_store.MockAccount) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(0)
@@ -99,7 +127,7 @@ func TestGetAccountAPI(t *testing.T) {
 
 			store := mock_store.NewMockAccount(ctrl)
 			service := &service.Service{
-				Account: service.NewAccountService(store),
+				Account: service.NewAccountService(store, nil),
 			}
 			tc.buildStubs(store)
 			recorder := httptest.NewRecorder()
@@ -138,17 +166,17 @@ func TestCreateAccountAPI(t *testing.T) {
 		name          string
 		inp           createAccountRequest
 		setupAuth     func(t *testing.T, request *http.Request, token auth.TokenManager)
-		buildStubs    func(store *mock_store.MockAccount)
+		buildStubs    func(store *mock_store.MockTx)
 		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
 			inp:  inp,
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
-			buildStubs: func(store *mock_store.MockAccount) {
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
 			},
 			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
@@ -160,10 +188,10 @@ func TestCreateAccountAPI(t *testing.T) {
 			name: "InvalidInput",
 			inp:  createAccountRequest{Owner: "asf", Currency: "US"},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
-			buildStubs: func(store *mock_store.MockAccount) {
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 
 			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
@@ -174,10 +202,10 @@ func TestCreateAccountAPI(t *testing.T) {
 			name: "InternalServer",
 			inp:  inp,
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
-			buildStubs: func(store *mock_store.MockAccount) {
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, sql.ErrConnDone)
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, sql.ErrConnDone)
 			},
 
 			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
@@ -192,11 +220,11 @@ func TestCreateAccountAPI(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		store := mock_store.NewMockAccount(ctrl)
+		store := mock_store.NewMockTx(ctrl)
 		tc.buildStubs(store)
 
 		service := &service.Service{
-			Account: service.NewAccountService(store),
+			Account: service.NewAccountService(nil, store),
 		}
 
 		recorder := httptest.NewRecorder()
@@ -252,10 +280,15 @@ func TestListAccount(t *testing.T) {
 				PageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock_store.MockAccount) {
-				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(1).Return(accounts, nil)
+				arg := domain.ListAccountsParams{
+					Owner:  user.Username,
+					Limit:  n,
+					Offset: 0,
+				}
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
 			},
 			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
@@ -269,7 +302,7 @@ func TestListAccount(t *testing.T) {
 				PageSize: 5,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock_store.MockAccount) {
 				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
@@ -285,7 +318,7 @@ func TestListAccount(t *testing.T) {
 				PageSize: 5,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock_store.MockAccount) {
 				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(1).Return([]domain.Account{}, sql.ErrConnDone)
@@ -305,7 +338,7 @@ func TestListAccount(t *testing.T) {
 		tc.buildStubs(store)
 
 		service := &service.Service{
-			Account: service.NewAccountService(store),
+			Account: service.NewAccountService(store, nil),
 		}
 
 		recorder := httptest.NewRecorder()
@@ -379,3 +412,113 @@ func requiredBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []doma
 	require.NoError(t, err)
 	require.Equal(t, accounts, gotAccounts)
 }
+
+func TestCloseAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	closed := account
+	closed.Balance = 0
+	closed.Status = domain.AccountClosed
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(accounts *mock_store.MockAccount, store *mock_store.MockTx)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_store.MockAccount, store *mock_store.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := domain.ChangeAccountStatusParams{
+					ID:        account.ID,
+					Status:    domain.AccountClosed,
+					Reason:    "moving banks",
+					ChangedBy: user.Username,
+				}
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(closed, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var got domain.Account
+				require.NoError(t, json.NewDecoder(recoder.Body).Decode(&got))
+				require.Equal(t, domain.AccountClosed, got.Status)
+			},
+		},
+		{
+			name:     "NonZeroBalance",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_store.MockAccount, store *mock_store.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, e.ErrNonZeroBalance)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recoder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: other.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_store.MockAccount, store *mock_store.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name:     "Banker",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(accounts *mock_store.MockAccount, store *mock_store.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_store.NewMockAccount(ctrl)
+			store := mock_store.NewMockTx(ctrl)
+			tc.buildStubs(accounts, store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{Account: service.NewAccountService(accounts, store)},
+				token:   token,
+			}
+			handler.Init(router.Group("/api"))
+
+			url := fmt.Sprintf("/api/v1/accounts/%d/close", account.ID)
+			body, err := json.Marshal(gin.H{"reason": "moving banks"})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+			addAuthorization(t, request, token, "Bearer", tc.username, tc.role, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -2,10 +2,14 @@ package v1
 
 import (
 	"database/sql"
-	"log"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/export"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 )
@@ -13,8 +17,15 @@ import (
 func (h *Handler) initAccountsRoutes(api *gin.RouterGroup) {
 	accounts := api.Group("/accounts", h.userIdentity)
 	{
-		accounts.POST("/create", h.createAccount)
+		accounts.POST("/create", h.audited(domain.AuditAccountCreate), h.createAccount)
 		accounts.GET("/:id", h.getAccountByID)
+		accounts.GET("/:id/statement", h.getAccountStatement)
+		accounts.GET("/:id/statement.csv", h.exportAccountStatement(export.CSV))
+		accounts.GET("/:id/statement.ofx", h.exportAccountStatement(export.OFX))
+		accounts.GET("/:id/statement.xml", h.exportAccountStatement(export.CAMT053))
+		accounts.POST("/:id/deposit", h.audited(domain.AuditAccountDeposit), h.requireRole(util.BankerRole, util.ProcessorRole), h.cashHandler(domain.TransferKindDeposit))
+		accounts.POST("/:id/withdraw", h.audited(domain.AuditAccountWithdraw), h.requireRole(util.BankerRole, util.ProcessorRole), h.cashHandler(domain.TransferKindWithdrawal))
+		accounts.POST("/:id/close", h.audited(domain.AuditAccountClose), h.closeAccount)
 		accounts.GET("", h.listAccount)
 	}
 }
@@ -33,7 +44,6 @@ func (h *Handler) createAccount(ctx *gin.Context) {
 	}
 
 	username := ctx.MustGet(userCtx).(string)
-	log.Println(username)
 	arg := domain.CreateAccountParams{
 		Owner:    username,
 		Currency: inp.Currency,
@@ -52,6 +62,7 @@ func (h *Handler) createAccount(ctx *gin.Context) {
 		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
 		return
 	}
+	auditTarget(ctx, domain.AuditTargetAccount, account.ID)
 	ctx.JSON(http.StatusOK, account)
 }
 
@@ -67,19 +78,95 @@ func (h *Handler) getAccountByID(ctx *gin.Context) {
 		return
 	}
 
-	account, err := h.service.Account.GetAccountByID(ctx, inp.ID)
+	account, ok := h.accountOwnedByUser(ctx, inp.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+type closeAccountRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// closeAccount closes one of the caller's accounts for good. The balance has
+// to be brought to zero first.
+func (h *Handler) closeAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	var inp closeAccountRequest
+	if err := ctx.ShouldBindJSON(&inp); err != nil && !errors.Is(err, io.EOF) {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	account, ok := h.accountOwnedByUser(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	// bankers freeze accounts, only the owner may close one
+	if !callerMay(ctx, account.Owner, writeAccess) {
+		newResponse(ctx, http.StatusForbidden, "account doesn't belong to the authenticated user")
+		return
+	}
+
+	h.changeAccountStatus(ctx, domain.ChangeAccountStatusParams{
+		ID:        account.ID,
+		Status:    domain.AccountClosed,
+		Reason:    inp.Reason,
+		ChangedBy: ctx.MustGet(userCtx).(string),
+	})
+}
+
+// changeAccountStatus applies the change and writes the response.
+func (h *Handler) changeAccountStatus(ctx *gin.Context, arg domain.ChangeAccountStatusParams) {
+	account, err := h.service.Account.ChangeAccountStatus(ctx, arg)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
 			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
-			return
+		case errors.Is(err, e.ErrInvalidStatusTransition), errors.Is(err, e.ErrNonZeroBalance):
+			newResponse(ctx, http.StatusUnprocessableEntity, err.Error())
+		default:
+			newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
 		}
-		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
 		return
 	}
 
 	ctx.JSON(http.StatusOK, account)
 }
 
+// accountOwnedByUser loads the account and checks that it belongs to the
+// authenticated user. Bankers may access any account. On failure the
+// response is already written.
+func (h *Handler) accountOwnedByUser(ctx *gin.Context, accountID int) (domain.Account, bool) {
+	auditTarget(ctx, domain.AuditTargetAccount, accountID)
+
+	account, err := h.service.Account.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
+			return account, false
+		}
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return account, false
+	}
+
+	username := ctx.MustGet(userCtx).(string)
+	if account.Owner != username && ctx.GetString(roleCtx) != util.BankerRole {
+		newResponse(ctx, http.StatusForbidden, "account doesn't belong to the authenticated user")
+		return account, false
+	}
+
+	return account, true
+}
+
 type listAccountRequest struct {
 	PageID   int `form:"page_id" binding:"required,min=1"`
 	PageSize int `form:"page_size" binding:"required,min=5,max=10"`
@@ -93,6 +180,7 @@ func (h *Handler) listAccount(ctx *gin.Context) {
 	}
 
 	arg := domain.ListAccountsParams{
+		Owner:  ctx.MustGet(userCtx).(string),
 		Limit:  inp.PageSize,
 		Offset: (inp.PageID - 1) * inp.PageSize,
 	}
@@ -0,0 +1,339 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_store "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditedRoute(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(tx *mock_store.MockTx, audit *mock_store.MockAudit)
+		wantStatus int
+	}{
+		{
+			name: "Success",
+			buildStubs: func(tx *mock_store.MockTx, audit *mock_store.MockAudit) {
+				tx.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+				audit.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+						require.Equal(t, user.Username, arg.Actor)
+						require.Equal(t, domain.AuditAccountCreate, arg.Action)
+						require.Equal(t, domain.AuditTargets{domain.AuditTargetAccount: {account.ID}}, arg.Targets)
+						require.Equal(t, "req-1", arg.RequestID)
+						require.Equal(t, "test-agent", arg.UserAgent)
+						require.Equal(t, "10.0.0.1", arg.ClientIP)
+						require.Equal(t, domain.AuditSuccess, arg.Outcome)
+						require.Empty(t, arg.Error)
+						return domain.AuditEvent{}, nil
+					})
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Failure",
+			buildStubs: func(tx *mock_store.MockTx, audit *mock_store.MockAudit) {
+				tx.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, sql.ErrConnDone)
+				audit.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+						require.Equal(t, user.Username, arg.Actor)
+						require.Empty(t, arg.Targets)
+						require.Equal(t, domain.AuditFailure, arg.Outcome)
+						require.Contains(t, arg.Error, sql.ErrConnDone.Error())
+						return domain.AuditEvent{}, nil
+					})
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "RecordError",
+			buildStubs: func(tx *mock_store.MockTx, audit *mock_store.MockAudit) {
+				tx.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+				audit.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).Return(domain.AuditEvent{}, sql.ErrConnDone)
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tx := mock_store.NewMockTx(ctrl)
+			audit := mock_store.NewMockAudit(ctrl)
+			tc.buildStubs(tx, audit)
+
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{
+					Account: service.NewAccountService(nil, tx),
+					Audit:   service.NewAuditService(audit),
+				},
+				token: token,
+			}
+			handler.Init(router.Group("/api"))
+
+			body, err := json.Marshal(gin.H{"currency": account.Currency})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/accounts/create", bytes.NewBuffer(body))
+			require.NoError(t, err)
+			request = request.WithContext(logger.WithRequestID(request.Context(), slog.Default(), "req-1"))
+			request.Header.Set("User-Agent", "test-agent")
+			request.RemoteAddr = "10.0.0.1:50000"
+			addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.wantStatus, recorder.Code)
+		})
+	}
+}
+
+func TestAuditedLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := mock_store.NewMockUser(ctrl)
+	users.EXPECT().GetUser(gomock.Any(), gomock.Eq("nobody")).Times(1).Return(domain.User{}, sql.ErrNoRows)
+
+	audit := mock_store.NewMockAudit(ctrl)
+	audit.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+			// the actor of a failed login is whoever it was tried for
+			require.Equal(t, "nobody", arg.Actor)
+			require.Equal(t, domain.AuditUserLogin, arg.Action)
+			require.Equal(t, domain.AuditFailure, arg.Outcome)
+			return domain.AuditEvent{}, nil
+		})
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	router := gin.Default()
+	handler := &Handler{
+		service: &service.Service{
+			User:  service.NewUserService(users, nil, h, token, time.Minute, time.Hour),
+			Audit: service.NewAuditService(audit),
+		},
+		token: token,
+	}
+	handler.Init(router.Group("/api"))
+
+	body, err := json.Marshal(gin.H{"username": "nobody", "password": "secret"})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestAuditedDeniedRoute(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tx := mock_store.NewMockTx(ctrl)
+	tx.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+
+	audit := mock_store.NewMockAudit(ctrl)
+	audit.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+			// the role is checked after the event is opened, so that denied
+			// calls are recorded like they are over gRPC
+			require.Equal(t, user.Username, arg.Actor)
+			require.Equal(t, domain.AuditAccountFreeze, arg.Action)
+			require.Equal(t, domain.AuditFailure, arg.Outcome)
+			require.Contains(t, arg.Error, "not allowed")
+			return domain.AuditEvent{}, nil
+		})
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	router := gin.Default()
+	handler := &Handler{
+		service: &service.Service{
+			Account: service.NewAccountService(nil, tx),
+			Audit:   service.NewAuditService(audit),
+		},
+		token: token,
+	}
+	handler.Init(router.Group("/api"))
+
+	body, err := json.Marshal(gin.H{"reason": "mine"})
+	require.NoError(t, err)
+	url := fmt.Sprintf("/api/v1/banker/accounts/%d/freeze", account.ID)
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	require.NoError(t, err)
+	addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestListAuditEventsAPI(t *testing.T) {
+	from := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	events := []domain.AuditEvent{
+		{
+			ID:        1,
+			Actor:     "alice",
+			Action:    domain.AuditTransferCreate,
+			Targets:   domain.AuditTargets{domain.AuditTargetAccount: {1, 2}, domain.AuditTargetTransfer: {7}},
+			Outcome:   domain.AuditSuccess,
+			CreatedAt: from,
+		},
+	}
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		role          string
+		buildStubs    func(store *mock_store.MockAudit)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"actor":       {"alice"},
+				"target_type": {domain.AuditTargetAccount},
+				"target_id":   {"1"},
+				"from":        {from.Format(time.RFC3339)},
+				"to":          {to.Format(time.RFC3339)},
+				"page_id":     {"2"},
+				"page_size":   {"20"},
+			},
+			role: util.BankerRole,
+			buildStubs: func(store *mock_store.MockAudit) {
+				arg := domain.ListAuditEventsParams{
+					Actor:      "alice",
+					TargetType: domain.AuditTargetAccount,
+					TargetID:   1,
+					From:       from,
+					To:         to,
+					Limit:      20,
+					Offset:     20,
+				}
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Eq(arg)).Times(1).Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var got []domain.AuditEvent
+				require.NoError(t, json.NewDecoder(recoder.Body).Decode(&got))
+				require.Equal(t, events, got)
+			},
+		},
+		{
+			name:  "Depositor",
+			query: url.Values{"page_id": {"1"}, "page_size": {"10"}},
+			role:  util.DepositorRole,
+			buildStubs: func(store *mock_store.MockAudit) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name:  "TargetTypeWithoutID",
+			query: url.Values{"target_type": {domain.AuditTargetAccount}, "page_id": {"1"}, "page_size": {"10"}},
+			role:  util.BankerRole,
+			buildStubs: func(store *mock_store.MockAudit) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name:  "InvalidOutcome",
+			query: url.Values{"outcome": {"maybe"}, "page_id": {"1"}, "page_size": {"10"}},
+			role:  util.BankerRole,
+			buildStubs: func(store *mock_store.MockAudit) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name: "ToBeforeFrom",
+			query: url.Values{
+				"from":      {to.Format(time.RFC3339)},
+				"to":        {from.Format(time.RFC3339)},
+				"page_id":   {"1"},
+				"page_size": {"10"},
+			},
+			role: util.BankerRole,
+			buildStubs: func(store *mock_store.MockAudit) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_store.NewMockAudit(ctrl)
+			tc.buildStubs(store)
+
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{Audit: service.NewAuditService(store)},
+				token:   token,
+			}
+			handler.Init(router.Group("/api"))
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/banker/audit_events?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, token, "Bearer", "banker", tc.role, time.Minute)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
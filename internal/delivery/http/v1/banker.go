@@ -0,0 +1,226 @@
+package v1
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// initBankerRoutes registers the back-office routes, which are only open to
+// bankers and aren't limited to the caller's own accounts.
+func (h *Handler) initBankerRoutes(api *gin.RouterGroup) {
+	// the role is checked per route, after audited, so that denied calls to
+	// audited routes are recorded as well
+	banker := api.Group("/banker", h.userIdentity)
+	{
+		banker.GET("/accounts", h.requireRole(util.BankerRole), h.listCustomerAccounts)
+		banker.PUT("/accounts/:id/overdraft_limit", h.audited(domain.AuditAccountOverdraftLimit), h.requireRole(util.BankerRole), h.setOverdraftLimit)
+		banker.POST("/accounts/:id/freeze", h.audited(domain.AuditAccountFreeze), h.requireRole(util.BankerRole), h.setAccountStatus(domain.AccountFrozen))
+		banker.POST("/accounts/:id/unfreeze", h.audited(domain.AuditAccountUnfreeze), h.requireRole(util.BankerRole), h.setAccountStatus(domain.AccountActive))
+		banker.GET("/accounts/:id/status_changes", h.requireRole(util.BankerRole), h.listAccountStatusChanges)
+		banker.GET("/transfers/:id", h.requireRole(util.BankerRole), h.getTransferByID)
+		banker.GET("/ledger/accounts/check", h.requireRole(util.BankerRole), h.checkLedgerAccounts)
+		banker.GET("/ledger/transfers/check", h.requireRole(util.BankerRole), h.checkLedgerTransfers)
+		banker.GET("/audit_events", h.requireRole(util.BankerRole), h.listAuditEvents)
+	}
+}
+
+type listCustomerAccountsRequest struct {
+	Owner    string `form:"owner" binding:"required"`
+	PageID   int    `form:"page_id" binding:"required,min=1"`
+	PageSize int    `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *Handler) listCustomerAccounts(ctx *gin.Context) {
+	var inp listCustomerAccountsRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	arg := domain.ListAccountsParams{
+		Owner:  inp.Owner,
+		Limit:  inp.PageSize,
+		Offset: (inp.PageID - 1) * inp.PageSize,
+	}
+	accounts, err := h.service.Account.ListAccounts(ctx, arg)
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
+
+type setOverdraftLimitRequest struct {
+	OverdraftLimit *int `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+func (h *Handler) setOverdraftLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+	auditTarget(ctx, domain.AuditTargetAccount, uri.ID)
+
+	var inp setOverdraftLimitRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	account, err := h.service.Account.SetOverdraftLimit(ctx, domain.SetOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: *inp.OverdraftLimit,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
+			return
+		}
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+type setAccountStatusRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// setAccountStatus serves freezing and unfreezing, which only differ in the
+// status the account ends up in. A reason is required for the record.
+func (h *Handler) setAccountStatus(status string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var uri getAccountRequest
+		if err := ctx.BindUri(&uri); err != nil {
+			newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+			return
+		}
+		auditTarget(ctx, domain.AuditTargetAccount, uri.ID)
+
+		var inp setAccountStatusRequest
+		if err := ctx.BindJSON(&inp); err != nil {
+			newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+			return
+		}
+
+		h.changeAccountStatus(ctx, domain.ChangeAccountStatusParams{
+			ID:        uri.ID,
+			Status:    status,
+			Reason:    inp.Reason,
+			ChangedBy: ctx.MustGet(userCtx).(string),
+		})
+	}
+}
+
+type listAccountStatusChangesRequest struct {
+	PageID   int `form:"page_id" binding:"required,min=1"`
+	PageSize int `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listAccountStatusChanges returns who froze, unfroze or closed the account
+// and why, newest first.
+func (h *Handler) listAccountStatusChanges(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	var inp listAccountStatusChangesRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	changes, err := h.service.Account.ListAccountStatusChanges(ctx, domain.ListAccountStatusChangesParams{
+		AccountID: uri.ID,
+		Limit:     inp.PageSize,
+		Offset:    (inp.PageID - 1) * inp.PageSize,
+	})
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, changes)
+}
+
+type getTransferRequest struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+func (h *Handler) getTransferByID(ctx *gin.Context) {
+	var inp getTransferRequest
+	if err := ctx.BindUri(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	transfer, err := h.service.Transfer.GetTransfer(ctx, inp.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
+			return
+		}
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
+
+const defaultLedgerPageSize = 500
+
+type checkLedgerRequest struct {
+	AfterID int `form:"after_id" binding:"min=0"`
+	Limit   int `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
+type checkLedgerResponse struct {
+	OK bool `json:"ok"`
+	domain.LedgerBatch
+}
+
+// checkLedgerAccounts checks the balances of one page of accounts. A full
+// scan doesn't fit in a request, the caller pages through the table by
+// passing the returned last_id as after_id until it is zero. cmd/ledgercheck
+// scans the whole database in one run.
+func (h *Handler) checkLedgerAccounts(ctx *gin.Context) {
+	h.checkLedgerBatch(ctx, h.service.Ledger.CheckAccountBalances)
+}
+
+// checkLedgerTransfers checks the entries of one page of transfers.
+func (h *Handler) checkLedgerTransfers(ctx *gin.Context) {
+	h.checkLedgerBatch(ctx, h.service.Ledger.CheckTransferEntries)
+}
+
+func (h *Handler) checkLedgerBatch(ctx *gin.Context, check func(context.Context, domain.LedgerBatchParams) (domain.LedgerBatch, error)) {
+	var inp checkLedgerRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+	if inp.Limit == 0 {
+		inp.Limit = defaultLedgerPageSize
+	}
+
+	batch, err := check(ctx, domain.LedgerBatchParams{AfterID: inp.AfterID, Limit: inp.Limit})
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+	if batch.Discrepancies == nil {
+		batch.Discrepancies = []domain.LedgerDiscrepancy{}
+	}
+
+	ctx.JSON(http.StatusOK, checkLedgerResponse{OK: batch.OK(), LedgerBatch: batch})
+}
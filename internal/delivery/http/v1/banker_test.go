@@ -0,0 +1,429 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_store "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOverdraftLimitAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	limit := 500
+
+	updated := account
+	updated.OverdraftLimit = limit
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		accountID     int
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, token auth.TokenManager)
+		buildStubs    func(store *mock_store.MockAccount)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			body:      gin.H{"overdraft_limit": limit},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockAccount) {
+				arg := domain.SetOverdraftLimitParams{ID: account.ID, OverdraftLimit: limit}
+				store.EXPECT().SetOverdraftLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var got domain.Account
+				require.NoError(t, json.NewDecoder(recoder.Body).Decode(&got))
+				require.Equal(t, limit, got.OverdraftLimit)
+			},
+		},
+		{
+			name:      "Depositor",
+			accountID: account.ID,
+			body:      gin.H{"overdraft_limit": limit},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockAccount) {
+				store.EXPECT().SetOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name:      "NegativeLimit",
+			accountID: account.ID,
+			body:      gin.H{"overdraft_limit": -1},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockAccount) {
+				store.EXPECT().SetOverdraftLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			body:      gin.H{"overdraft_limit": limit},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockAccount) {
+				store.EXPECT().SetOverdraftLimit(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_store.NewMockAccount(ctrl)
+			tc.buildStubs(store)
+
+			service := &service.Service{
+				Account: service.NewAccountService(store, nil),
+			}
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: service,
+				token:   token,
+			}
+			handler.Init(api)
+
+			url := fmt.Sprintf("/api/v1/banker/accounts/%d/overdraft_limit", tc.accountID)
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+			tc.setupAuth(t, request, token)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestFreezeAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	frozen := account
+	frozen.Status = domain.AccountFrozen
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		path          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, token auth.TokenManager)
+		buildStubs    func(store *mock_store.MockTx)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Freeze",
+			path: "freeze",
+			body: gin.H{"reason": "card reported stolen"},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockTx) {
+				arg := domain.ChangeAccountStatusParams{
+					ID:        account.ID,
+					Status:    domain.AccountFrozen,
+					Reason:    "card reported stolen",
+					ChangedBy: "banker",
+				}
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var got domain.Account
+				require.NoError(t, json.NewDecoder(recoder.Body).Decode(&got))
+				require.Equal(t, domain.AccountFrozen, got.Status)
+			},
+		},
+		{
+			name: "Unfreeze",
+			path: "unfreeze",
+			body: gin.H{"reason": "card found"},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg domain.ChangeAccountStatusParams) (domain.Account, error) {
+						require.Equal(t, domain.AccountActive, arg.Status)
+						return account, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+			},
+		},
+		{
+			name: "Depositor",
+			path: "freeze",
+			body: gin.H{"reason": "mine"},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name: "MissingReason",
+			path: "freeze",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name: "ClosedAccount",
+			path: "freeze",
+			body: gin.H{"reason": "too late"},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(domain.Account{}, fmt.Errorf("%w: closed to frozen", e.ErrInvalidStatusTransition))
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recoder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			path: "freeze",
+			body: gin.H{"reason": "card reported stolen"},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock_store.MockTx) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_store.NewMockTx(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{Account: service.NewAccountService(nil, store)},
+				token:   token,
+			}
+			handler.Init(router.Group("/api"))
+
+			url := fmt.Sprintf("/api/v1/banker/accounts/%d/%s", account.ID, tc.path)
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+			tc.setupAuth(t, request, token)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCheckLedgerAPI(t *testing.T) {
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	discrepancy := domain.LedgerDiscrepancy{
+		Kind:      domain.LedgerAccountBalanceMismatch,
+		AccountID: 7,
+		Detail:    "balance 10, entries sum to 3",
+	}
+
+	testCases := []struct {
+		name          string
+		path          string
+		query         string
+		role          string
+		buildStubs    func(ledger *mock_store.MockLedger)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "AccountsPage",
+			path:  "accounts",
+			query: "after_id=2&limit=2",
+			role:  util.BankerRole,
+			buildStubs: func(ledger *mock_store.MockLedger) {
+				ledger.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Eq(domain.LedgerBatchParams{AfterID: 2, Limit: 2})).
+					Times(1).Return(domain.LedgerBatch{Checked: 2, LastID: 4}, nil)
+				ledger.EXPECT().CheckTransferEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var got checkLedgerResponse
+				require.NoError(t, json.NewDecoder(recoder.Body).Decode(&got))
+				require.True(t, got.OK)
+				require.Equal(t, 2, got.Checked)
+				require.Equal(t, 4, got.LastID)
+				require.Empty(t, got.Discrepancies)
+			},
+		},
+		{
+			name: "Discrepancy",
+			path: "accounts",
+			role: util.BankerRole,
+			buildStubs: func(ledger *mock_store.MockLedger) {
+				ledger.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Eq(domain.LedgerBatchParams{Limit: defaultLedgerPageSize})).
+					Times(1).Return(domain.LedgerBatch{Checked: 1, Discrepancies: []domain.LedgerDiscrepancy{discrepancy}}, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var got checkLedgerResponse
+				require.NoError(t, json.NewDecoder(recoder.Body).Decode(&got))
+				require.False(t, got.OK)
+				require.Zero(t, got.LastID)
+				require.Equal(t, []domain.LedgerDiscrepancy{discrepancy}, got.Discrepancies)
+			},
+		},
+		{
+			name: "TransfersPage",
+			path: "transfers",
+			role: util.BankerRole,
+			buildStubs: func(ledger *mock_store.MockLedger) {
+				ledger.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Any()).Times(0)
+				ledger.EXPECT().CheckTransferEntries(gomock.Any(), gomock.Eq(domain.LedgerBatchParams{Limit: defaultLedgerPageSize})).
+					Times(1).Return(domain.LedgerBatch{Checked: 3, Skipped: 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				var got checkLedgerResponse
+				require.NoError(t, json.NewDecoder(recoder.Body).Decode(&got))
+				require.True(t, got.OK)
+				require.Equal(t, 3, got.Checked)
+				require.Equal(t, 1, got.Skipped)
+			},
+		},
+		{
+			name:  "LimitTooLarge",
+			path:  "transfers",
+			query: "limit=5000",
+			role:  util.BankerRole,
+			buildStubs: func(ledger *mock_store.MockLedger) {
+				ledger.EXPECT().CheckTransferEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name: "Depositor",
+			path: "accounts",
+			role: util.DepositorRole,
+			buildStubs: func(ledger *mock_store.MockLedger) {
+				ledger.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			path: "accounts",
+			role: util.BankerRole,
+			buildStubs: func(ledger *mock_store.MockLedger) {
+				ledger.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Any()).Times(1).Return(domain.LedgerBatch{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ledger := mock_store.NewMockLedger(ctrl)
+			tc.buildStubs(ledger)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: &service.Service{Ledger: service.NewLedgerService(ledger)},
+				token:   token,
+			}
+			handler.Init(api)
+
+			url := fmt.Sprintf("/api/v1/banker/ledger/%s/check?%s", tc.path, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, token, "Bearer", "banker", tc.role, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/gin-gonic/gin"
+)
+
+type cashRequest struct {
+	Amount   int    `json:"amount" binding:"required,gt=0"`
+	Currency string `json:"currency" binding:"required,currency"`
+	// reference at the external processor, a repeated one is rejected
+	ExternalRef string `json:"external_ref" binding:"required,max=255"`
+}
+
+// cashHandler serves deposits and withdrawals, which only differ in the
+// service method booking them. Only bankers and the processor book cash, on
+// any customer's account.
+func (h *Handler) cashHandler(kind string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var uri getAccountRequest
+		if err := ctx.BindUri(&uri); err != nil {
+			newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+			return
+		}
+
+		var inp cashRequest
+		if err := ctx.BindJSON(&inp); err != nil {
+			newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+			return
+		}
+
+		auditTarget(ctx, domain.AuditTargetAccount, uri.ID)
+		account, ok := h.validAccount(ctx, uri.ID, inp.Currency)
+		if !ok {
+			return
+		}
+
+		arg := domain.CashParams{
+			AccountID:   account.ID,
+			Amount:      inp.Amount,
+			ExternalRef: inp.ExternalRef,
+		}
+
+		book := h.service.Cash.Deposit
+		if kind == domain.TransferKindWithdrawal {
+			book = h.service.Cash.Withdraw
+		}
+
+		result, err := book(ctx, arg)
+		if err != nil {
+			switch {
+			case errors.Is(err, e.ErrDuplicateExternalRef):
+				newResponse(ctx, http.StatusConflict, err.Error())
+			case errors.Is(err, e.ErrInsufficientFunds), errors.Is(err, e.ErrNoSettlementAccount),
+				errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+				newResponse(ctx, http.StatusUnprocessableEntity, err.Error())
+			default:
+				newResponse(ctx, http.StatusInternalServerError, "Incorect db:"+err.Error())
+			}
+			return
+		}
+
+		auditTarget(ctx, domain.AuditTargetTransfer, result.Transfer.ID)
+		ctx.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,238 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCashAPI(t *testing.T) {
+	amount := 100
+	user1, _ := randomUser(t)
+
+	account := randomAccount(user1.Username)
+	account.Currency = util.CAD
+
+	// no settlement account exists for EUR
+	account2 := randomAccount(user1.Username)
+	account2.Currency = util.EUR
+
+	settlement := domain.Account{ID: 1001, Owner: domain.SettlementOwner, Currency: util.CAD}
+	externalRef := util.RandomString(16)
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		path          string
+		accountID     int
+		body          cashRequest
+		setupAuth     func(t *testing.T, request *http.Request, token auth.TokenManager)
+		buildStubs    func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Deposit",
+			path:      "deposit",
+			accountID: account.ID,
+			body:      cashRequest{Amount: amount, Currency: util.CAD, ExternalRef: externalRef},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "processor", util.ProcessorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.CAD)).Times(1).Return(settlement, nil)
+
+				arg := domain.CashTxParams{
+					AccountID:           account.ID,
+					SettlementAccountID: 1001,
+					Amount:              amount,
+					ExternalRef:         externalRef,
+				}
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.CashTxResult{
+					Transfer: domain.Transfer{ID: 1, FromAccountID: 1001, ToAccountID: account.ID, Amount: amount, Kind: domain.TransferKindDeposit, ExternalRef: externalRef},
+				}, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result domain.CashTxResult
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+				require.Equal(t, domain.TransferKindDeposit, result.Transfer.Kind)
+				require.Equal(t, externalRef, result.Transfer.ExternalRef)
+			},
+		},
+		{
+			name:      "Withdraw",
+			path:      "withdraw",
+			accountID: account.ID,
+			body:      cashRequest{Amount: amount, Currency: util.CAD, ExternalRef: externalRef},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "processor", util.ProcessorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.CAD)).Times(1).Return(settlement, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.CashTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Depositor",
+			path:      "deposit",
+			accountID: account.ID,
+			body:      cashRequest{Amount: amount, Currency: util.CAD, ExternalRef: externalRef},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "CurrencyMismatch",
+			path:      "deposit",
+			accountID: account.ID,
+			body:      cashRequest{Amount: amount, Currency: util.USD, ExternalRef: externalRef},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "processor", util.ProcessorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingExternalRef",
+			path:      "deposit",
+			accountID: account.ID,
+			body:      cashRequest{Amount: amount, Currency: util.CAD},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "processor", util.ProcessorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "DuplicateExternalRef",
+			path:      "deposit",
+			accountID: account.ID,
+			body:      cashRequest{Amount: amount, Currency: util.CAD, ExternalRef: externalRef},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "processor", util.ProcessorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.CAD)).Times(1).Return(settlement, nil)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.CashTxResult{}, e.ErrDuplicateExternalRef)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			path:      "withdraw",
+			accountID: account.ID,
+			body:      cashRequest{Amount: amount, Currency: util.CAD, ExternalRef: externalRef},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "processor", util.ProcessorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(2).Return(account, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.CAD)).Times(1).Return(settlement, nil)
+				store.EXPECT().WithdrawTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.CashTxResult{}, e.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:      "NoSettlementAccount",
+			path:      "deposit",
+			accountID: account2.ID,
+			body:      cashRequest{Amount: amount, Currency: util.EUR, ExternalRef: externalRef},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "processor", util.ProcessorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				accounts.EXPECT().GetAccountByOwner(gomock.Any(), gomock.Eq(domain.SettlementOwner), gomock.Eq(util.EUR)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+				store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_repository.NewMockAccount(ctrl)
+			store := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(accounts, store)
+
+			service := &service.Service{
+				Account: service.NewAccountService(accounts, nil),
+				Cash:    service.NewCashService(store, accounts),
+			}
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: service,
+				token:   token,
+			}
+			handler.Init(api)
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/accounts/%d/%s", tc.accountID, tc.path)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, token)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -31,5 +31,8 @@ func (h *Handler) Init(api *gin.RouterGroup) {
 		h.initAccountsRoutes(v1)
 		h.initTransferTxRoutes(v1)
 		h.initUsersRoutes(v1)
+		h.initTokensRoutes(v1)
+		h.initBankerRoutes(v1)
+		h.initWebhookRoutes(v1)
 	}
 }
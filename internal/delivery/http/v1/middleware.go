@@ -6,38 +6,76 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
 	"github.com/gin-gonic/gin"
 )
 
 const (
 	authorizationHeaderKey = "Authorization"
 	userCtx                = "userId"
+	roleCtx                = "role"
 )
 
 func (h *Handler) userIdentity(ctx *gin.Context) {
-	username, err := h.parseAuthHeader(ctx)
+	identity, err := h.parseAuthHeader(ctx)
 
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	ctx.Set(userCtx, username)
+	ctx.Set(userCtx, identity.Username)
+	ctx.Set(roleCtx, identity.Role)
 	ctx.Next()
 }
 
-func (h *Handler) parseAuthHeader(ctx *gin.Context) (string, error) {
+// requireRole only lets through users with one of the given roles. It must
+// be registered after userIdentity.
+func (h *Handler) requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString(roleCtx)
+		for _, r := range roles {
+			if role == r {
+				ctx.Next()
+				return
+			}
+		}
+		// kept for the audit log
+		ctx.Error(fmt.Errorf("role %q is not allowed", role))
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	}
+}
+
+type access int
+
+const (
+	// the owner and bankers may look at a resource
+	readAccess access = iota
+	// only the owner may change it
+	writeAccess
+)
+
+// callerMay reports whether the authenticated user has the given access to
+// a resource of owner.
+func callerMay(ctx *gin.Context, owner string, a access) bool {
+	if owner == ctx.MustGet(userCtx).(string) {
+		return true
+	}
+	return a == readAccess && ctx.GetString(roleCtx) == util.BankerRole
+}
+
+func (h *Handler) parseAuthHeader(ctx *gin.Context) (auth.Identity, error) {
 	header := ctx.GetHeader(authorizationHeaderKey)
 	if header == "" {
-		return "", errors.New("empty auth header")
+		return auth.Identity{}, errors.New("empty auth header")
 	}
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-		fmt.Println(header)
-		return "", errors.New("invalid auth header")
+		return auth.Identity{}, errors.New("invalid auth header")
 	}
 
 	if len(headerParts[1]) == 0 {
-		return "", errors.New("token is empty")
+		return auth.Identity{}, errors.New("token is empty")
 	}
 
 	return h.token.Parse(headerParts[1])
@@ -8,12 +8,13 @@ import (
 	"time"
 
 	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
 
-func addAuthorization(t *testing.T, request *http.Request, token auth.TokenManager, authorizationType string, username string, duration time.Duration) {
-	accessToken, err := token.NewJWT(username, duration)
+func addAuthorization(t *testing.T, request *http.Request, token auth.TokenManager, authorizationType string, username string, role string, duration time.Duration) {
+	accessToken, err := token.NewJWT(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, accessToken)
 
@@ -34,7 +35,7 @@ func TestUserIdentity(t *testing.T) {
 		{
 			name: "ok",
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", "user", time.Minute)
+				addAuthorization(t, request, token, "Bearer", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recoder.Code)
@@ -43,7 +44,7 @@ func TestUserIdentity(t *testing.T) {
 		{
 			name: "invalid header",
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "", "user", time.Minute)
+				addAuthorization(t, request, token, "", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recoder.Code)
@@ -61,7 +62,7 @@ func TestUserIdentity(t *testing.T) {
 		{
 			name: "invalid header",
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "", "user", -time.Minute)
+				addAuthorization(t, request, token, "", "user", util.DepositorRole, -time.Minute)
 
 			},
 			checkResponse: func(recoder *httptest.ResponseRecorder) {
@@ -94,3 +95,72 @@ func TestUserIdentity(t *testing.T) {
 		})
 	}
 }
+
+func TestRequireRole(t *testing.T) {
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, token auth.TokenManager)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "banker",
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "banker", util.BankerRole, time.Minute)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+			},
+		},
+		{
+			name: "depositor",
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name: "no role",
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "user", "", time.Minute)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name: "no authorization",
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.Default()
+			handler := &Handler{
+				token: token,
+			}
+			router.GET("/banker", handler.userIdentity, handler.requireRole(util.BankerRole), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			request, err := http.NewRequest(http.MethodGet, "/banker", nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			tc.setupAuth(t, request, token)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package v1
 
 import (
-	"log"
+	"errors"
+	"log/slog"
+	"net/http"
 
+	"github.com/begenov/backend/pkg/logger"
 	"github.com/begenov/backend/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -20,6 +23,12 @@ var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 }
 
 func newResponse(c *gin.Context, statusCode int, message string) {
-	log.Println(message)
+	level := slog.LevelInfo
+	if statusCode >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logger.FromContext(c.Request.Context()).Log(c.Request.Context(), level, message, "status", statusCode)
+	// kept for the audit log
+	c.Error(errors.New(message))
 	c.AbortWithStatusJSON(statusCode, Resposne{Message: message})
 }
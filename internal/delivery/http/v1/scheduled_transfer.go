@@ -0,0 +1,226 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initScheduledTransferRoutes(transfers *gin.RouterGroup) {
+	scheduled := transfers.Group("/scheduled")
+	{
+		scheduled.POST("", h.audited(domain.AuditScheduledTransferCreate), h.createScheduledTransfer)
+		scheduled.GET("", h.listScheduledTransfers)
+		scheduled.GET("/:id", h.getScheduledTransfer)
+		scheduled.PUT("/:id", h.audited(domain.AuditScheduledTransferUpdate), h.updateScheduledTransfer)
+		scheduled.DELETE("/:id", h.audited(domain.AuditScheduledTransferDelete), h.deleteScheduledTransfer)
+		scheduled.GET("/:id/runs", h.listScheduledTransferRuns)
+	}
+}
+
+type createScheduledTransferRequest struct {
+	FromAccountID int    `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int    `json:"to_account_id" binding:"required,min=1"`
+	Amount        int    `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,oneof=USD EUR CAD"`
+	Schedule      string `json:"schedule" binding:"required"`
+}
+
+func (h *Handler) createScheduledTransfer(ctx *gin.Context) {
+	var inp createScheduledTransferRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	auditTarget(ctx, domain.AuditTargetAccount, inp.FromAccountID, inp.ToAccountID)
+
+	account, ok := h.validAccount(ctx, inp.FromAccountID, inp.Currency)
+	if !ok {
+		return
+	}
+
+	if !callerMay(ctx, account.Owner, writeAccess) {
+		newResponse(ctx, http.StatusForbidden, "from account doesn't belong to the authenticated user")
+		return
+	}
+
+	if _, ok := h.getAccount(ctx, inp.ToAccountID); !ok {
+		return
+	}
+
+	scheduled, err := h.service.ScheduledTransfer.CreateScheduledTransfer(ctx, domain.CreateScheduledTransferParams{
+		Owner:         account.Owner,
+		FromAccountID: inp.FromAccountID,
+		ToAccountID:   inp.ToAccountID,
+		Amount:        inp.Amount,
+		Schedule:      inp.Schedule,
+	})
+	if err != nil {
+		if errors.Is(err, e.ErrInvalidSchedule) {
+			newResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	auditTarget(ctx, domain.AuditTargetScheduledTransfer, scheduled.ID)
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int `form:"page_id" binding:"required,min=1"`
+	PageSize int `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *Handler) listScheduledTransfers(ctx *gin.Context) {
+	var inp listScheduledTransfersRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	scheduled, err := h.service.ScheduledTransfer.ListScheduledTransfers(ctx, domain.ListScheduledTransfersParams{
+		Owner:  ctx.MustGet(userCtx).(string),
+		Limit:  inp.PageSize,
+		Offset: (inp.PageID - 1) * inp.PageSize,
+	})
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type getScheduledTransferRequest struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+func (h *Handler) getScheduledTransfer(ctx *gin.Context) {
+	scheduled, ok := h.scheduledTransferOwnedByUser(ctx, readAccess)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount   int    `json:"amount" binding:"required,gt=0"`
+	Schedule string `json:"schedule" binding:"required"`
+	Active   *bool  `json:"active" binding:"required"`
+}
+
+func (h *Handler) updateScheduledTransfer(ctx *gin.Context) {
+	scheduled, ok := h.scheduledTransferOwnedByUser(ctx, writeAccess)
+	if !ok {
+		return
+	}
+
+	var inp updateScheduledTransferRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	scheduled, err := h.service.ScheduledTransfer.UpdateScheduledTransfer(ctx, domain.UpdateScheduledTransferParams{
+		ID:       scheduled.ID,
+		Amount:   inp.Amount,
+		Schedule: inp.Schedule,
+		Active:   *inp.Active,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrInvalidSchedule):
+			newResponse(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
+		default:
+			newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+func (h *Handler) deleteScheduledTransfer(ctx *gin.Context) {
+	scheduled, ok := h.scheduledTransferOwnedByUser(ctx, writeAccess)
+	if !ok {
+		return
+	}
+
+	if err := h.service.ScheduledTransfer.DeleteScheduledTransfer(ctx, scheduled.ID); err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type listScheduledTransferRunsRequest struct {
+	PageID   int `form:"page_id" binding:"required,min=1"`
+	PageSize int `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listScheduledTransferRuns returns the outcome of every attempt, newest
+// first.
+func (h *Handler) listScheduledTransferRuns(ctx *gin.Context) {
+	scheduled, ok := h.scheduledTransferOwnedByUser(ctx, readAccess)
+	if !ok {
+		return
+	}
+
+	var inp listScheduledTransferRunsRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	runs, err := h.service.ScheduledTransfer.ListScheduledTransferRuns(ctx, domain.ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               inp.PageSize,
+		Offset:              (inp.PageID - 1) * inp.PageSize,
+	})
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
+
+// scheduledTransferOwnedByUser loads the scheduled transfer named in the uri
+// and checks that the caller has the given access to it. Bankers may see any
+// of them, but only the owner may change one.
+func (h *Handler) scheduledTransferOwnedByUser(ctx *gin.Context, a access) (domain.ScheduledTransfer, bool) {
+	var uri getScheduledTransferRequest
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return domain.ScheduledTransfer{}, false
+	}
+	auditTarget(ctx, domain.AuditTargetScheduledTransfer, uri.ID)
+
+	scheduled, err := h.service.ScheduledTransfer.GetScheduledTransfer(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
+			return scheduled, false
+		}
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return scheduled, false
+	}
+
+	if !callerMay(ctx, scheduled.Owner, a) {
+		newResponse(ctx, http.StatusForbidden, "scheduled transfer doesn't belong to the authenticated user")
+		return scheduled, false
+	}
+
+	return scheduled, true
+}
@@ -0,0 +1,387 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	amount := 100
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(accounts *mock_repository.MockAccount, store *mock_repository.MockScheduledTransfer)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"schedule":        "0 9 1 * *",
+			},
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockScheduledTransfer) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg domain.CreateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+						require.Equal(t, user1.Username, arg.Owner)
+						require.Equal(t, 1, arg.NextRunAt.Day())
+						require.True(t, arg.NextRunAt.After(time.Now()))
+						return domain.ScheduledTransfer{
+							ID:            1,
+							Owner:         arg.Owner,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   arg.ToAccountID,
+							Amount:        arg.Amount,
+							Schedule:      arg.Schedule,
+							NextRunAt:     arg.NextRunAt,
+							Active:        true,
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got domain.ScheduledTransfer
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+				require.Equal(t, "0 9 1 * *", got.Schedule)
+				require.True(t, got.Active)
+			},
+		},
+		{
+			name: "InvalidSchedule",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"schedule":        "every friday",
+			},
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockScheduledTransfer) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwner",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"schedule":        "@every 168h",
+			},
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockScheduledTransfer) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Banker",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"schedule":        "@every 168h",
+			},
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockScheduledTransfer) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.EUR,
+				"schedule":        "@every 168h",
+			},
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockScheduledTransfer) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountNotFound",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        util.USD,
+				"schedule":        "@every 168h",
+			},
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockScheduledTransfer) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_repository.NewMockAccount(ctrl)
+			store := mock_repository.NewMockScheduledTransfer(ctrl)
+			tc.buildStubs(accounts, store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: &service.Service{
+					Account:           service.NewAccountService(accounts, nil),
+					ScheduledTransfer: service.NewScheduledTransferService(store),
+				},
+				token: token,
+			}
+			handler.Init(api)
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/transfers/scheduled", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, token, "Bearer", tc.username, tc.role, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateScheduledTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	scheduled := domain.ScheduledTransfer{
+		ID:            int(util.RandomInt(1, 1000)),
+		Owner:         user1.Username,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        100,
+		Schedule:      "@every 24h",
+		NextRunAt:     time.Now().Add(time.Hour),
+		Active:        true,
+	}
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(store *mock_repository.MockScheduledTransfer)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Pause",
+			body:     gin.H{"amount": 200, "schedule": "@weekly", "active": false},
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockScheduledTransfer) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg domain.UpdateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+						require.Equal(t, scheduled.ID, arg.ID)
+						require.Equal(t, 200, arg.Amount)
+						require.False(t, arg.Active)
+						require.Equal(t, time.Sunday, arg.NextRunAt.Weekday())
+
+						updated := scheduled
+						updated.Amount = arg.Amount
+						updated.Schedule = arg.Schedule
+						updated.NextRunAt = arg.NextRunAt
+						updated.Active = arg.Active
+						return updated, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got domain.ScheduledTransfer
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+				require.False(t, got.Active)
+				require.Equal(t, 200, got.Amount)
+			},
+		},
+		{
+			name:     "MissingActive",
+			body:     gin.H{"amount": 200, "schedule": "@weekly"},
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockScheduledTransfer) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			body:     gin.H{"amount": 200, "schedule": "@weekly", "active": true},
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockScheduledTransfer) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Banker",
+			body:     gin.H{"amount": 200, "schedule": "@weekly", "active": true},
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mock_repository.MockScheduledTransfer) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			body:     gin.H{"amount": 200, "schedule": "@weekly", "active": true},
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockScheduledTransfer) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(domain.ScheduledTransfer{}, sql.ErrNoRows)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockScheduledTransfer(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: &service.Service{ScheduledTransfer: service.NewScheduledTransferService(store)},
+				token:   token,
+			}
+			handler.Init(api)
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/api/v1/transfers/scheduled/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, token, "Bearer", tc.username, tc.role, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := domain.ScheduledTransfer{ID: 7, Owner: user.Username, Schedule: "@daily", Active: true}
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock_repository.NewMockScheduledTransfer(ctrl)
+	store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+	store.EXPECT().DeleteScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(nil)
+
+	recorder := httptest.NewRecorder()
+	router := gin.Default()
+	handler := &Handler{
+		service: &service.Service{ScheduledTransfer: service.NewScheduledTransferService(store)},
+		token:   token,
+	}
+	handler.Init(router.Group("/api"))
+
+	request, err := http.NewRequest(http.MethodDelete, "/api/v1/transfers/scheduled/7", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+	router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+}
@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/export"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type statementRequest struct {
+	// RFC 3339 timestamps, the period is [from, to)
+	From time.Time `form:"from" binding:"required"`
+	To   time.Time `form:"to" binding:"required,gtfield=From"`
+}
+
+func (h *Handler) getAccountStatement(ctx *gin.Context) {
+	arg, ok := h.bindStatementParams(ctx)
+	if !ok {
+		return
+	}
+
+	statement, err := h.service.Entry.GetStatement(ctx, arg)
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, statement)
+}
+
+func (h *Handler) exportAccountStatement(format export.Format) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		arg, ok := h.bindStatementParams(ctx)
+		if !ok {
+			return
+		}
+
+		filename := fmt.Sprintf("statement-%d-%s.%s", arg.AccountID, arg.From.UTC().Format("20060102"), export.FileExtension(format))
+		ctx.Header("Content-Type", export.ContentType(format))
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+		err := h.service.Export.ExportStatement(ctx, ctx.Writer, format, arg)
+		if err != nil {
+			if ctx.Writer.Written() {
+				// the status is already sent, all we can do is cut the body short
+				logger.FromContext(ctx.Request.Context()).Error("export statement", "account_id", arg.AccountID, "err", err)
+				return
+			}
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+			newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		}
+	}
+}
+
+// bindStatementParams reads the account and period of a statement request
+// and checks access to the account. On failure the response is already
+// written.
+func (h *Handler) bindStatementParams(ctx *gin.Context) (domain.StatementParams, bool) {
+	var uri getAccountRequest
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return domain.StatementParams{}, false
+	}
+
+	var inp statementRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return domain.StatementParams{}, false
+	}
+
+	if _, ok := h.accountOwnedByUser(ctx, uri.ID); !ok {
+		return domain.StatementParams{}, false
+	}
+
+	return domain.StatementParams{
+		AccountID: uri.ID,
+		From:      inp.From,
+		To:        inp.To,
+	}, true
+}
@@ -0,0 +1,328 @@
+package v1
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_store "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	from := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	statement := randomStatement(account, from, to)
+
+	testCases := []struct {
+		name          string
+		from          string
+		to            string
+		setupAuth     func(t *testing.T, request *http.Request, token auth.TokenManager)
+		buildStubs    func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			from: from.Format(time.RFC3339),
+			to:   to.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := domain.StatementParams{AccountID: account.ID, From: from, To: to}
+				entries.EXPECT().GetStatement(gomock.Any(), gomock.Eq(arg)).Times(1).Return(statement, nil)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				data, err := io.ReadAll(recoder.Body)
+				require.NoError(t, err)
+				var got domain.Statement
+				require.NoError(t, json.Unmarshal(data, &got))
+				require.Equal(t, statement, got)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			from: from.Format(time.RFC3339),
+			to:   to.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				entries.EXPECT().GetStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recoder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			from: from.Format(time.RFC3339),
+			to:   to.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+				entries.EXPECT().GetStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recoder.Code)
+			},
+		},
+		{
+			name: "InvalidPeriod",
+			from: to.Format(time.RFC3339),
+			to:   from.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				entries.EXPECT().GetStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name: "InvalidDate",
+			from: "2023-05-01",
+			to:   to.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				entries.EXPECT().GetStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+		{
+			name: "InternalServer",
+			from: from.Format(time.RFC3339),
+			to:   to.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				entries.EXPECT().GetStatement(gomock.Any(), gomock.Any()).Times(1).Return(domain.Statement{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_store.NewMockAccount(ctrl)
+			entries := mock_store.NewMockEntry(ctrl)
+			service := &service.Service{
+				Account: service.NewAccountService(accounts, nil),
+				Entry:   service.NewEntryService(entries),
+			}
+			tc.buildStubs(accounts, entries)
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: service,
+				token:   token,
+			}
+			handler.Init(api)
+
+			query := url.Values{}
+			query.Set("from", tc.from)
+			query.Set("to", tc.to)
+			path := fmt.Sprintf("/api/v1/accounts/%d/statement?%s", account.ID, query.Encode())
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, token)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestExportAccountStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	from := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	statement := randomStatement(account, from, to)
+
+	testCases := []struct {
+		name          string
+		path          string
+		buildStubs    func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "CSV",
+			path: "statement.csv",
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				entries.EXPECT().StreamStatement(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(streamStatement(statement))
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recoder.Header().Get("Content-Type"))
+				require.Contains(t, recoder.Header().Get("Content-Disposition"), ".csv")
+				require.Len(t, strings.Split(strings.TrimSpace(recoder.Body.String()), "\n"), len(statement.Entries)+1)
+			},
+		},
+		{
+			name: "OFX",
+			path: "statement.ofx",
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				entries.EXPECT().StreamStatement(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(streamStatement(statement))
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+				require.Equal(t, "application/x-ofx", recoder.Header().Get("Content-Type"))
+				require.Contains(t, recoder.Body.String(), "<OFX>")
+			},
+		},
+		{
+			name: "CAMT053",
+			path: "statement.xml",
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				entries.EXPECT().StreamStatement(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(streamStatement(statement))
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+				require.Equal(t, "application/xml; charset=utf-8", recoder.Header().Get("Content-Type"))
+				require.Contains(t, recoder.Body.String(), "camt.053.001.02")
+			},
+		},
+		{
+			name: "InternalServer",
+			path: "statement.csv",
+			buildStubs: func(accounts *mock_store.MockAccount, entries *mock_store.MockEntry) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				entries.EXPECT().StreamStatement(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recoder.Code)
+				require.Empty(t, recoder.Header().Get("Content-Disposition"))
+				require.Contains(t, recoder.Header().Get("Content-Type"), "application/json")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_store.NewMockAccount(ctrl)
+			entries := mock_store.NewMockEntry(ctrl)
+			service := &service.Service{
+				Account: service.NewAccountService(accounts, nil),
+				Export:  service.NewExportService(entries),
+			}
+			tc.buildStubs(accounts, entries)
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: service,
+				token:   token,
+			}
+			handler.Init(api)
+
+			query := url.Values{}
+			query.Set("from", from.Format(time.RFC3339))
+			query.Set("to", to.Format(time.RFC3339))
+			path := fmt.Sprintf("/api/v1/accounts/%d/%s?%s", account.ID, tc.path, query.Encode())
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// streamStatement hands statement to the callbacks of StreamStatement.
+func streamStatement(statement domain.Statement) func(context.Context, domain.StatementParams, func(domain.Statement) error, func(domain.StatementEntry) error) error {
+	return func(_ context.Context, _ domain.StatementParams, begin func(domain.Statement) error, entry func(domain.StatementEntry) error) error {
+		if err := begin(statement); err != nil {
+			return err
+		}
+		for _, e := range statement.Entries {
+			if err := entry(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func randomStatement(account domain.Account, from, to time.Time) domain.Statement {
+	statement := domain.Statement{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: int(util.RandomMany()),
+	}
+
+	balance := statement.OpeningBalance
+	for i := 0; i < 3; i++ {
+		amount := int(util.RandomInt(-100, 100))
+		balance += amount
+		statement.Entries = append(statement.Entries, domain.StatementEntry{
+			ID:                    int(util.RandomInt(1, 1000)),
+			Amount:                amount,
+			Balance:               balance,
+			TransferID:            int(util.RandomInt(1, 1000)),
+			CounterpartyAccountID: int(util.RandomInt(1, 1000)),
+			CounterpartyOwner:     util.RandomOwner(),
+			CreatedAt:             from.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	statement.ClosingBalance = balance
+	return statement
+}
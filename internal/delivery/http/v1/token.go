@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initTokensRoutes(api *gin.RouterGroup) {
+	tokens := api.Group("/tokens")
+	{
+		tokens.POST("/renew_access", h.audited(domain.AuditTokenRenew), h.renewAccessToken)
+	}
+}
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *Handler) renewAccessToken(ctx *gin.Context) {
+	var inp renewAccessTokenRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	res, err := h.service.User.RenewAccessToken(ctx, inp.RefreshToken)
+	if err != nil {
+		switch err {
+		case e.ErrInvalidToken, e.ErrExpiredToken, e.ErrBlockedSession:
+			newResponse(ctx, http.StatusUnauthorized, err.Error())
+			return
+		default:
+			newResponse(ctx, http.StatusInternalServerError, "Invalid db:"+err.Error())
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
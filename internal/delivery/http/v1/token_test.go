@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	refreshToken := util.RandomString(32)
+
+	session := domain.Session{
+		ID:        int(util.RandomInt(1, 1000)),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock_repository.MockSession, users *mock_repository.MockUser)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mock_repository.MockSession, users *mock_repository.MockUser) {
+				store.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+				store.EXPECT().RotateSession(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+				users.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recoder.Code)
+
+				data, err := io.ReadAll(recoder.Body)
+				require.NoError(t, err)
+				var res domain.RenewAccessTokenResponse
+				require.NoError(t, json.Unmarshal(data, &res))
+				require.NotEmpty(t, res.AccessToken)
+				require.NotEmpty(t, res.RefreshToken)
+				require.NotEqual(t, refreshToken, res.RefreshToken)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			body: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mock_repository.MockSession, users *mock_repository.MockUser) {
+				store.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(domain.Session{}, sql.ErrNoRows)
+				store.EXPECT().RotateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recoder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			body: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mock_repository.MockSession, users *mock_repository.MockUser) {
+				blocked := session
+				blocked.IsBlocked = true
+				store.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(blocked, nil)
+				store.EXPECT().RotateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recoder.Code)
+			},
+		},
+		{
+			name: "ExpiredSession",
+			body: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mock_repository.MockSession, users *mock_repository.MockUser) {
+				expired := session
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(expired, nil)
+				store.EXPECT().RotateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recoder.Code)
+			},
+		},
+		{
+			name: "AlreadyRotated",
+			body: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mock_repository.MockSession, users *mock_repository.MockUser) {
+				store.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+				store.EXPECT().RotateSession(gomock.Any(), gomock.Any()).Times(1).Return(domain.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recoder.Code)
+			},
+		},
+		{
+			name: "InternalServer",
+			body: gin.H{"refresh_token": refreshToken},
+			buildStubs: func(store *mock_repository.MockSession, users *mock_repository.MockUser) {
+				store.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).Times(1).Return(domain.Session{}, sql.ErrConnDone)
+				store.EXPECT().RotateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recoder.Code)
+			},
+		},
+		{
+			name: "InvalidInput",
+			body: gin.H{},
+			buildStubs: func(store *mock_repository.MockSession, users *mock_repository.MockUser) {
+				store.EXPECT().GetSessionByRefreshToken(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RotateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recoder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recoder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockSession(ctrl)
+			users := mock_repository.NewMockUser(ctrl)
+			tc.buildStubs(store, users)
+			token, err := auth.NewManager(util.RandomString(6))
+			require.NoError(t, err)
+
+			service := &service.Service{
+				User: service.NewUserService(users, store, h, token, 15*time.Minute, 24*time.Hour),
+			}
+
+			handler := NewHandler(service, token)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			router := gin.Default()
+			api := router.Group("/api")
+			handler.Init(api)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/tokens/renew_access", bytes.NewBuffer(data))
+			require.NoError(t, err)
+			router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
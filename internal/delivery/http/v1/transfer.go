@@ -1,22 +1,34 @@
 package v1
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 
 	"github.com/begenov/backend/internal/domain"
 	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
 	"github.com/gin-gonic/gin"
 )
 
 func (h *Handler) initTransferTxRoutes(api *gin.RouterGroup) {
 	transfers := api.Group("/transfers", h.userIdentity)
 	{
-		transfers.POST("/create", h.createTransfer)
+		transfers.POST("/create", h.audited(domain.AuditTransferCreate), h.createTransfer)
+		transfers.POST("/batch", h.audited(domain.AuditTransferBatch), h.createBatchTransfer)
+		transfers.POST("/:id/reverse", h.audited(domain.AuditTransferReverse), h.requireRole(util.BankerRole), h.reverseTransfer)
+		h.initScheduledTransferRoutes(transfers)
 	}
 }
 
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
 type transferRequest struct {
 	FromAccountID int    `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int    `json:"to_account_id" binding:"required,min=1"`
@@ -30,6 +42,13 @@ func (h *Handler) createTransfer(ctx *gin.Context) {
 		newResponse(ctx, http.StatusBadRequest, "Incorect input"+err.Error())
 		return
 	}
+	auditTarget(ctx, domain.AuditTargetAccount, inp.FromAccountID, inp.ToAccountID)
+
+	idempotencyKey := ctx.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		newResponse(ctx, http.StatusBadRequest, "Incorect input: idempotency key is too long")
+		return
+	}
 
 	account, ok := h.validAccount(ctx, inp.FromAccountID, inp.Currency)
 	if !ok {
@@ -39,40 +58,171 @@ func (h *Handler) createTransfer(ctx *gin.Context) {
 	username := ctx.MustGet(userCtx).(string)
 
 	if account.Owner != username {
-		log.Println(account.Owner, username)
 		newResponse(ctx, http.StatusUnauthorized, "from account doent't belong to the authenticated user")
 		return
 	}
 
-	_, ok = h.validAccount(ctx, inp.ToAccountID, inp.Currency)
+	// the to account may hold another currency, the amount is converted
+	_, ok = h.getAccount(ctx, inp.ToAccountID)
 	if !ok {
 		return
 	}
 
 	arg := domain.TransferTxParams{
-		FromAccountID: inp.FromAccountID,
-		ToAccountID:   inp.ToAccountID,
-		Amount:        inp.Amount,
+		FromAccountID:  inp.FromAccountID,
+		ToAccountID:    inp.ToAccountID,
+		Amount:         inp.Amount,
+		Currency:       inp.Currency,
+		IdempotencyKey: idempotencyKey,
+		Owner:          username,
 	}
 
 	result, err := h.service.TransferTx.TransferTx(ctx, arg)
 	if err != nil {
-		newResponse(ctx, http.StatusInternalServerError, "Incorect db:"+err.Error())
+		switch {
+		case errors.Is(err, e.ErrIdempotencyKeyConflict), errors.Is(err, e.ErrInsufficientFunds),
+			errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+			newResponse(ctx, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, e.ErrIdempotencyKeyInProgress):
+			newResponse(ctx, http.StatusConflict, err.Error())
+		case errors.Is(err, e.ErrRateNotFound), errors.Is(err, e.ErrAmountTooSmall):
+			newResponse(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, e.ErrSettlementAccount):
+			newResponse(ctx, http.StatusForbidden, err.Error())
+		default:
+			newResponse(ctx, http.StatusInternalServerError, "Incorect db:"+err.Error())
+		}
 		return
 	}
 
+	auditTarget(ctx, domain.AuditTargetTransfer, result.Transfer.ID)
+	if result.Replayed {
+		ctx.Header(idempotentReplayedHeader, "true")
+	}
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (h *Handler) validAccount(ctx *gin.Context, accountID int, currency string) (domain.Account, bool) {
+type batchTransferRequest struct {
+	Mode      string            `json:"mode" binding:"required,oneof=atomic best_effort"`
+	Transfers []transferRequest `json:"transfers" binding:"required,min=1,dive"`
+}
 
-	account, err := h.service.Account.GetAccountByID(ctx, accountID)
+// createBatchTransfer books many transfers in one call, e.g. a payroll run.
+// Every from account has to belong to the caller and hold the given currency,
+// otherwise the whole batch is rejected before anything is booked.
+func (h *Handler) createBatchTransfer(ctx *gin.Context) {
+	var inp batchTransferRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorect input"+err.Error())
+		return
+	}
+	if len(inp.Transfers) > domain.MaxBatchTransfers {
+		newResponse(ctx, http.StatusBadRequest, fmt.Sprintf("Incorect input: batch holds more than %d transfers", domain.MaxBatchTransfers))
+		return
+	}
+
+	username := ctx.MustGet(userCtx).(string)
+
+	// payroll batches repeat the same from account, look each one up once
+	checked := make(map[int]string)
+	arg := domain.BatchTransferParams{
+		Mode:      inp.Mode,
+		Transfers: make([]domain.TransferTxParams, 0, len(inp.Transfers)),
+	}
+	for _, transfer := range inp.Transfers {
+		auditTarget(ctx, domain.AuditTargetAccount, transfer.FromAccountID, transfer.ToAccountID)
+		if currency, ok := checked[transfer.FromAccountID]; !ok || currency != transfer.Currency {
+			account, ok := h.validAccount(ctx, transfer.FromAccountID, transfer.Currency)
+			if !ok {
+				return
+			}
+
+			if account.Owner != username {
+				newResponse(ctx, http.StatusUnauthorized, fmt.Sprintf("from account [%d] doent't belong to the authenticated user", account.ID))
+				return
+			}
+			checked[transfer.FromAccountID] = transfer.Currency
+		}
+
+		arg.Transfers = append(arg.Transfers, domain.TransferTxParams{
+			FromAccountID: transfer.FromAccountID,
+			ToAccountID:   transfer.ToAccountID,
+			Amount:        transfer.Amount,
+		})
+	}
+
+	result, err := h.service.TransferTx.BatchTransfer(ctx, arg)
 	if err != nil {
-		if err == e.ErrRecordNotFound {
+		switch {
+		case errors.Is(err, e.ErrInsufficientFunds), errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+			newResponse(ctx, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, e.ErrRecordNotFound), errors.Is(err, sql.ErrNoRows):
 			newResponse(ctx, http.StatusNotFound, err.Error())
-			return account, false
+		case errors.Is(err, e.ErrRateNotFound), errors.Is(err, e.ErrAmountTooSmall):
+			newResponse(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, e.ErrSettlementAccount):
+			newResponse(ctx, http.StatusForbidden, err.Error())
+		default:
+			newResponse(ctx, http.StatusInternalServerError, "Incorect db:"+err.Error())
 		}
-		newResponse(ctx, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for _, item := range result.Items {
+		if item.Result != nil {
+			auditTarget(ctx, domain.AuditTargetTransfer, item.Result.Transfer.ID)
+		}
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+type reverseTransferRequest struct {
+	// zero or missing reverses whatever is left of the transfer
+	Amount int `json:"amount" binding:"min=0"`
+}
+
+// reverseTransfer lets support undo a transfer, fully or in part.
+func (h *Handler) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorect input"+err.Error())
+		return
+	}
+	auditTarget(ctx, domain.AuditTargetTransfer, uri.ID)
+
+	var inp reverseTransferRequest
+	if err := ctx.ShouldBindJSON(&inp); err != nil && !errors.Is(err, io.EOF) {
+		newResponse(ctx, http.StatusBadRequest, "Incorect input"+err.Error())
+		return
+	}
+
+	result, err := h.service.TransferTx.ReverseTransfer(ctx, domain.ReverseTransferParams{
+		TransferID: uri.ID,
+		Amount:     inp.Amount,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			newResponse(ctx, http.StatusNotFound, err.Error())
+		case errors.Is(err, e.ErrTransferNotReversible), errors.Is(err, e.ErrReversalExceedsAmount), errors.Is(err, e.ErrInsufficientFunds),
+			errors.Is(err, e.ErrAccountFrozen), errors.Is(err, e.ErrAccountClosed):
+			newResponse(ctx, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, e.ErrAmountTooSmall):
+			newResponse(ctx, http.StatusBadRequest, err.Error())
+		default:
+			newResponse(ctx, http.StatusInternalServerError, "Incorect db:"+err.Error())
+		}
+		return
+	}
+
+	auditTarget(ctx, domain.AuditTargetTransfer, result.Transfer.ID)
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) validAccount(ctx *gin.Context, accountID int, currency string) (domain.Account, bool) {
+
+	account, ok := h.getAccount(ctx, accountID)
+	if !ok {
 		return account, false
 	}
 
@@ -85,3 +235,17 @@ func (h *Handler) validAccount(ctx *gin.Context, accountID int, currency string)
 	return account, true
 
 }
+
+func (h *Handler) getAccount(ctx *gin.Context, accountID int) (domain.Account, bool) {
+	account, err := h.service.Account.GetAccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newResponse(ctx, http.StatusNotFound, err.Error())
+			return account, false
+		}
+		newResponse(ctx, http.StatusInternalServerError, err.Error())
+		return account, false
+	}
+
+	return account, true
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/begenov/backend/internal/service"
 	"github.com/begenov/backend/pkg/auth"
 	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/exchange"
 	"github.com/begenov/backend/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -34,6 +35,10 @@ func TestCreateTransfer(t *testing.T) {
 	account2.Currency = util.CAD
 	account3.Currency = util.EUR
 
+	// a currency the rate provider has no quote for
+	account4 := randomAccount(user2.Username)
+	account4.Currency = "GBP"
+
 	token, err := auth.NewManager("qwe")
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
@@ -54,16 +59,20 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
-				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
 
 				arg := domain.TransferTxParams{
 					FromAccountID: account1.ID,
 					ToAccountID:   account2.ID,
 					Amount:        amount,
+					ToAmount:      amount,
+					ExchangeRate:  1,
+					Currency:      util.CAD,
+					Owner:         user1.Username,
 				}
 				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
 
@@ -81,10 +90,10 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
-				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(domain.Account{}, e.ErrRecordNotFound)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
 
 				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
@@ -103,11 +112,11 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(domain.Account{}, e.ErrRecordNotFound)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
 
 				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
 
@@ -125,7 +134,7 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -147,7 +156,7 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -169,7 +178,7 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -191,7 +200,7 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      "asdf",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -213,7 +222,7 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      "asdf",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
@@ -235,7 +244,7 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(domain.Account{}, sql.ErrConnDone)
@@ -256,7 +265,7 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
@@ -277,11 +286,11 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user1.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
-				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
 				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, sql.ErrConnDone)
 
 			},
@@ -290,7 +299,7 @@ func TestCreateTransfer(t *testing.T) {
 			},
 		},
 		{
-			name: "InvalidCurrency",
+			name: "CrossCurrency",
 			body: transferRequest{
 				FromAccountID: account2.ID,
 				ToAccountID:   account3.ID,
@@ -298,11 +307,84 @@ func TestCreateTransfer(t *testing.T) {
 				Currency:      util.CAD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
-				addAuthorization(t, request, token, "Bearer", user2.Username, time.Minute)
+				addAuthorization(t, request, token, "Bearer", user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(2).Return(account3, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+
+				// 10 CAD at 0.666667 EUR per CAD
+				arg := domain.TransferTxParams{
+					FromAccountID: account2.ID,
+					ToAccountID:   account3.ID,
+					Amount:        amount,
+					ToAmount:      7,
+					ExchangeRate:  0.666667,
+					Currency:      util.CAD,
+					Owner:         user2.Username,
+				}
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "FromAccountCurrencyMismatch",
+			body: transferRequest{
+				FromAccountID: account2.ID,
+				ToAccountID:   account3.ID,
+				Amount:        amount,
+				Currency:      util.EUR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user2.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
-				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
 				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(0)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: transferRequest{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.CAD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, e.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "RateNotFound",
+			body: transferRequest{
+				FromAccountID: account1.ID,
+				ToAccountID:   account4.ID,
+				Amount:        amount,
+				Currency:      util.CAD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, token auth.TokenManager) {
+				addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account4.ID)).Times(2).Return(account4, nil)
 				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
 
 			},
@@ -324,8 +406,8 @@ func TestCreateTransfer(t *testing.T) {
 			tc.buildStubs(store1, store2)
 
 			service := &service.Service{
-				Account:    service.NewAccountService(store1),
-				TransferTx: service.NewTransferService(store2),
+				Account:    service.NewAccountService(store1, nil),
+				TransferTx: service.NewTransferService(store2, store1, testRates),
 			}
 
 			recorder := httptest.NewRecorder()
@@ -352,3 +434,498 @@ func TestCreateTransfer(t *testing.T) {
 
 	}
 }
+
+var testRates = exchange.NewStaticProvider(util.USD, map[string]float64{
+	util.EUR: 0.9,
+	util.CAD: 1.35,
+})
+
+func TestCreateTransferIdempotency(t *testing.T) {
+	amount := 10
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+
+	account1.Currency = util.CAD
+	account2.Currency = util.CAD
+
+	idempotencyKey := util.RandomString(16)
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	arg := domain.TransferTxParams{
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         amount,
+		ToAmount:       amount,
+		ExchangeRate:   1,
+		IdempotencyKey: idempotencyKey,
+		Currency:       util.CAD,
+		Owner:          user1.Username,
+	}
+
+	testCases := []struct {
+		name           string
+		idempotencyKey string
+		buildStubs     func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx)
+		checkResponse  func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "Ok",
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name:           "Replayed",
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{Replayed: true}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name:           "Conflict",
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{}, e.ErrIdempotencyKeyConflict)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:           "InProgress",
+			idempotencyKey: idempotencyKey,
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{}, e.ErrIdempotencyKeyInProgress)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:           "KeyTooLong",
+			idempotencyKey: util.RandomString(maxIdempotencyKeyLength + 1),
+			buildStubs: func(store1 *mock_repository.MockAccount, store2 *mock_repository.MockTx) {
+				store1.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store2.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store1 := mock_repository.NewMockAccount(ctrl)
+			store2 := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(store1, store2)
+
+			service := &service.Service{
+				Account:    service.NewAccountService(store1, nil),
+				TransferTx: service.NewTransferService(store2, store1, testRates),
+			}
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: service,
+				token:   token,
+			}
+			handler.Init(api)
+
+			body, err := json.Marshal(transferRequest{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+				Currency:      util.CAD,
+			})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/transfers/create", bytes.NewBuffer(body))
+			require.NoError(t, err)
+			request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			addAuthorization(t, request, token, "Bearer", user1.Username, util.DepositorRole, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestReverseTransferAPI(t *testing.T) {
+	transferID := 42
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          string
+		role          string
+		buildStubs    func(store *mock_repository.MockTx)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Full",
+			role: util.BankerRole,
+			buildStubs: func(store *mock_repository.MockTx) {
+				arg := domain.ReverseTransferParams{TransferID: transferID}
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{
+					Transfer: domain.Transfer{ID: 43, Kind: domain.TransferKindReversal, ReversalOf: transferID},
+				}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result domain.TransferTxResult
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+				require.Equal(t, transferID, result.Transfer.ReversalOf)
+			},
+		},
+		{
+			name: "Partial",
+			body: `{"amount": 5}`,
+			role: util.BankerRole,
+			buildStubs: func(store *mock_repository.MockTx) {
+				arg := domain.ReverseTransferParams{TransferID: transferID, Amount: 5}
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(domain.TransferTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Depositor",
+			role: util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockTx) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeAmount",
+			body: `{"amount": -5}`,
+			role: util.BankerRole,
+			buildStubs: func(store *mock_repository.MockTx) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			role: util.BankerRole,
+			buildStubs: func(store *mock_repository.MockTx) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "ExceedsAmount",
+			body: `{"amount": 1000}`,
+			role: util.BankerRole,
+			buildStubs: func(store *mock_repository.MockTx) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, e.ErrReversalExceedsAmount)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_repository.NewMockAccount(ctrl)
+			store := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(store)
+
+			service := &service.Service{
+				TransferTx: service.NewTransferService(store, accounts, testRates),
+			}
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			api := router.Group("/api")
+			handler := &Handler{
+				service: service,
+				token:   token,
+			}
+			handler.Init(api)
+
+			url := fmt.Sprintf("/api/v1/transfers/%d/reverse", transferID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			addAuthorization(t, request, token, "Bearer", "support", tc.role, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestBatchTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account3 := randomAccount(user2.Username)
+	account1.ID, account2.ID, account3.ID = 1, 2, 3
+	account1.Currency, account2.Currency, account3.Currency = util.USD, util.USD, util.EUR
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	item := func(from, to domain.Account, amount int, currency string) gin.H {
+		return gin.H{
+			"from_account_id": from.ID,
+			"to_account_id":   to.ID,
+			"amount":          amount,
+			"currency":        currency,
+		}
+	}
+
+	tooManyTransfers := make([]gin.H, domain.MaxBatchTransfers+1)
+	for i := range tooManyTransfers {
+		tooManyTransfers[i] = item(account1, account2, 10, util.USD)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Atomic",
+			body: gin.H{
+				"mode":      domain.BatchAtomic,
+				"transfers": []gin.H{item(account1, account2, 10, util.USD), item(account1, account3, 100, util.USD)},
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				// once for the ownership check and once per transfer for the rate
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(3).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+
+				arg := []domain.TransferTxParams{
+					{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10, ToAmount: 10, ExchangeRate: 1},
+					{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 100, ToAmount: 90, ExchangeRate: 0.9},
+				}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]domain.TransferTxResult{{}, {}}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result domain.BatchTransferResult
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+				require.Equal(t, 2, result.Succeeded)
+				require.Zero(t, result.Failed)
+			},
+		},
+		{
+			name: "AtomicInsufficientFunds",
+			body: gin.H{
+				"mode":      domain.BatchAtomic,
+				"transfers": []gin.H{item(account1, account2, 10, util.USD)},
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(nil, &e.BatchTransferError{Index: 0, Err: e.ErrInsufficientFunds})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "AtomicToAccountNotFound",
+			body: gin.H{
+				"mode":      domain.BatchAtomic,
+				"transfers": []gin.H{item(account1, account2, 10, util.USD)},
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(2).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(domain.Account{}, sql.ErrNoRows)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BestEffort",
+			body: gin.H{
+				"mode":      domain.BatchBestEffort,
+				"transfers": []gin.H{item(account1, account2, 10, util.USD), item(account1, account2, 1000, util.USD)},
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(3).Return(account1, nil)
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(2).Return(account2, nil)
+				gomock.InOrder(
+					store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{Transfer: domain.Transfer{ID: 1}}, nil),
+					store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(domain.TransferTxResult{}, e.ErrInsufficientFunds),
+				)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result domain.BatchTransferResult
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&result))
+				require.Equal(t, 1, result.Succeeded)
+				require.Equal(t, 1, result.Failed)
+				require.Equal(t, 1, result.Items[0].Result.Transfer.ID)
+				require.Equal(t, e.ErrInsufficientFunds.Error(), result.Items[1].Error)
+			},
+		},
+		{
+			name: "NotOwner",
+			body: gin.H{
+				"mode":      domain.BatchBestEffort,
+				"transfers": []gin.H{item(account1, account2, 10, util.USD)},
+			},
+			username: user2.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"mode":      domain.BatchAtomic,
+				"transfers": []gin.H{item(account1, account2, 10, util.EUR)},
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidMode",
+			body: gin.H{
+				"mode":      "sometimes",
+				"transfers": []gin.H{item(account1, account2, 10, util.USD)},
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidItem",
+			body: gin.H{
+				"mode":      domain.BatchAtomic,
+				"transfers": []gin.H{item(account1, account2, -10, util.USD)},
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyTransfers",
+			body: gin.H{
+				"mode":      domain.BatchAtomic,
+				"transfers": tooManyTransfers,
+			},
+			username: user1.Username,
+			buildStubs: func(accounts *mock_repository.MockAccount, store *mock_repository.MockTx) {
+				accounts.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			accounts := mock_repository.NewMockAccount(ctrl)
+			store := mock_repository.NewMockTx(ctrl)
+			tc.buildStubs(accounts, store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{
+					Account:    service.NewAccountService(accounts, nil),
+					TransferTx: service.NewTransferService(store, accounts, testRates),
+				},
+				token: token,
+			}
+			handler.Init(router.Group("/api"))
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/transfers/batch", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, token, "Bearer", tc.username, util.DepositorRole, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -12,8 +12,9 @@ import (
 func (h *Handler) initUsersRoutes(api *gin.RouterGroup) {
 	users := api.Group("/users")
 	{
-		users.POST("/create", h.createUser)
-		users.POST("/login", h.loginUser)
+		users.POST("/create", h.audited(domain.AuditUserCreate), h.createUser)
+		users.POST("/login", h.audited(domain.AuditUserLogin), h.loginUser)
+		users.POST("/logout", h.audited(domain.AuditUserLogout), h.logoutUser)
 	}
 }
 
@@ -30,6 +31,8 @@ func (h *Handler) createUser(ctx *gin.Context) {
 		newResponse(ctx, http.StatusBadRequest, "Invalid input"+err.Error())
 		return
 	}
+	auditActor(ctx, inp.Username)
+
 	arg := domain.CreateUserParams{
 		Username:       inp.Username,
 		HashedPassword: inp.Password,
@@ -64,8 +67,16 @@ func (h *Handler) loginUser(ctx *gin.Context) {
 		newResponse(ctx, http.StatusBadRequest, "Invalid input")
 		return
 	}
+	auditActor(ctx, inp.Username)
+
+	arg := domain.LoginUserParams{
+		Username:  inp.Username,
+		Password:  inp.Password,
+		UserAgent: ctx.Request.UserAgent(),
+		ClientIP:  ctx.ClientIP(),
+	}
 
-	res, err := h.service.User.GetUserByUsername(ctx, inp.Username, inp.Password)
+	res, err := h.service.User.GetUserByUsername(ctx, arg)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
@@ -83,9 +94,35 @@ func (h *Handler) loginUser(ctx *gin.Context) {
 		}
 	}
 
+	auditTarget(ctx, domain.AuditTargetSession, res.SessionID)
 	ctx.JSON(http.StatusOK, res)
 }
 
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *Handler) logoutUser(ctx *gin.Context) {
+	var inp logoutUserRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	if err := h.service.User.Logout(ctx, inp.RefreshToken); err != nil {
+		switch err {
+		case e.ErrInvalidToken, e.ErrExpiredToken, e.ErrBlockedSession:
+			newResponse(ctx, http.StatusUnauthorized, err.Error())
+			return
+		default:
+			newResponse(ctx, http.StatusInternalServerError, "Invalid db:"+err.Error())
+			return
+		}
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 func newUserResponse(user domain.User) domain.UserResponse {
 	return domain.UserResponse{
 		Username:          user.Username,
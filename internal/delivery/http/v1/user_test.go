@@ -193,7 +193,7 @@ func TestCreateUser(t *testing.T) {
 			require.NotEmpty(t, token)
 
 			service := &service.Service{
-				User: service.NewUserService(store, h, token, 15*time.Minute),
+				User: service.NewUserService(store, mock_repository.NewMockSession(ctrl), h, token, 15*time.Minute, 24*time.Hour),
 			}
 
 			handler := NewHandler(service, token)
@@ -225,6 +225,7 @@ func randomUser(t *testing.T) (domain.User, string) {
 		HashedPassword: hashedPassword,
 		FullName:       util.RandomOwner(),
 		Email:          util.RandomEmail(),
+		Role:           util.DepositorRole,
 	}, password
 }
 
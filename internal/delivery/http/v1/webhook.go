@@ -0,0 +1,205 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initWebhookRoutes(api *gin.RouterGroup) {
+	webhooks := api.Group("/webhooks", h.userIdentity)
+	{
+		webhooks.POST("", h.audited(domain.AuditWebhookCreate), h.createWebhook)
+		webhooks.GET("", h.listWebhooks)
+		webhooks.GET("/:id", h.getWebhook)
+		webhooks.PUT("/:id", h.audited(domain.AuditWebhookUpdate), h.updateWebhook)
+		webhooks.DELETE("/:id", h.audited(domain.AuditWebhookDelete), h.deleteWebhook)
+		webhooks.GET("/:id/deliveries", h.listWebhookDeliveries)
+	}
+}
+
+type createWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,startswith=https://"`
+	EventTypes []string `json:"event_types" binding:"dive,oneof=account.created account.status_changed transfer.completed"`
+}
+
+// createWebhook subscribes the caller to the events of their accounts. The
+// response is the only place the signing secret is shown. Receivers must use
+// https, and the sender refuses to connect to addresses that aren't public.
+func (h *Handler) createWebhook(ctx *gin.Context) {
+	var inp createWebhookRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	subscription, err := h.service.Webhook.CreateWebhookSubscription(ctx, domain.CreateWebhookSubscriptionParams{
+		Owner:      ctx.MustGet(userCtx).(string),
+		URL:        inp.URL,
+		EventTypes: inp.EventTypes,
+	})
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	auditTarget(ctx, domain.AuditTargetWebhook, subscription.ID)
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+type listWebhooksRequest struct {
+	PageID   int `form:"page_id" binding:"required,min=1"`
+	PageSize int `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (h *Handler) listWebhooks(ctx *gin.Context) {
+	var inp listWebhooksRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	subscriptions, err := h.service.Webhook.ListWebhookSubscriptions(ctx, domain.ListWebhookSubscriptionsParams{
+		Owner:  ctx.MustGet(userCtx).(string),
+		Limit:  inp.PageSize,
+		Offset: (inp.PageID - 1) * inp.PageSize,
+	})
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+type getWebhookRequest struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+func (h *Handler) getWebhook(ctx *gin.Context) {
+	subscription, ok := h.webhookOwnedByUser(ctx, readAccess)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+type updateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,startswith=https://"`
+	EventTypes []string `json:"event_types" binding:"dive,oneof=account.created account.status_changed transfer.completed"`
+	Active     *bool    `json:"active" binding:"required"`
+}
+
+func (h *Handler) updateWebhook(ctx *gin.Context) {
+	subscription, ok := h.webhookOwnedByUser(ctx, writeAccess)
+	if !ok {
+		return
+	}
+
+	var inp updateWebhookRequest
+	if err := ctx.BindJSON(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	subscription, err := h.service.Webhook.UpdateWebhookSubscription(ctx, domain.UpdateWebhookSubscriptionParams{
+		ID:         subscription.ID,
+		URL:        inp.URL,
+		EventTypes: inp.EventTypes,
+		Active:     *inp.Active,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
+			return
+		}
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	subscription.Secret = ""
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+func (h *Handler) deleteWebhook(ctx *gin.Context) {
+	subscription, ok := h.webhookOwnedByUser(ctx, writeAccess)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Webhook.DeleteWebhookSubscription(ctx, subscription.ID); err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type listWebhookDeliveriesRequest struct {
+	PageID   int `form:"page_id" binding:"required,min=1"`
+	PageSize int `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listWebhookDeliveries returns the delivery log of the subscription, newest
+// first.
+func (h *Handler) listWebhookDeliveries(ctx *gin.Context) {
+	subscription, ok := h.webhookOwnedByUser(ctx, readAccess)
+	if !ok {
+		return
+	}
+
+	var inp listWebhookDeliveriesRequest
+	if err := ctx.BindQuery(&inp); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return
+	}
+
+	deliveries, err := h.service.Webhook.ListWebhookDeliveries(ctx, domain.ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          inp.PageSize,
+		Offset:         (inp.PageID - 1) * inp.PageSize,
+	})
+	if err != nil {
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// webhookOwnedByUser loads the subscription named in the uri and checks that
+// the caller has the given access to it. Bankers may see any of them, but
+// only the owner may change one. The secret is blanked out.
+func (h *Handler) webhookOwnedByUser(ctx *gin.Context, a access) (domain.WebhookSubscription, bool) {
+	var uri getWebhookRequest
+	if err := ctx.BindUri(&uri); err != nil {
+		newResponse(ctx, http.StatusBadRequest, "Incorrect input:"+err.Error())
+		return domain.WebhookSubscription{}, false
+	}
+	auditTarget(ctx, domain.AuditTargetWebhook, uri.ID)
+
+	subscription, err := h.service.Webhook.GetWebhookSubscription(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			newResponse(ctx, http.StatusNotFound, "Incorrect db:"+err.Error())
+			return subscription, false
+		}
+		newResponse(ctx, http.StatusInternalServerError, "Incorrect db:"+err.Error())
+		return subscription, false
+	}
+	subscription.Secret = ""
+
+	if !callerMay(ctx, subscription.Owner, a) {
+		newResponse(ctx, http.StatusForbidden, "webhook doesn't belong to the authenticated user")
+		return subscription, false
+	}
+
+	return subscription, true
+}
@@ -0,0 +1,358 @@
+package v1
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	mock_repository "github.com/begenov/backend/internal/repository/mocks"
+	"github.com/begenov/backend/internal/service"
+	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock_repository.MockWebhook)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"url":         "https://example.com/hooks",
+				"event_types": []string{domain.EventTransferCompleted},
+			},
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg domain.CreateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Len(t, arg.Secret, 64)
+						return domain.WebhookSubscription{
+							ID:         1,
+							Owner:      arg.Owner,
+							URL:        arg.URL,
+							Secret:     arg.Secret,
+							EventTypes: arg.EventTypes,
+							Active:     true,
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got domain.WebhookSubscription
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+				require.NotEmpty(t, got.Secret)
+				require.Equal(t, []string{domain.EventTransferCompleted}, got.EventTypes)
+			},
+		},
+		{
+			name: "AllEvents",
+			body: gin.H{"url": "https://example.com/hooks"},
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(1).Return(domain.WebhookSubscription{ID: 1}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidURL",
+			body: gin.H{"url": "not a url"},
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PlainHTTP",
+			body: gin.H{"url": "http://example.com/hooks"},
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEventType",
+			body: gin.H{
+				"url":         "https://example.com/hooks",
+				"event_types": []string{"account.deleted"},
+			},
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"url": "https://example.com/hooks"},
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Times(1).Return(domain.WebhookSubscription{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockWebhook(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{Webhook: service.NewWebhookService(store)},
+				token:   token,
+			}
+			handler.Init(router.Group("/api"))
+
+			body, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/webhooks", bytes.NewBuffer(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetWebhookAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	subscription := domain.WebhookSubscription{
+		ID:     int(util.RandomInt(1, 1000)),
+		Owner:  user1.Username,
+		URL:    "https://example.com/hooks",
+		Secret: "secret",
+		Active: true,
+	}
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mock_repository.MockWebhook)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got domain.WebhookSubscription
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+				require.Equal(t, subscription.URL, got.URL)
+				require.Empty(t, got.Secret)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Banker",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(domain.WebhookSubscription{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockWebhook(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{Webhook: service.NewWebhookService(store)},
+				token:   token,
+			}
+			handler.Init(router.Group("/api"))
+
+			url := fmt.Sprintf("/api/v1/webhooks/%d", subscription.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, token, "Bearer", tc.username, tc.role, time.Minute)
+			router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	subscription := domain.WebhookSubscription{ID: 3, Owner: user.Username, URL: "https://example.com/hooks", Active: true}
+
+	deliveries := []domain.WebhookDelivery{
+		{ID: 2, SubscriptionID: 3, EventID: 11, EventType: domain.EventTransferCompleted, Status: domain.WebhookDeliveryDead, Attempts: 8, ResponseStatus: 500},
+		{ID: 1, SubscriptionID: 3, EventID: 10, EventType: domain.EventAccountCreated, Status: domain.WebhookDeliverySucceeded, Attempts: 1, ResponseStatus: 200},
+	}
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock_repository.NewMockWebhook(ctrl)
+	store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+	store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Eq(domain.ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          5,
+		Offset:         5,
+	})).Times(1).Return(deliveries, nil)
+
+	recorder := httptest.NewRecorder()
+	router := gin.Default()
+	handler := &Handler{
+		service: &service.Service{Webhook: service.NewWebhookService(store)},
+		token:   token,
+	}
+	handler.Init(router.Group("/api"))
+
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/webhooks/3/deliveries?page_id=2&page_size=5", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, token, "Bearer", user.Username, util.DepositorRole, time.Minute)
+	router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got []domain.WebhookDelivery
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+	require.Len(t, got, 2)
+	require.Equal(t, domain.WebhookDeliveryDead, got[0].Status)
+}
+
+func TestDeleteWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	subscription := domain.WebhookSubscription{ID: 3, Owner: user.Username, URL: "https://example.com/hooks", Active: true}
+
+	token, err := auth.NewManager("qwe")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		username   string
+		role       string
+		buildStubs func(store *mock_repository.MockWebhook)
+		code       int
+	}{
+		{
+			name:     "Owner",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+				store.EXPECT().DeleteWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(nil)
+			},
+			code: http.StatusNoContent,
+		},
+		{
+			// bankers may look at subscriptions but not change them
+			name:     "Banker",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mock_repository.MockWebhook) {
+				store.EXPECT().GetWebhookSubscription(gomock.Any(), gomock.Eq(subscription.ID)).Times(1).Return(subscription, nil)
+				store.EXPECT().DeleteWebhookSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock_repository.NewMockWebhook(ctrl)
+			tc.buildStubs(store)
+
+			recorder := httptest.NewRecorder()
+			router := gin.Default()
+			handler := &Handler{
+				service: &service.Service{Webhook: service.NewWebhookService(store)},
+				token:   token,
+			}
+			handler.Init(router.Group("/api"))
+
+			request, err := http.NewRequest(http.MethodDelete, "/api/v1/webhooks/3", nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, token, "Bearer", tc.username, tc.role, time.Minute)
+			router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.code, recorder.Code)
+		})
+	}
+}
@@ -2,12 +2,24 @@ package domain
 
 import "time"
 
+const (
+	AccountActive = "active"
+	// a frozen account can neither be debited nor credited until a banker
+	// unfreezes it
+	AccountFrozen = "frozen"
+	// closing is final and needs a zero balance
+	AccountClosed = "closed"
+)
+
 type Account struct {
-	ID        int       `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int       `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	ID       int    `json:"id"`
+	Owner    string `json:"owner"`
+	Balance  int    `json:"balance"`
+	Currency string `json:"currency"`
+	// how far below zero the balance may go
+	OverdraftLimit int       `json:"overdraft_limit"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type CreateAccountParams struct {
@@ -17,8 +29,9 @@ type CreateAccountParams struct {
 }
 
 type ListAccountsParams struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
+	Owner  string `json:"owner"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
 }
 
 type UpdateAccountParams struct {
@@ -30,3 +43,51 @@ type AddAccountBalanceParams struct {
 	Amount int `json:"amount"`
 	ID     int `json:"id"`
 }
+
+type SetOverdraftLimitParams struct {
+	ID             int `json:"id"`
+	OverdraftLimit int `json:"overdraft_limit"`
+}
+
+type ChangeAccountStatusParams struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	// username of whoever asked for the change
+	ChangedBy string `json:"changed_by"`
+}
+
+// AccountStatusChange records one transition of an account's status.
+type AccountStatusChange struct {
+	ID         int       `json:"id"`
+	AccountID  int       `json:"account_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	ChangedBy  string    `json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AccountStatusChangedEvent is the payload of an account.status_changed
+// event. The reason and who made the change stay internal, they may name a
+// fraud case or a banker.
+type AccountStatusChangedEvent struct {
+	AccountID  int       `json:"account_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+type CreateAccountStatusChangeParams struct {
+	AccountID  int    `json:"account_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	ChangedBy  string `json:"changed_by"`
+}
+
+type ListAccountStatusChangesParams struct {
+	AccountID int `json:"account_id"`
+	Limit     int `json:"limit"`
+	Offset    int `json:"offset"`
+}
@@ -0,0 +1,109 @@
+package domain
+
+import "time"
+
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditUserCreate = "user.create"
+	AuditUserLogin  = "user.login"
+	AuditUserLogout = "user.logout"
+	AuditTokenRenew = "token.renew"
+
+	AuditAccountCreate         = "account.create"
+	AuditAccountDeposit        = "account.deposit"
+	AuditAccountWithdraw       = "account.withdraw"
+	AuditAccountClose          = "account.close"
+	AuditAccountFreeze         = "account.freeze"
+	AuditAccountUnfreeze       = "account.unfreeze"
+	AuditAccountOverdraftLimit = "account.set_overdraft_limit"
+
+	AuditTransferCreate  = "transfer.create"
+	AuditTransferBatch   = "transfer.batch"
+	AuditTransferReverse = "transfer.reverse"
+
+	AuditScheduledTransferCreate = "scheduled_transfer.create"
+	AuditScheduledTransferUpdate = "scheduled_transfer.update"
+	AuditScheduledTransferDelete = "scheduled_transfer.delete"
+
+	AuditWebhookCreate = "webhook.create"
+	AuditWebhookUpdate = "webhook.update"
+	AuditWebhookDelete = "webhook.delete"
+)
+
+// Kinds of objects an audit event can point at.
+const (
+	AuditTargetAccount           = "account"
+	AuditTargetTransfer          = "transfer"
+	AuditTargetScheduledTransfer = "scheduled_transfer"
+	AuditTargetWebhook           = "webhook"
+	AuditTargetSession           = "session"
+)
+
+// AuditTargets holds the ids of the objects a call touched by their kind.
+type AuditTargets map[string][]int
+
+// Add appends ids under kind, skipping the ones already there.
+func (t AuditTargets) Add(kind string, ids ...int) {
+	for _, id := range ids {
+		if !containsID(t[kind], id) {
+			t[kind] = append(t[kind], id)
+		}
+	}
+}
+
+func containsID(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditEvent records one state-changing call, whether it succeeded or not.
+// Audit events are never updated or deleted.
+type AuditEvent struct {
+	ID int64 `json:"id"`
+	// username of the caller, empty when it isn't known, e.g. a failed login
+	// of an unknown user
+	Actor     string       `json:"actor"`
+	Action    string       `json:"action"`
+	Targets   AuditTargets `json:"targets"`
+	RequestID string       `json:"request_id"`
+	ClientIP  string       `json:"client_ip"`
+	UserAgent string       `json:"user_agent"`
+	Outcome   string       `json:"outcome"`
+	Error     string       `json:"error,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type CreateAuditEventParams struct {
+	Actor     string       `json:"actor"`
+	Action    string       `json:"action"`
+	Targets   AuditTargets `json:"targets"`
+	RequestID string       `json:"request_id"`
+	ClientIP  string       `json:"client_ip"`
+	UserAgent string       `json:"user_agent"`
+	Outcome   string       `json:"outcome"`
+	Error     string       `json:"error"`
+}
+
+// ListAuditEventsParams filters the audit log. Zero values don't filter.
+type ListAuditEventsParams struct {
+	Actor   string `json:"actor"`
+	Action  string `json:"action"`
+	Outcome string `json:"outcome"`
+	// events that touched the object TargetID of kind TargetType
+	TargetType string `json:"target_type"`
+	TargetID   int    `json:"target_id"`
+	// the period is [From, To)
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+}
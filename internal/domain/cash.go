@@ -0,0 +1,29 @@
+package domain
+
+// SettlementOwner owns the settlement accounts, one per currency, which
+// mirror the money held at the external processor. No customer can sign up
+// under this name.
+const SettlementOwner = "bank_settlement"
+
+// CashParams describes money coming into or leaving the bank through an
+// external processor.
+type CashParams struct {
+	AccountID int `json:"account_id"`
+	Amount    int `json:"amount"`
+	// reference at the external processor, e.g. the card transaction ID
+	ExternalRef string `json:"external_ref"`
+}
+
+type CashTxParams struct {
+	AccountID int `json:"account_id"`
+	// settlement account for the currency of the account
+	SettlementAccountID int    `json:"settlement_account_id"`
+	Amount              int    `json:"amount"`
+	ExternalRef         string `json:"external_ref"`
+}
+
+type CashTxResult struct {
+	Transfer Transfer `json:"transfer"`
+	Account  Account  `json:"account"`
+	Entry    Entry    `json:"entry"`
+}
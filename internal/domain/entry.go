@@ -13,6 +13,8 @@ type Entry struct {
 type CreateEntryParams struct {
 	AccountID int `json:"account_id"`
 	Amount    int `json:"amount"`
+	// zero when the entry isn't part of a transfer
+	TransferID int `json:"transfer_id"`
 }
 
 type ListEntriesParams struct {
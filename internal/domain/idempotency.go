@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// IdempotencyKeyTTL is how long a key replays the result of its request.
+// After that the key is swept and can be used for a new request.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// SchedulerIdempotencyOwner scopes the keys of scheduled runs. Usernames are
+// alphanumeric, so a client can't pick a key that collides with them.
+const SchedulerIdempotencyOwner = "system:scheduler"
+
+type IdempotencyKey struct {
+	Owner       string    `json:"owner"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	TransferID  int       `json:"transfer_id"`
+	Response    []byte    `json:"response"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type CreateIdempotencyKeyParams struct {
+	Owner       string        `json:"owner"`
+	Key         string        `json:"key"`
+	RequestHash string        `json:"request_hash"`
+	TTL         time.Duration `json:"ttl"`
+}
+
+type UpdateIdempotencyKeyParams struct {
+	Owner      string `json:"owner"`
+	Key        string `json:"key"`
+	TransferID int    `json:"transfer_id"`
+	Response   []byte `json:"response"`
+}
@@ -0,0 +1,55 @@
+package domain
+
+const (
+	// the balance of the account differs from the sum of its entries
+	LedgerAccountBalanceMismatch = "account_balance_mismatch"
+	// the transfer doesn't have exactly one debit of amount on the from
+	// account and one credit of to_amount on the to account
+	LedgerTransferEntriesMismatch = "transfer_entries_mismatch"
+)
+
+type LedgerCheckParams struct {
+	// number of accounts or transfers read per query
+	BatchSize int `json:"batch_size"`
+}
+
+// LedgerBatchParams selects the rows with an id greater than AfterID.
+type LedgerBatchParams struct {
+	AfterID int `json:"after_id"`
+	Limit   int `json:"limit"`
+}
+
+type LedgerBatch struct {
+	// number of rows read and the id of the last one, zero when the table
+	// has been read to the end
+	Checked int `json:"checked"`
+	LastID  int `json:"last_id"`
+	// rows read but not checked, i.e. transfers booked before entries were
+	// linked to their transfer
+	Skipped       int                 `json:"skipped"`
+	Discrepancies []LedgerDiscrepancy `json:"discrepancies"`
+}
+
+// OK reports whether the batch satisfies all invariants.
+func (b LedgerBatch) OK() bool {
+	return len(b.Discrepancies) == 0
+}
+
+type LedgerDiscrepancy struct {
+	Kind       string `json:"kind"`
+	AccountID  int    `json:"account_id,omitempty"`
+	TransferID int    `json:"transfer_id,omitempty"`
+	Detail     string `json:"detail"`
+}
+
+type LedgerReport struct {
+	AccountsChecked  int                 `json:"accounts_checked"`
+	TransfersChecked int                 `json:"transfers_checked"`
+	TransfersSkipped int                 `json:"transfers_skipped"`
+	Discrepancies    []LedgerDiscrepancy `json:"discrepancies"`
+}
+
+// OK reports whether the ledger satisfies all invariants.
+func (r LedgerReport) OK() bool {
+	return len(r.Discrepancies) == 0
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventAccountCreated       = "account.created"
+	EventTransferCompleted    = "transfer.completed"
+	EventAccountStatusChanged = "account.status_changed"
+)
+
+// OutboxEvent is written in the same transaction as the change it describes
+// and published to downstream services afterwards.
+type OutboxEvent struct {
+	ID int64 `json:"id"`
+	// the account the event belongs to, its events are delivered in order
+	AccountID   int             `json:"account_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+}
+
+type CreateOutboxEventParams struct {
+	AccountID int             `json:"account_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+}
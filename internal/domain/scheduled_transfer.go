@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+const (
+	ScheduledRunSucceeded = "succeeded"
+	ScheduledRunFailed    = "failed"
+)
+
+// ScheduledTransfer is a standing order that moves Amount every time its
+// schedule fires.
+type ScheduledTransfer struct {
+	ID            int    `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int    `json:"from_account_id"`
+	ToAccountID   int    `json:"to_account_id"`
+	Amount        int    `json:"amount"`
+	// a cron expression like "0 9 1 * *" or an interval like "@every 168h"
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateScheduledTransferParams struct {
+	Owner         string    `json:"owner"`
+	FromAccountID int       `json:"from_account_id"`
+	ToAccountID   int       `json:"to_account_id"`
+	Amount        int       `json:"amount"`
+	Schedule      string    `json:"schedule"`
+	NextRunAt     time.Time `json:"next_run_at"`
+}
+
+type UpdateScheduledTransferParams struct {
+	ID        int       `json:"id"`
+	Amount    int       `json:"amount"`
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+	Active    bool      `json:"active"`
+}
+
+type ListScheduledTransfersParams struct {
+	Owner  string `json:"owner"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// ScheduledTransferRun records one attempt to execute a scheduled transfer.
+type ScheduledTransferRun struct {
+	ID                  int       `json:"id"`
+	ScheduledTransferID int       `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	Status              string    `json:"status"`
+	// zero when the attempt failed
+	TransferID int       `json:"transfer_id,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateScheduledTransferRunParams struct {
+	ScheduledTransferID int       `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	Status              string    `json:"status"`
+	TransferID          int       `json:"transfer_id"`
+	Error               string    `json:"error"`
+}
+
+type ListScheduledTransferRunsParams struct {
+	ScheduledTransferID int `json:"scheduled_transfer_id"`
+	Limit               int `json:"limit"`
+	Offset              int `json:"offset"`
+}
+
+// ScheduledRunOutcome is what executing a due scheduled transfer produced:
+// the run to record and when the transfer is due next.
+type ScheduledRunOutcome struct {
+	Run       CreateScheduledTransferRunParams
+	NextRunAt time.Time
+}
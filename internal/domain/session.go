@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+type Session struct {
+	ID               int       `json:"id"`
+	Username         string    `json:"username"`
+	RefreshTokenHash string    `json:"-"`
+	UserAgent        string    `json:"user_agent"`
+	ClientIP         string    `json:"client_ip"`
+	IsBlocked        bool      `json:"is_blocked"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type CreateSessionParams struct {
+	Username         string    `json:"username"`
+	RefreshTokenHash string    `json:"-"`
+	UserAgent        string    `json:"user_agent"`
+	ClientIP         string    `json:"client_ip"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type RotateSessionParams struct {
+	ID                  int       `json:"id"`
+	OldRefreshTokenHash string    `json:"-"`
+	RefreshTokenHash    string    `json:"-"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+type StatementParams struct {
+	AccountID int       `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// Statement lists the entries of an account created in [From, To).
+type Statement struct {
+	AccountID      int              `json:"account_id"`
+	Currency       string           `json:"currency"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance int              `json:"opening_balance"`
+	ClosingBalance int              `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"`
+}
+
+type StatementEntry struct {
+	ID     int `json:"id"`
+	Amount int `json:"amount"`
+	// balance of the account right after this entry
+	Balance int `json:"balance"`
+	// zero for entries that aren't part of a transfer
+	TransferID            int       `json:"transfer_id,omitempty"`
+	CounterpartyAccountID int       `json:"counterparty_account_id,omitempty"`
+	CounterpartyOwner     string    `json:"counterparty_owner,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+}
@@ -1,9 +1,26 @@
 package domain
 
+import "database/sql"
+
 type TransferTxParams struct {
 	FromAccountID int `json:"from_account_id"`
 	ToAccountID   int `json:"to_account_id"`
 	Amount        int `json:"amount"`
+	// amount credited to the to account and the rate it was converted at,
+	// zero for transfers between accounts in the same currency
+	ToAmount     int     `json:"to_amount"`
+	ExchangeRate float64 `json:"exchange_rate"`
+	// optional, a retry with the same key replays the original result
+	IdempotencyKey string `json:"idempotency_key"`
+	// who the idempotency key belongs to, keys of different owners never
+	// collide
+	Owner string `json:"owner"`
+	// currency of the from account as the client sent it, part of the
+	// request an idempotency key is checked against
+	Currency string `json:"currency"`
+	// isolation level of the transaction, read committed when left at
+	// sql.LevelDefault
+	Isolation sql.IsolationLevel `json:"-"`
 }
 
 type TransferTxResult struct {
@@ -12,4 +29,39 @@ type TransferTxResult struct {
 	ToAccount   Account
 	FromEntry   Entry
 	ToEntry     Entry
+	// set when the result was replayed for a known idempotency key
+	Replayed bool `json:"-"`
+	// how many times the transaction was retried after a serialization
+	// failure or a deadlock, only logged and counted
+	Retries int `json:"-"`
+}
+
+const (
+	// BatchAtomic books every transfer of a batch in one transaction or none
+	// of them.
+	BatchAtomic = "atomic"
+	// BatchBestEffort books each transfer on its own and reports the outcome
+	// per item.
+	BatchBestEffort = "best_effort"
+
+	MaxBatchTransfers = 500
+)
+
+type BatchTransferParams struct {
+	Mode      string             `json:"mode"`
+	Transfers []TransferTxParams `json:"transfers"`
+}
+
+type BatchTransferItem struct {
+	// position of the transfer in the request
+	Index  int               `json:"index"`
+	Result *TransferTxResult `json:"result,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+type BatchTransferResult struct {
+	Mode      string              `json:"mode"`
+	Items     []BatchTransferItem `json:"items"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
 }
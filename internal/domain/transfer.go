@@ -2,19 +2,40 @@ package domain
 
 import "time"
 
+const (
+	TransferKindTransfer   = "transfer"
+	TransferKindDeposit    = "deposit"
+	TransferKindWithdrawal = "withdrawal"
+	TransferKindReversal   = "reversal"
+)
+
 type Transfer struct {
 	ID            int `json:"id"`
 	FromAccountID int `json:"from_account_id"`
 	ToAccountID   int `json:"to_account_id"`
-	// must be positive
-	Amount    int       `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	// must be positive, in the currency of the from account
+	Amount int `json:"amount"`
+	// amount credited, in the currency of the to account
+	ToAmount     int     `json:"to_amount"`
+	ExchangeRate float64 `json:"exchange_rate"`
+	Kind         string  `json:"kind"`
+	// set for deposits and withdrawals, unique across all transfers
+	ExternalRef string `json:"external_ref,omitempty"`
+	// set for reversals, the transfer this one compensates
+	ReversalOf int       `json:"reversal_of,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type CreateTransferParams struct {
-	FromAccountID int `json:"from_account_id"`
-	ToAccountID   int `json:"to_account_id"`
-	Amount        int `json:"amount"`
+	FromAccountID int     `json:"from_account_id"`
+	ToAccountID   int     `json:"to_account_id"`
+	Amount        int     `json:"amount"`
+	ToAmount      int     `json:"to_amount"`
+	ExchangeRate  float64 `json:"exchange_rate"`
+	// defaults to TransferKindTransfer
+	Kind        string `json:"kind"`
+	ExternalRef string `json:"external_ref"`
+	ReversalOf  int    `json:"reversal_of"`
 }
 
 type ListTransfersParams struct {
@@ -23,3 +44,17 @@ type ListTransfersParams struct {
 	Limit         int `json:"limit"`
 	Offset        int `json:"offset"`
 }
+
+type ReverseTransferParams struct {
+	TransferID int `json:"transfer_id"`
+	// in the currency of the original from account, zero reverses whatever
+	// hasn't been reversed yet
+	Amount int `json:"amount"`
+}
+
+// ReversedTotals sums the reversals of a transfer, Amount in the currency of
+// the original to account and ToAmount in that of the original from account.
+type ReversedTotals struct {
+	Amount   int `json:"amount"`
+	ToAmount int `json:"to_amount"`
+}
@@ -7,6 +7,7 @@ type User struct {
 	HashedPassword    string    `json:"hashed_password"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -17,3 +18,10 @@ type CreateUserParams struct {
 	FullName       string `json:"full_name"`
 	Email          string `json:"email"`
 }
+
+type LoginUserParams struct {
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	UserAgent string `json:"user_agent"`
+	ClientIP  string `json:"client_ip"`
+}
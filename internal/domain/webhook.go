@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	// every attempt failed, the delivery is given up
+	WebhookDeliveryDead = "dead"
+)
+
+// WebhookSubscription asks for the events of the owner's accounts to be
+// posted to URL.
+type WebhookSubscription struct {
+	ID    int    `json:"id"`
+	Owner string `json:"owner"`
+	URL   string `json:"url"`
+	// signs the deliveries, only shown when the subscription is created
+	Secret string `json:"secret,omitempty"`
+	// empty means every event
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateWebhookSubscriptionParams struct {
+	Owner      string   `json:"owner"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type UpdateWebhookSubscriptionParams struct {
+	ID         int      `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+}
+
+type ListWebhookSubscriptionsParams struct {
+	Owner  string `json:"owner"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// WebhookDelivery is one event on its way to one subscription.
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	SubscriptionID int             `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	AccountID      int             `json:"account_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	// status code of the last response, zero when there was none
+	ResponseStatus int       `json:"response_status"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DueWebhookDelivery is a delivery together with where it goes.
+type DueWebhookDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID int `json:"subscription_id"`
+	Limit          int `json:"limit"`
+	Offset         int `json:"offset"`
+}
+
+// WebhookAttempt is the outcome of posting a delivery once.
+type WebhookAttempt struct {
+	Status         string
+	ResponseStatus int
+	Error          string
+	// when to try again, only used while the delivery is pending
+	NextAttemptAt time.Time
+}
@@ -0,0 +1,170 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camtGrpHdr struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtPeriod struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Ccy      string `xml:"Ccy"`
+	Servicer string `xml:"Svcr>FinInstnId>Othr>Id"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	DtTm      string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	NtryRef   string         `xml:"NtryRef"`
+	Amt       camtAmount     `xml:"Amt"`
+	CdtDbtInd string         `xml:"CdtDbtInd"`
+	Sts       string         `xml:"Sts"`
+	BookgDt   string         `xml:"BookgDt>DtTm"`
+	ValDt     string         `xml:"ValDt>DtTm"`
+	BkTxCd    camtBkTxCd     `xml:"BkTxCd>Prtry"`
+	TxDtls    *camtTxDetails `xml:"NtryDtls>TxDtls,omitempty"`
+}
+
+type camtBkTxCd struct {
+	Cd   string `xml:"Cd"`
+	Issr string `xml:"Issr"`
+}
+
+type camtTxDetails struct {
+	EndToEndID string         `xml:"Refs>EndToEndId"`
+	Cdtr       *camtParty     `xml:"RltdPties>Cdtr,omitempty"`
+	CdtrAcct   *camtPartyAcct `xml:"RltdPties>CdtrAcct,omitempty"`
+	Dbtr       *camtParty     `xml:"RltdPties>Dbtr,omitempty"`
+	DbtrAcct   *camtPartyAcct `xml:"RltdPties>DbtrAcct,omitempty"`
+}
+
+type camtParty struct {
+	Nm string `xml:"Nm"`
+}
+
+type camtPartyAcct struct {
+	ID string `xml:"Id>Othr>Id"`
+}
+
+// camt053Encoder writes the Ntry elements of the statement as they come,
+// after the balances that are known from Begin.
+type camt053Encoder struct {
+	x         *xmlWriter
+	createdAt time.Time
+	currency  string
+}
+
+func newCAMT053Encoder(w io.Writer, createdAt time.Time) *camt053Encoder {
+	return &camt053Encoder{x: newXMLWriter(w), createdAt: createdAt}
+}
+
+func (e *camt053Encoder) Begin(statement domain.Statement) error {
+	e.currency = statement.Currency
+	id := fmt.Sprintf("%d-%s", statement.AccountID, statement.From.UTC().Format("20060102"))
+	x := e.x
+
+	x.raw(xml.Header)
+	x.open("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace})
+	x.open("BkToCstmrStmt")
+	x.element("GrpHdr", camtGrpHdr{
+		MsgID:   id,
+		CreDtTm: camtTime(e.createdAt),
+	})
+	x.open("Stmt")
+	x.element("Id", id)
+	x.element("CreDtTm", camtTime(e.createdAt))
+	x.element("FrToDt", camtPeriod{
+		FrDtTm: camtTime(statement.From),
+		ToDtTm: camtTime(statement.To),
+	})
+	x.element("Acct", camtAccount{
+		ID:       strconv.Itoa(statement.AccountID),
+		Ccy:      statement.Currency,
+		Servicer: bankID,
+	})
+	x.element("Bal", camtBalanceOf("OPBD", statement.OpeningBalance, statement.Currency, statement.From))
+	x.element("Bal", camtBalanceOf("CLBD", statement.ClosingBalance, statement.Currency, statement.To))
+	return x.err
+}
+
+func (e *camt053Encoder) Entry(entry domain.StatementEntry) error {
+	ntry := camtEntry{
+		NtryRef:   strconv.Itoa(entry.ID),
+		Amt:       camtAmount{Ccy: e.currency, Value: formatAmount(abs(entry.Amount), e.currency)},
+		CdtDbtInd: creditDebit(entry.Amount),
+		Sts:       "BOOK",
+		BookgDt:   camtTime(entry.CreatedAt),
+		ValDt:     camtTime(entry.CreatedAt),
+		BkTxCd:    camtBkTxCd{Cd: "ENTRY", Issr: bankID},
+	}
+
+	if entry.TransferID != 0 {
+		ntry.BkTxCd.Cd = "TRANSFER"
+
+		party := &camtParty{Nm: entry.CounterpartyOwner}
+		account := &camtPartyAcct{ID: strconv.Itoa(entry.CounterpartyAccountID)}
+		details := &camtTxDetails{EndToEndID: strconv.Itoa(entry.TransferID)}
+		// the counterparty receives the money of a debit and sends the
+		// money of a credit
+		if entry.Amount < 0 {
+			details.Cdtr, details.CdtrAcct = party, account
+		} else {
+			details.Dbtr, details.DbtrAcct = party, account
+		}
+		ntry.TxDtls = details
+	}
+
+	e.x.element("Ntry", ntry)
+	return e.x.err
+}
+
+func (e *camt053Encoder) End() error {
+	e.x.close("Stmt", "BkToCstmrStmt", "Document")
+	return e.x.flush()
+}
+
+func camtBalanceOf(code string, balance int, currency string, at time.Time) camtBalance {
+	return camtBalance{
+		Type:      code,
+		Amt:       camtAmount{Ccy: currency, Value: formatAmount(abs(balance), currency)},
+		CdtDbtInd: creditDebit(balance),
+		DtTm:      camtTime(at),
+	}
+}
+
+func creditDebit(amount int) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
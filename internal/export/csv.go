@@ -0,0 +1,63 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+var csvHeader = []string{
+	"entry_id",
+	"created_at",
+	"amount",
+	"balance",
+	"currency",
+	"transfer_id",
+	"counterparty_account_id",
+	"counterparty_owner",
+}
+
+// csvEncoder writes one row per entry, amounts as decimals of the currency.
+// Columns that don't apply to an entry, like the transfer of a deposit, are
+// left empty.
+type csvEncoder struct {
+	w        *csv.Writer
+	currency string
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Begin(statement domain.Statement) error {
+	e.currency = statement.Currency
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvEncoder) Entry(entry domain.StatementEntry) error {
+	return e.w.Write([]string{
+		strconv.Itoa(entry.ID),
+		entry.CreatedAt.UTC().Format(time.RFC3339),
+		formatAmount(entry.Amount, e.currency),
+		formatAmount(entry.Balance, e.currency),
+		e.currency,
+		optionalID(entry.TransferID),
+		optionalID(entry.CounterpartyAccountID),
+		entry.CounterpartyOwner,
+	})
+}
+
+func (e *csvEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func optionalID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
@@ -0,0 +1,117 @@
+// Package export encodes account statements in the formats accepted by
+// common ledger software.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/util"
+)
+
+type Format string
+
+const (
+	CSV     Format = "csv"
+	OFX     Format = "ofx"
+	CAMT053 Format = "camt.053"
+)
+
+// bankID identifies the bank in OFX and camt.053 documents.
+const bankID = "SIMPLEBANK"
+
+// Encoder writes a statement one entry at a time, so a long statement never
+// has to be held in memory.
+type Encoder interface {
+	// Begin writes what comes before the entries. The entries of statement
+	// are ignored.
+	Begin(statement domain.Statement) error
+	Entry(entry domain.StatementEntry) error
+	// End finishes the document and flushes what is still buffered.
+	End() error
+}
+
+// NewEncoder returns an encoder writing to w. createdAt is stamped on the
+// document as its generation time. Nothing is written before Begin.
+func NewEncoder(w io.Writer, format Format, createdAt time.Time) (Encoder, error) {
+	switch format {
+	case CSV:
+		return newCSVEncoder(w), nil
+	case OFX:
+		return newOFXEncoder(w, createdAt), nil
+	case CAMT053:
+		return newCAMT053Encoder(w, createdAt), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// Encode writes a statement that is already loaded to w.
+func Encode(w io.Writer, format Format, statement domain.Statement, createdAt time.Time) error {
+	enc, err := NewEncoder(w, format, createdAt)
+	if err != nil {
+		return err
+	}
+
+	if err := enc.Begin(statement); err != nil {
+		return err
+	}
+	for _, entry := range statement.Entries {
+		if err := enc.Entry(entry); err != nil {
+			return err
+		}
+	}
+	return enc.End()
+}
+
+func ContentType(format Format) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case OFX:
+		return "application/x-ofx"
+	case CAMT053:
+		return "application/xml; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// FileExtension is the extension used when the statement is downloaded.
+func FileExtension(format Format) string {
+	if format == CAMT053 {
+		return "xml"
+	}
+	return string(format)
+}
+
+func abs(amount int) int {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// formatAmount writes an amount kept in minor units as a decimal of the
+// currency, -1050 cents as "-10.50".
+func formatAmount(amount int, currency string) string {
+	scale := util.MinorUnits(currency)
+
+	digits := strconv.Itoa(abs(amount))
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	if scale == 0 {
+		return sign + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
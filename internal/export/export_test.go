@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		format Format
+		golden string
+	}{
+		{format: CSV, golden: "statement.csv"},
+		{format: OFX, golden: "statement.ofx"},
+		{format: CAMT053, golden: "statement.xml"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(string(tc.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Encode(&buf, tc.format, testStatement(), time.Date(2023, time.June, 1, 8, 30, 0, 0, time.UTC))
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0644))
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+		})
+	}
+}
+
+func TestEncodeUnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := Encode(&buf, Format("pdf"), testStatement(), time.Now())
+	require.Error(t, err)
+	require.Zero(t, buf.Len())
+}
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		amount int
+		want   string
+	}{
+		{amount: 0, want: "0.00"},
+		{amount: 5, want: "0.05"},
+		{amount: 150, want: "1.50"},
+		{amount: -60, want: "-0.60"},
+		{amount: -123456, want: "-1234.56"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, formatAmount(tc.amount, "USD"))
+	}
+}
+
+func testStatement() domain.Statement {
+	from := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	return domain.Statement{
+		AccountID:      42,
+		Currency:       "USD",
+		From:           from,
+		To:             from.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		ClosingBalance: 215,
+		Entries: []domain.StatementEntry{
+			{
+				ID:                    7,
+				Amount:                150,
+				Balance:               250,
+				TransferID:            3,
+				CounterpartyAccountID: 12,
+				CounterpartyOwner:     "alice",
+				CreatedAt:             from.Add(26 * time.Hour),
+			},
+			{
+				ID:                    9,
+				Amount:                -60,
+				Balance:               190,
+				TransferID:            4,
+				CounterpartyAccountID: 15,
+				CounterpartyOwner:     "bob",
+				CreatedAt:             from.Add(50*time.Hour + 15*time.Minute),
+			},
+			{
+				ID:        11,
+				Amount:    25,
+				Balance:   215,
+				CreatedAt: from.Add(100 * time.Hour),
+			},
+		},
+	}
+}
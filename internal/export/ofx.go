@@ -0,0 +1,122 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxBankAcct struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxStmtTrn struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// ofxEncoder writes the transactions of BANKTRANLIST as they come. The
+// ledger balance that follows them is known from Begin.
+type ofxEncoder struct {
+	x         *xmlWriter
+	createdAt time.Time
+	statement domain.Statement
+}
+
+func newOFXEncoder(w io.Writer, createdAt time.Time) *ofxEncoder {
+	return &ofxEncoder{x: newXMLWriter(w), createdAt: createdAt}
+}
+
+func (e *ofxEncoder) Begin(statement domain.Statement) error {
+	e.statement = statement
+	x := e.x
+
+	x.raw(ofxHeader)
+	x.open("OFX")
+	x.open("SIGNONMSGSRSV1")
+	x.element("SONRS", ofxSignOn{
+		Status:   ofxStatus{Code: 0, Severity: "INFO"},
+		DTServer: ofxTime(e.createdAt),
+		Language: "ENG",
+	})
+	x.close("SIGNONMSGSRSV1")
+
+	x.open("BANKMSGSRSV1")
+	x.open("STMTTRNRS")
+	x.element("TRNUID", "0")
+	x.element("STATUS", ofxStatus{Code: 0, Severity: "INFO"})
+	x.open("STMTRS")
+	x.element("CURDEF", statement.Currency)
+	x.element("BANKACCTFROM", ofxBankAcct{
+		BankID:   bankID,
+		AcctID:   strconv.Itoa(statement.AccountID),
+		AcctType: "CHECKING",
+	})
+	x.open("BANKTRANLIST")
+	x.element("DTSTART", ofxTime(statement.From))
+	x.element("DTEND", ofxTime(statement.To))
+	return x.err
+}
+
+func (e *ofxEncoder) Entry(entry domain.StatementEntry) error {
+	trn := ofxStmtTrn{
+		TrnType:  "CREDIT",
+		DTPosted: ofxTime(entry.CreatedAt),
+		TrnAmt:   formatAmount(entry.Amount, e.statement.Currency),
+		FITID:    strconv.Itoa(entry.ID),
+		Name:     entry.CounterpartyOwner,
+	}
+	if entry.Amount < 0 {
+		trn.TrnType = "DEBIT"
+	}
+	if entry.TransferID != 0 {
+		trn.Memo = fmt.Sprintf("Transfer %d", entry.TransferID)
+	}
+	e.x.element("STMTTRN", trn)
+	return e.x.err
+}
+
+func (e *ofxEncoder) End() error {
+	x := e.x
+	x.close("BANKTRANLIST")
+	x.element("LEDGERBAL", ofxBalance{
+		BalAmt: formatAmount(e.statement.ClosingBalance, e.statement.Currency),
+		DTAsOf: ofxTime(e.statement.To),
+	})
+	x.close("STMTRS", "STMTTRNRS", "BANKMSGSRSV1", "OFX")
+	return x.flush()
+}
+
+// ofxTime formats t as an OFX datetime in UTC.
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
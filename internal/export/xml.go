@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// xmlWriter writes the XML formats token by token around the entries, so
+// each entry is encoded on its own. The first error sticks and turns the
+// later calls into no-ops.
+type xmlWriter struct {
+	w   io.Writer
+	enc *xml.Encoder
+	err error
+}
+
+func newXMLWriter(w io.Writer) *xmlWriter {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &xmlWriter{w: w, enc: enc}
+}
+
+// raw writes s as is, it must only be called with nothing buffered.
+func (x *xmlWriter) raw(s string) {
+	if x.err != nil {
+		return
+	}
+	_, x.err = io.WriteString(x.w, s)
+}
+
+func (x *xmlWriter) open(name string, attr ...xml.Attr) {
+	if x.err != nil {
+		return
+	}
+	x.err = x.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attr})
+}
+
+func (x *xmlWriter) close(names ...string) {
+	for _, name := range names {
+		if x.err != nil {
+			return
+		}
+		x.err = x.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (x *xmlWriter) element(name string, v interface{}) {
+	if x.err != nil {
+		return
+	}
+	x.err = x.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// flush writes out what the encoder still buffers, ending with a newline.
+func (x *xmlWriter) flush() error {
+	if x.err != nil {
+		return x.err
+	}
+	if x.err = x.enc.Flush(); x.err != nil {
+		return x.err
+	}
+	x.raw("\n")
+	return x.err
+}
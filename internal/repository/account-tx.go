@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+)
+
+// accountTransitions lists the statuses each status may move to. Closing is
+// final.
+var accountTransitions = map[string][]string{
+	domain.AccountActive: {domain.AccountFrozen, domain.AccountClosed},
+	domain.AccountFrozen: {domain.AccountActive},
+}
+
+// ChangeAccountStatusTx moves the account to arg.Status, records who did it
+// and why, and writes an account.status_changed event. An account is only
+// closed with a zero balance; the row lock keeps a transfer from landing in
+// between the check and the change.
+func (r *Repository) ChangeAccountStatusTx(ctx context.Context, arg domain.ChangeAccountStatusParams) (domain.Account, error) {
+	var account domain.Account
+
+	_, err := r.execTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(q *Repository) error {
+		var err error
+
+		account, err = q.Account.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if !canTransition(account.Status, arg.Status) {
+			return fmt.Errorf("%w: %s to %s", e.ErrInvalidStatusTransition, account.Status, arg.Status)
+		}
+		if arg.Status == domain.AccountClosed && account.Balance != 0 {
+			return e.ErrNonZeroBalance
+		}
+
+		change, err := q.Account.CreateAccountStatusChange(ctx, domain.CreateAccountStatusChangeParams{
+			AccountID:  account.ID,
+			FromStatus: account.Status,
+			ToStatus:   arg.Status,
+			Reason:     arg.Reason,
+			ChangedBy:  arg.ChangedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		account, err = q.Account.SetAccountStatus(ctx, account.ID, arg.Status)
+		if err != nil {
+			return err
+		}
+
+		return writeOutboxEvent(ctx, q, account.ID, domain.EventAccountStatusChanged, domain.AccountStatusChangedEvent{
+			AccountID:  change.AccountID,
+			FromStatus: change.FromStatus,
+			ToStatus:   change.ToStatus,
+			ChangedAt:  change.CreatedAt,
+		})
+	})
+
+	return account, err
+}
+
+func canTransition(from, to string) bool {
+	for _, status := range accountTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -5,13 +5,15 @@ import (
 	"database/sql"
 
 	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/lib/pq"
 )
 
 type AccountRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
-func New(db *sql.DB) *AccountRepo {
+func New(db DBTX) *AccountRepo {
 	return &AccountRepo{
 		db: db,
 	}
@@ -24,7 +26,7 @@ func (r *AccountRepo) CreateAccount(ctx context.Context, arg domain.CreateAccoun
 		currency
 	) VALUES (
 		$1, $2, $3
-	) RETURNING id, owner, balance, currency, created_at
+	) RETURNING id, owner, balance, currency, overdraft_limit, status, created_at
 	`
 
 	row := r.db.QueryRowContext(ctx, stmt, arg.Owner, arg.Balance, arg.Currency)
@@ -34,20 +36,16 @@ func (r *AccountRepo) CreateAccount(ctx context.Context, arg domain.CreateAccoun
 		&i.Owner,
 		&i.Balance,
 		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
 		&i.CreatedAt,
 	)
 
 	return i, err
 }
 
-func (r *AccountRepo) DeleteAccount(ctx context.Context, id int) error {
-	stmt := `DELETE FROM accounts WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, stmt, id)
-	return err
-}
-
 func (r *AccountRepo) GetAccount(ctx context.Context, id int) (domain.Account, error) {
-	stmt := `SELECT id, owner, balance, currency, created_at FROM accounts
+	stmt := `SELECT id, owner, balance, currency, overdraft_limit, status, created_at FROM accounts
 	WHERE id = $1 LIMIT 1`
 	row := r.db.QueryRowContext(ctx, stmt, id)
 	var i domain.Account
@@ -56,17 +54,39 @@ func (r *AccountRepo) GetAccount(ctx context.Context, id int) (domain.Account, e
 		&i.Owner,
 		&i.Balance,
 		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+// GetAccountByOwner reads the open account the owner holds in the currency.
+// Closed ones are kept for their history and may share owner and currency.
+func (r *AccountRepo) GetAccountByOwner(ctx context.Context, owner string, currency string) (domain.Account, error) {
+	stmt := `SELECT id, owner, balance, currency, overdraft_limit, status, created_at FROM accounts
+	WHERE owner = $1 AND currency = $2 AND status <> 'closed' LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, owner, currency)
+	var i domain.Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 func (r *AccountRepo) ListAccounts(ctx context.Context, arg domain.ListAccountsParams) ([]domain.Account, error) {
-	stmt := `SELECT id, owner, balance, currency, created_at FROM accounts
+	stmt := `SELECT id, owner, balance, currency, overdraft_limit, status, created_at FROM accounts
+	WHERE owner = $1
 	ORDER BY id
-	LIMIT $1
-	OFFSET $2`
-	row, err := r.db.QueryContext(ctx, stmt, arg.Limit, arg.Offset)
+	LIMIT $2
+	OFFSET $3`
+	row, err := r.db.QueryContext(ctx, stmt, arg.Owner, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -78,6 +98,8 @@ func (r *AccountRepo) ListAccounts(ctx context.Context, arg domain.ListAccountsP
 			&i.Owner,
 			&i.Balance,
 			&i.Currency,
+			&i.OverdraftLimit,
+			&i.Status,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -95,9 +117,17 @@ func (r *AccountRepo) ListAccounts(ctx context.Context, arg domain.ListAccountsP
 	return items, nil
 }
 
+// GetAccountForUpdate reads the account and holds a row lock on it until the
+// transaction ends. FOR NO KEY UPDATE still lets entries and transfers that
+// reference the account be inserted concurrently.
 func (r *AccountRepo) GetAccountForUpdate(ctx context.Context, id int) (domain.Account, error) {
-	stmt := `SELECT id, owner, balance, currency, created_at FROM accounts
-	WHERE id = $1 LIMIT 1`
+	if _, ok := r.db.(txBeginner); ok {
+		return domain.Account{}, e.ErrLockOutsideTx
+	}
+
+	stmt := `SELECT id, owner, balance, currency, overdraft_limit, status, created_at FROM accounts
+	WHERE id = $1 LIMIT 1
+	FOR NO KEY UPDATE`
 	row := r.db.QueryRowContext(ctx, stmt, id)
 	var i domain.Account
 	err := row.Scan(
@@ -105,16 +135,68 @@ func (r *AccountRepo) GetAccountForUpdate(ctx context.Context, id int) (domain.A
 		&i.Owner,
 		&i.Balance,
 		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
+// LockAccounts locks every given account in ascending id order, so any two
+// transactions locking an overlapping set can't deadlock on each other. The
+// accounts are returned in id order, and sql.ErrNoRows is returned when one
+// of them doesn't exist.
+func (r *AccountRepo) LockAccounts(ctx context.Context, ids ...int) ([]domain.Account, error) {
+	if _, ok := r.db.(txBeginner); ok {
+		return nil, e.ErrLockOutsideTx
+	}
+
+	unique := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		unique[id] = struct{}{}
+	}
+
+	stmt := `SELECT id, owner, balance, currency, overdraft_limit, status, created_at FROM accounts
+	WHERE id = ANY($1)
+	ORDER BY id
+	FOR NO KEY UPDATE`
+	rows, err := r.db.QueryContext(ctx, stmt, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]domain.Account, 0, len(unique))
+	for rows.Next() {
+		var i domain.Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(items) != len(unique) {
+		return nil, sql.ErrNoRows
+	}
+	return items, nil
+}
+
 func (r *AccountRepo) UpdateAccount(ctx context.Context, arg domain.UpdateAccountParams) (domain.Account, error) {
 	stmt := `UPDATE accounts
 	SET balance = $2
 	WHERE id = $1
-	RETURNING id, owner, balance, currency, created_at`
+	RETURNING id, owner, balance, currency, overdraft_limit, status, created_at`
 
 	row := r.db.QueryRowContext(ctx, stmt, arg.ID, arg.Balance)
 	var i domain.Account
@@ -123,6 +205,8 @@ func (r *AccountRepo) UpdateAccount(ctx context.Context, arg domain.UpdateAccoun
 		&i.Owner,
 		&i.Balance,
 		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
 		&i.CreatedAt,
 	)
 	return i, err
@@ -132,7 +216,7 @@ func (r *AccountRepo) AddAccountBalance(ctx context.Context, arg domain.AddAccou
 	stmt := `UPDATE accounts
 	SET balance = balance + $1 
 	WHERE id = $2
-	RETURNING id, owner, balance, currency, created_at`
+	RETURNING id, owner, balance, currency, overdraft_limit, status, created_at`
 	row := r.db.QueryRowContext(ctx, stmt, arg.Amount, arg.ID)
 	var i domain.Account
 	err := row.Scan(
@@ -140,7 +224,108 @@ func (r *AccountRepo) AddAccountBalance(ctx context.Context, arg domain.AddAccou
 		&i.Owner,
 		&i.Balance,
 		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *AccountRepo) SetOverdraftLimit(ctx context.Context, arg domain.SetOverdraftLimitParams) (domain.Account, error) {
+	stmt := `UPDATE accounts
+	SET overdraft_limit = $2
+	WHERE id = $1
+	RETURNING id, owner, balance, currency, overdraft_limit, status, created_at`
+
+	row := r.db.QueryRowContext(ctx, stmt, arg.ID, arg.OverdraftLimit)
+	var i domain.Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *AccountRepo) SetAccountStatus(ctx context.Context, id int, status string) (domain.Account, error) {
+	stmt := `UPDATE accounts
+	SET status = $2
+	WHERE id = $1
+	RETURNING id, owner, balance, currency, overdraft_limit, status, created_at`
+
+	row := r.db.QueryRowContext(ctx, stmt, id, status)
+	var i domain.Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *AccountRepo) CreateAccountStatusChange(ctx context.Context, arg domain.CreateAccountStatusChangeParams) (domain.AccountStatusChange, error) {
+	stmt := `INSERT INTO account_status_changes (
+		account_id,
+		from_status,
+		to_status,
+		reason,
+		changed_by
+	) VALUES (
+		$1, $2, $3, $4, $5
+	) RETURNING id, account_id, from_status, to_status, reason, changed_by, created_at`
+
+	row := r.db.QueryRowContext(ctx, stmt, arg.AccountID, arg.FromStatus, arg.ToStatus, arg.Reason, arg.ChangedBy)
+	var i domain.AccountStatusChange
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.Reason,
+		&i.ChangedBy,
 		&i.CreatedAt,
 	)
 	return i, err
 }
+
+func (r *AccountRepo) ListAccountStatusChanges(ctx context.Context, arg domain.ListAccountStatusChangesParams) ([]domain.AccountStatusChange, error) {
+	stmt := `SELECT id, account_id, from_status, to_status, reason, changed_by, created_at FROM account_status_changes
+	WHERE account_id = $1
+	ORDER BY id DESC
+	LIMIT $2
+	OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, stmt, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.AccountStatusChange{}
+	for rows.Next() {
+		var i domain.AccountStatusChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Reason,
+			&i.ChangedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -3,12 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
 	"github.com/begenov/backend/pkg/util"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,43 +55,168 @@ func TestUpdateAccount(t *testing.T) {
 
 }
 
-func TestDeleateAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
+func TestChangeAccountStatusTx(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 10)
+	require.Equal(t, domain.AccountActive, account1.Status)
+
+	change := func(status string) (domain.Account, error) {
+		return store.ChangeAccountStatusTx(ctx, domain.ChangeAccountStatusParams{
+			ID:        account1.ID,
+			Status:    status,
+			Reason:    "test",
+			ChangedBy: account1.Owner,
+		})
+	}
 
-	err := repo.DeleteAccount(ctx, account1.ID)
+	account2, err := change(domain.AccountFrozen)
 	require.NoError(t, err)
+	require.Equal(t, domain.AccountFrozen, account2.Status)
 
-	account2, err := repo.GetAccount(ctx, account1.ID)
+	// a frozen account can't be closed until it is unfrozen
+	_, err = change(domain.AccountClosed)
+	require.ErrorIs(t, err, e.ErrInvalidStatusTransition)
+
+	_, err = change(domain.AccountActive)
+	require.NoError(t, err)
+
+	_, err = change(domain.AccountClosed)
+	require.ErrorIs(t, err, e.ErrNonZeroBalance)
+
+	_, err = repo.UpdateAccount(ctx, domain.UpdateAccountParams{ID: account1.ID, Balance: 0})
+	require.NoError(t, err)
+
+	account2, err = change(domain.AccountClosed)
+	require.NoError(t, err)
+	require.Equal(t, domain.AccountClosed, account2.Status)
+
+	_, err = change(domain.AccountActive)
+	require.ErrorIs(t, err, e.ErrInvalidStatusTransition)
+
+	changes, err := repo.ListAccountStatusChanges(ctx, domain.ListAccountStatusChangesParams{
+		AccountID: account1.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	require.Equal(t, domain.AccountActive, changes[0].FromStatus)
+	require.Equal(t, domain.AccountClosed, changes[0].ToStatus)
+	require.Equal(t, account1.Owner, changes[0].ChangedBy)
+
+	// the row and its history stay
+	account2, err = repo.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.AccountClosed, account2.Status)
+
+	// the event leaves out the reason and who made the change
+	var payload map[string]interface{}
+	var data []byte
+	err = db.QueryRowContext(ctx, `SELECT payload FROM outbox_events WHERE account_id = $1 AND event_type = $2 ORDER BY id DESC LIMIT 1`,
+		account1.ID, domain.EventAccountStatusChanged).Scan(&data)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &payload))
+	require.Equal(t, domain.AccountClosed, payload["to_status"])
+	require.NotContains(t, payload, "reason")
+	require.NotContains(t, payload, "changed_by")
+
+	// the closed account no longer takes up the owner's slot for the currency
+	reopened, err := repo.CreateAccount(ctx, domain.CreateAccountParams{
+		Owner:    account1.Owner,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+
+	account2, err = repo.GetAccountByOwner(ctx, account1.Owner, account1.Currency)
+	require.NoError(t, err)
+	require.Equal(t, reopened.ID, account2.ID)
+
+	_, err = repo.CreateAccount(ctx, domain.CreateAccountParams{
+		Owner:    account1.Owner,
+		Currency: account1.Currency,
+	})
 	require.Error(t, err)
-	require.EqualError(t, err, sql.ErrNoRows.Error())
-	require.Empty(t, account2)
 }
 
 func TestListAccounts(t *testing.T) {
+	var lastAccount domain.Account
 	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+		lastAccount = createRandomAccount(t)
 	}
 
 	arg := domain.ListAccountsParams{
+		Owner:  lastAccount.Owner,
 		Limit:  5,
-		Offset: 5,
+		Offset: 0,
 	}
 
 	accounts, err := repo.ListAccounts(context.Background(), arg)
 	require.NoError(t, err)
-	require.Len(t, accounts, arg.Limit)
+	require.NotEmpty(t, accounts)
 	for _, account := range accounts {
 		require.NotEmpty(t, account)
+		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 
 }
 
+func TestGetAccountForUpdateOutsideTx(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := repo.GetAccountForUpdate(ctx, account.ID)
+	require.ErrorIs(t, err, e.ErrLockOutsideTx)
+
+	_, err = repo.LockAccounts(ctx, account.ID)
+	require.ErrorIs(t, err, e.ErrLockOutsideTx)
+}
+
+func TestLockAccounts(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	accounts, err := New(tx).LockAccounts(ctx, account2.ID, account1.ID, account2.ID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	require.Equal(t, account1.ID, accounts[0].ID)
+	require.Equal(t, account2.ID, accounts[1].ID)
+
+	// a second transaction can't lock the same row while the first holds it
+	other, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer other.Rollback()
+
+	_, err = other.ExecContext(ctx, "SET LOCAL lock_timeout = '50ms'")
+	require.NoError(t, err)
+	_, err = New(other).GetAccountForUpdate(ctx, account1.ID)
+	require.Error(t, err)
+	require.Equal(t, "55P03", string(err.(*pq.Error).Code))
+}
+
+func TestLockAccountsNotFound(t *testing.T) {
+	account := createRandomAccount(t)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = New(tx).LockAccounts(ctx, account.ID, account.ID+1000000)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 func createRandomAccount(t *testing.T) domain.Account {
+	return createAccountWithBalance(t, int(util.RandomMany()))
+}
+
+func createAccountWithBalance(t *testing.T, balance int) domain.Account {
 	user := createRandomUser(t)
 
 	arg := domain.CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  int(util.RandomMany()),
+		Balance:  balance,
 		Currency: util.RandomCurrency(),
 	}
 
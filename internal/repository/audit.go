@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+type AuditRepo struct {
+	db DBTX
+}
+
+func NewAuditRepo(db DBTX) *AuditRepo {
+	return &AuditRepo{
+		db: db,
+	}
+}
+
+// CreateAuditEvent appends an event to the audit log. The table only takes
+// inserts, updates and deletes are rejected by a trigger.
+func (r *AuditRepo) CreateAuditEvent(ctx context.Context, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+	targets, err := json.Marshal(auditTargets(arg.Targets))
+	if err != nil {
+		return domain.AuditEvent{}, err
+	}
+
+	stmt := `INSERT INTO audit_events (
+		actor,
+		action,
+		targets,
+		request_id,
+		client_ip,
+		user_agent,
+		outcome,
+		error
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	) RETURNING id, actor, action, targets, request_id, client_ip, user_agent, outcome, error, created_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.Actor, arg.Action, targets, arg.RequestID, arg.ClientIP, arg.UserAgent, arg.Outcome, arg.Error)
+	return scanAuditEvent(row)
+}
+
+// ListAuditEvents returns the events matching every filter that is set,
+// newest first.
+func (r *AuditRepo) ListAuditEvents(ctx context.Context, arg domain.ListAuditEventsParams) ([]domain.AuditEvent, error) {
+	stmt := `SELECT id, actor, action, targets, request_id, client_ip, user_agent, outcome, error, created_at FROM audit_events
+	WHERE ($1 = '' OR actor = $1)
+	AND ($2 = '' OR action = $2)
+	AND ($3 = '' OR outcome = $3)
+	AND ($4 = '' OR targets @> jsonb_build_object($4::text, jsonb_build_array($5::bigint)))
+	AND ($6::timestamptz IS NULL OR created_at >= $6)
+	AND ($7::timestamptz IS NULL OR created_at < $7)
+	ORDER BY id DESC
+	LIMIT $8
+	OFFSET $9`
+	from := sql.NullTime{Time: arg.From, Valid: !arg.From.IsZero()}
+	to := sql.NullTime{Time: arg.To, Valid: !arg.To.IsZero()}
+	rows, err := r.db.QueryContext(ctx, stmt, arg.Actor, arg.Action, arg.Outcome, arg.TargetType, arg.TargetID, from, to, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.AuditEvent{}
+	for rows.Next() {
+		i, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAuditEvent(row rowScanner) (domain.AuditEvent, error) {
+	var i domain.AuditEvent
+	var targets []byte
+	err := row.Scan(
+		&i.ID,
+		&i.Actor,
+		&i.Action,
+		&targets,
+		&i.RequestID,
+		&i.ClientIP,
+		&i.UserAgent,
+		&i.Outcome,
+		&i.Error,
+		&i.CreatedAt,
+	)
+	if err != nil {
+		return i, err
+	}
+	err = json.Unmarshal(targets, &i.Targets)
+	return i, err
+}
+
+// auditTargets stores missing targets as an empty object, the column is not
+// null.
+func auditTargets(targets domain.AuditTargets) domain.AuditTargets {
+	if targets == nil {
+		return domain.AuditTargets{}
+	}
+	return targets
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndListAuditEvents(t *testing.T) {
+	store := NewRepository(db)
+	actor := util.RandomOwner()
+	start := time.Now().Add(-time.Minute)
+
+	created, err := store.Audit.CreateAuditEvent(ctx, domain.CreateAuditEventParams{
+		Actor:     actor,
+		Action:    domain.AuditTransferCreate,
+		Targets:   domain.AuditTargets{domain.AuditTargetAccount: {1, 2}, domain.AuditTargetTransfer: {7}},
+		RequestID: "req-1",
+		ClientIP:  "10.0.0.1",
+		UserAgent: "test-agent",
+		Outcome:   domain.AuditSuccess,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, created.ID)
+	require.Equal(t, domain.AuditTargets{domain.AuditTargetAccount: {1, 2}, domain.AuditTargetTransfer: {7}}, created.Targets)
+
+	_, err = store.Audit.CreateAuditEvent(ctx, domain.CreateAuditEventParams{
+		Actor:   actor,
+		Action:  domain.AuditUserLogin,
+		Outcome: domain.AuditFailure,
+		Error:   "wrong password",
+	})
+	require.NoError(t, err)
+
+	all, err := store.Audit.ListAuditEvents(ctx, domain.ListAuditEventsParams{Actor: actor, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	// newest first
+	require.Equal(t, domain.AuditUserLogin, all[0].Action)
+	require.Empty(t, all[0].Targets)
+
+	byTarget, err := store.Audit.ListAuditEvents(ctx, domain.ListAuditEventsParams{
+		Actor:      actor,
+		TargetType: domain.AuditTargetAccount,
+		TargetID:   2,
+		From:       start,
+		To:         time.Now().Add(time.Minute),
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, byTarget, 1)
+	require.Equal(t, created.ID, byTarget[0].ID)
+
+	failed, err := store.Audit.ListAuditEvents(ctx, domain.ListAuditEventsParams{Actor: actor, Outcome: domain.AuditFailure, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	require.Equal(t, "wrong password", failed[0].Error)
+}
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	store := NewRepository(db)
+
+	event, err := store.Audit.CreateAuditEvent(ctx, domain.CreateAuditEventParams{
+		Actor:   util.RandomOwner(),
+		Action:  domain.AuditAccountCreate,
+		Outcome: domain.AuditSuccess,
+	})
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, `UPDATE audit_events SET outcome = 'failure' WHERE id = $1`, event.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = db.ExecContext(ctx, `DELETE FROM audit_events WHERE id = $1`, event.ID)
+	require.ErrorContains(t, err, "append-only")
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+)
+
+// BatchTransferTx books all transfers in a single transaction, so either all
+// of them go through or none does. Every account of the batch is locked up
+// front in id order, which keeps concurrent batches from deadlocking. A
+// failing transfer is reported as an *e.BatchTransferError.
+func (r *Repository) BatchTransferTx(ctx context.Context, transfers []domain.TransferTxParams) ([]domain.TransferTxResult, error) {
+	var results []domain.TransferTxResult
+
+	accountIDs := make([]int, 0, 2*len(transfers))
+	for _, arg := range transfers {
+		accountIDs = append(accountIDs, arg.FromAccountID, arg.ToAccountID)
+	}
+
+	_, err := r.execTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(q *Repository) error {
+		results = make([]domain.TransferTxResult, 0, len(transfers))
+
+		if _, err := q.Account.LockAccounts(ctx, accountIDs...); err != nil {
+			return err
+		}
+
+		for i, arg := range transfers {
+			toAmount, exchangeRate := arg.ToAmount, arg.ExchangeRate
+			if toAmount == 0 {
+				toAmount, exchangeRate = arg.Amount, 1
+			}
+
+			// the balances change as the batch goes, so funds are checked
+			// against what the earlier transfers left
+			if err := checkFunds(ctx, q.Account, arg.FromAccountID, arg.ToAccountID, arg.Amount); err != nil {
+				return &e.BatchTransferError{Index: i, Err: err}
+			}
+
+			result, err := bookTransfer(ctx, q, domain.CreateTransferParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   arg.ToAccountID,
+				Amount:        arg.Amount,
+				ToAmount:      toAmount,
+				ExchangeRate:  exchangeRate,
+			})
+			if err != nil {
+				return &e.BatchTransferError{Index: i, Err: err}
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+
+	return results, err
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/lib/pq"
+)
+
+// DepositTx credits the account with money received by the external
+// processor. The other side of the booking is a debit on the settlement
+// account, so the sum of all balances doesn't change.
+func (r *Repository) DepositTx(ctx context.Context, arg domain.CashTxParams) (domain.CashTxResult, error) {
+	return r.cashTx(ctx, domain.TransferKindDeposit, arg)
+}
+
+// WithdrawTx debits the account for money paid out by the external processor
+// and credits the settlement account. The account must stay within its
+// overdraft limit.
+func (r *Repository) WithdrawTx(ctx context.Context, arg domain.CashTxParams) (domain.CashTxResult, error) {
+	return r.cashTx(ctx, domain.TransferKindWithdrawal, arg)
+}
+
+func (r *Repository) cashTx(ctx context.Context, kind string, arg domain.CashTxParams) (domain.CashTxResult, error) {
+	var result domain.CashTxResult
+
+	_, err := r.execTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(q *Repository) error {
+		transfer := domain.CreateTransferParams{
+			FromAccountID: arg.SettlementAccountID,
+			ToAccountID:   arg.AccountID,
+			Amount:        arg.Amount,
+			ToAmount:      arg.Amount,
+			ExchangeRate:  1,
+			Kind:          kind,
+			ExternalRef:   arg.ExternalRef,
+		}
+
+		if kind == domain.TransferKindWithdrawal {
+			transfer.FromAccountID, transfer.ToAccountID = arg.AccountID, arg.SettlementAccountID
+			if err := checkFunds(ctx, q.Account, arg.AccountID, arg.SettlementAccountID, arg.Amount); err != nil {
+				return err
+			}
+		} else {
+			accounts, err := q.Account.LockAccounts(ctx, arg.AccountID, arg.SettlementAccountID)
+			if err != nil {
+				return err
+			}
+			for _, locked := range accounts {
+				if err := checkOpen(locked); err != nil {
+					return err
+				}
+			}
+		}
+
+		booked, err := bookTransfer(ctx, q, transfer)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Constraint == "transfers_external_ref_key" {
+				return e.ErrDuplicateExternalRef
+			}
+			return err
+		}
+
+		result = domain.CashTxResult{
+			Transfer: booked.Transfer,
+			Account:  booked.ToAccount,
+			Entry:    booked.ToEntry,
+		}
+		if kind == domain.TransferKindWithdrawal {
+			result.Account, result.Entry = booked.FromAccount, booked.FromEntry
+		}
+		return nil
+	})
+
+	return result, err
+}
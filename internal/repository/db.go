@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is the part of *sql.DB and *sql.Tx the repos run their queries on,
+// so the same repo code works both on the pool and inside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txBeginner is implemented by *sql.DB but not by *sql.Tx.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
@@ -10,10 +10,10 @@ import (
 )
 
 type EntryRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewEntryRepo(db *sql.DB) *EntryRepo {
+func NewEntryRepo(db DBTX) *EntryRepo {
 	return &EntryRepo{
 		db: db,
 	}
@@ -23,12 +23,14 @@ func (r *EntryRepo) CreateEntry(ctx context.Context, arg domain.CreateEntryParam
 	stmt := `
 		INSERT INTO entries (
 			account_id,
-			amount
+			amount,
+			transfer_id
 		) VALUES (
-			$1, $2
+			$1, $2, $3
 		) RETURNING id, account_id, amount, created_at
 	`
-	row := r.db.QueryRowContext(ctx, stmt, arg.AccountID, arg.Amount)
+	transferID := sql.NullInt64{Int64: int64(arg.TransferID), Valid: arg.TransferID != 0}
+	row := r.db.QueryRowContext(ctx, stmt, arg.AccountID, arg.Amount, transferID)
 	var entry domain.Entry
 	err := row.Scan(
 		&entry.ID,
@@ -94,3 +96,116 @@ func (r *EntryRepo) ListEntries(ctx context.Context, arg domain.ListEntriesParam
 	}
 	return items, nil
 }
+
+// GetStatement computes the statement in a single snapshot, so the balances
+// and the listed entries always agree.
+func (r *EntryRepo) GetStatement(ctx context.Context, arg domain.StatementParams) (domain.Statement, error) {
+	var statement domain.Statement
+	err := r.StreamStatement(ctx, arg, func(s domain.Statement) error {
+		statement = s
+		statement.Entries = []domain.StatementEntry{}
+		return nil
+	}, func(entry domain.StatementEntry) error {
+		statement.Entries = append(statement.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return domain.Statement{}, err
+	}
+	return statement, nil
+}
+
+// StreamStatement computes the statement like GetStatement but hands it over
+// as the rows are read: begin gets the statement without its entries, then
+// entry is called for each entry in order. An error from either stops the
+// scan and is returned. The opening balance is derived from the current
+// balance minus everything booked since the start of the period, which only
+// touches the entries in and after the period. A tx-scoped repo already runs
+// in the caller's snapshot and uses it as is.
+func (r *EntryRepo) StreamStatement(ctx context.Context, arg domain.StatementParams, begin func(domain.Statement) error, entry func(domain.StatementEntry) error) error {
+	q := r.db
+	var tx *sql.Tx
+	if db, ok := r.db.(txBeginner); ok {
+		var err error
+		tx, err = db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		q = traceDBTX(tx)
+	}
+
+	statement := domain.Statement{
+		AccountID: arg.AccountID,
+		From:      arg.From,
+		To:        arg.To,
+	}
+
+	balanceStmt := `
+		SELECT
+			a.currency,
+			(a.balance - COALESCE(SUM(e.amount), 0))::bigint,
+			(a.balance - COALESCE(SUM(e.amount) FILTER (WHERE e.created_at >= $3), 0))::bigint
+		FROM accounts a
+		LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= $2
+		WHERE a.id = $1
+		GROUP BY a.id
+	`
+	row := q.QueryRowContext(ctx, balanceStmt, arg.AccountID, arg.From, arg.To)
+	if err := row.Scan(&statement.Currency, &statement.OpeningBalance, &statement.ClosingBalance); err != nil {
+		return err
+	}
+
+	entriesStmt := `
+		SELECT
+			e.id,
+			e.amount,
+			($4::bigint + SUM(e.amount) OVER (ORDER BY e.created_at, e.id))::bigint,
+			COALESCE(t.id, 0),
+			COALESCE(c.id, 0),
+			COALESCE(c.owner, ''),
+			e.created_at
+		FROM entries e
+		LEFT JOIN transfers t ON t.id = e.transfer_id
+		LEFT JOIN accounts c ON c.id = CASE
+			WHEN t.from_account_id = e.account_id THEN t.to_account_id
+			ELSE t.from_account_id
+		END
+		WHERE e.account_id = $1 AND e.created_at >= $2 AND e.created_at < $3
+		ORDER BY e.created_at, e.id
+	`
+	rows, err := q.QueryContext(ctx, entriesStmt, arg.AccountID, arg.From, arg.To, statement.OpeningBalance)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := begin(statement); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var i domain.StatementEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.Balance,
+			&i.TransferID,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
+			&i.CreatedAt,
+		); err != nil {
+			return err
+		}
+		if err := entry(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if tx == nil {
+		return nil
+	}
+	return tx.Commit()
+}
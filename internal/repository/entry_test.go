@@ -2,6 +2,7 @@ package repository
 
 import (
 	"testing"
+	"time"
 
 	"github.com/begenov/backend/internal/domain"
 	"github.com/begenov/backend/pkg/util"
@@ -52,6 +53,44 @@ func TestListEntries(t *testing.T) {
 
 }
 
+func TestGetStatement(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	transfer := createRandomTransfer(t, account1, account2)
+
+	from := time.Now().Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		createRandomEntry(t, account1)
+	}
+	_, err := entryRepo.CreateEntry(ctx, domain.CreateEntryParams{
+		AccountID:  account1.ID,
+		Amount:     -transfer.Amount,
+		TransferID: transfer.ID,
+	})
+	require.NoError(t, err)
+
+	statement, err := entryRepo.GetStatement(ctx, domain.StatementParams{
+		AccountID: account1.ID,
+		From:      from,
+		To:        time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Currency, statement.Currency)
+	require.Len(t, statement.Entries, 4)
+
+	balance := statement.OpeningBalance
+	for _, entry := range statement.Entries {
+		balance += entry.Amount
+		require.Equal(t, balance, entry.Balance)
+	}
+	require.Equal(t, balance, statement.ClosingBalance)
+
+	last := statement.Entries[3]
+	require.Equal(t, transfer.ID, last.TransferID)
+	require.Equal(t, account2.ID, last.CounterpartyAccountID)
+	require.Equal(t, account2.Owner, last.CounterpartyOwner)
+}
+
 func createRandomEntry(t *testing.T, account domain.Account) domain.Entry {
 	arg := domain.CreateEntryParams{
 		AccountID: account.ID,
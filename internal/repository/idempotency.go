@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+type IdempotencyRepo struct {
+	db DBTX
+}
+
+func NewIdempotencyRepo(db DBTX) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		db: db,
+	}
+}
+
+// CreateIdempotencyKey reserves the key for its owner. It returns false
+// without an error when the owner has already reserved the key for an
+// earlier request that hasn't expired yet. An expired key is taken over as
+// if it was new.
+func (r *IdempotencyRepo) CreateIdempotencyKey(ctx context.Context, arg domain.CreateIdempotencyKeyParams) (bool, error) {
+	stmt := `INSERT INTO idempotency_keys (
+		owner,
+		key,
+		request_hash,
+		expires_at
+	) VALUES (
+		$1, $2, $3, now() + make_interval(secs => $4)
+	) ON CONFLICT (owner, key) DO UPDATE SET
+		request_hash = EXCLUDED.request_hash,
+		transfer_id = NULL,
+		response = NULL,
+		created_at = now(),
+		expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= now()`
+	res, err := r.db.ExecContext(ctx, stmt, arg.Owner, arg.Key, arg.RequestHash, arg.TTL.Seconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *IdempotencyRepo) GetIdempotencyKey(ctx context.Context, owner, key string) (domain.IdempotencyKey, error) {
+	stmt := `SELECT owner, key, request_hash, transfer_id, response, created_at, expires_at FROM idempotency_keys
+	WHERE owner = $1 AND key = $2 LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, owner, key)
+	var i domain.IdempotencyKey
+	var transferID sql.NullInt64
+	err := row.Scan(
+		&i.Owner,
+		&i.Key,
+		&i.RequestHash,
+		&transferID,
+		&i.Response,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	i.TransferID = int(transferID.Int64)
+	return i, err
+}
+
+func (r *IdempotencyRepo) UpdateIdempotencyKey(ctx context.Context, arg domain.UpdateIdempotencyKeyParams) error {
+	stmt := `UPDATE idempotency_keys
+	SET transfer_id = $3, response = $4
+	WHERE owner = $1 AND key = $2`
+	_, err := r.db.ExecContext(ctx, stmt, arg.Owner, arg.Key, arg.TransferID, arg.Response)
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys removes up to limit expired keys and returns
+// how many it removed.
+func (r *IdempotencyRepo) DeleteExpiredIdempotencyKeys(ctx context.Context, limit int) (int, error) {
+	stmt := `DELETE FROM idempotency_keys
+	WHERE (owner, key) IN (
+		SELECT owner, key FROM idempotency_keys
+		WHERE expires_at <= now()
+		LIMIT $1
+	)`
+	res, err := r.db.ExecContext(ctx, stmt, limit)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+type LedgerRepo struct {
+	db DBTX
+}
+
+func NewLedgerRepo(db DBTX) *LedgerRepo {
+	return &LedgerRepo{
+		db: db,
+	}
+}
+
+// CheckAccountBalances compares the balance of the next batch of accounts
+// with the sum of their entries. Each batch is read in a single statement,
+// so a concurrent transfer can't show up as a discrepancy.
+func (r *LedgerRepo) CheckAccountBalances(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	stmt := `
+		SELECT a.id, a.balance, COALESCE(SUM(e.amount), 0)::bigint
+		FROM (
+			SELECT id, balance FROM accounts
+			WHERE id > $1
+			ORDER BY id
+			LIMIT $2
+		) a
+		LEFT JOIN entries e ON e.account_id = a.id
+		GROUP BY a.id, a.balance
+		ORDER BY a.id
+	`
+	rows, err := r.db.QueryContext(ctx, stmt, arg.AfterID, arg.Limit)
+	if err != nil {
+		return domain.LedgerBatch{}, err
+	}
+	defer rows.Close()
+
+	var batch domain.LedgerBatch
+	for rows.Next() {
+		var accountID, balance, total int
+		if err := rows.Scan(&accountID, &balance, &total); err != nil {
+			return domain.LedgerBatch{}, err
+		}
+		batch.Checked++
+		batch.LastID = accountID
+
+		if balance != total {
+			batch.Discrepancies = append(batch.Discrepancies, domain.LedgerDiscrepancy{
+				Kind:      domain.LedgerAccountBalanceMismatch,
+				AccountID: accountID,
+				Detail:    fmt.Sprintf("balance %d, entries sum to %d", balance, total),
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return domain.LedgerBatch{}, err
+	}
+
+	if batch.Checked < arg.Limit {
+		batch.LastID = 0
+	}
+	return batch, nil
+}
+
+// CheckTransferEntries checks that each transfer of the next batch has
+// exactly two entries: a debit of amount on the from account and a credit of
+// to_amount on the to account. Without a conversion the two sum to zero.
+//
+// Entries booked before migration 000005 have a NULL transfer_id, so the
+// transfers they belong to have no linked entries. A transfer without linked
+// entries that is older than the first linked entry is taken for one of those
+// and skipped rather than reported. The timestamps of the transfer and its
+// entries can't be compared: back then each insert committed on its own. The
+// entries still count towards the account balances.
+func (r *LedgerRepo) CheckTransferEntries(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	stmt := `
+		SELECT
+			t.id,
+			t.amount,
+			t.to_amount,
+			COUNT(e.id),
+			COUNT(e.id) FILTER (WHERE e.account_id = t.from_account_id AND e.amount = -t.amount),
+			COUNT(e.id) FILTER (WHERE e.account_id = t.to_account_id AND e.amount = t.to_amount),
+			COALESCE(SUM(e.amount), 0)::bigint,
+			COALESCE(t.created_at < (
+				SELECT l.created_at FROM entries l
+				WHERE l.transfer_id IS NOT NULL
+				ORDER BY l.id
+				LIMIT 1
+			), false)
+		FROM (
+			SELECT id, from_account_id, to_account_id, amount, to_amount, created_at FROM transfers
+			WHERE id > $1
+			ORDER BY id
+			LIMIT $2
+		) t
+		LEFT JOIN entries e ON e.transfer_id = t.id
+		GROUP BY t.id, t.from_account_id, t.to_account_id, t.amount, t.to_amount, t.created_at
+		ORDER BY t.id
+	`
+	rows, err := r.db.QueryContext(ctx, stmt, arg.AfterID, arg.Limit)
+	if err != nil {
+		return domain.LedgerBatch{}, err
+	}
+	defer rows.Close()
+
+	var batch domain.LedgerBatch
+	for rows.Next() {
+		var transferID, amount, toAmount, entries, debits, credits, total int
+		var legacy bool
+		if err := rows.Scan(&transferID, &amount, &toAmount, &entries, &debits, &credits, &total, &legacy); err != nil {
+			return domain.LedgerBatch{}, err
+		}
+		batch.Checked++
+		batch.LastID = transferID
+
+		if entries == 0 && legacy {
+			batch.Skipped++
+			continue
+		}
+
+		if entries != 2 || debits != 1 || credits != 1 {
+			batch.Discrepancies = append(batch.Discrepancies, domain.LedgerDiscrepancy{
+				Kind:       domain.LedgerTransferEntriesMismatch,
+				TransferID: transferID,
+				Detail: fmt.Sprintf("%d entries summing to %d, want a debit of %d and a credit of %d",
+					entries, total, amount, toAmount),
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return domain.LedgerBatch{}, err
+	}
+
+	if batch.Checked < arg.Limit {
+		batch.LastID = 0
+	}
+	return batch, nil
+}
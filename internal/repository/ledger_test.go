@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerChecks(t *testing.T) {
+	store := NewRepository(db)
+
+	settlement := createAccountWithBalance(t, 0)
+	account1 := createAccountWithBalance(t, 0)
+	account2 := createAccountWithBalance(t, 0)
+
+	_, err := store.DepositTx(ctx, domain.CashTxParams{
+		AccountID:           account1.ID,
+		SettlementAccountID: settlement.ID,
+		Amount:              100,
+		ExternalRef:         util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	transfer, err := store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        50,
+	})
+	require.NoError(t, err)
+
+	// corrupt the ledger: move money without an entry and write a transfer
+	// that has no entries at all
+	_, err = db.ExecContext(ctx, `UPDATE accounts SET balance = balance + 7 WHERE id = $1`, account2.ID)
+	require.NoError(t, err)
+
+	orphan, err := store.Transfer.CreateTransfer(ctx, domain.CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		ToAmount:      10,
+		ExchangeRate:  1,
+	})
+	require.NoError(t, err)
+
+	legacy := createLegacyTransfer(t, account1.ID, account2.ID, 5)
+
+	accounts, err := store.Ledger.CheckAccountBalances(ctx, domain.LedgerBatchParams{
+		AfterID: settlement.ID - 1,
+		Limit:   3,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, accounts.Checked)
+	require.Len(t, accounts.Discrepancies, 1)
+	require.Equal(t, domain.LedgerAccountBalanceMismatch, accounts.Discrepancies[0].Kind)
+	require.Equal(t, account2.ID, accounts.Discrepancies[0].AccountID)
+
+	transfers, err := store.Ledger.CheckTransferEntries(ctx, domain.LedgerBatchParams{
+		AfterID: transfer.Transfer.ID - 1,
+		Limit:   1000,
+	})
+	require.NoError(t, err)
+
+	var reported []int
+	for _, d := range transfers.Discrepancies {
+		require.Equal(t, domain.LedgerTransferEntriesMismatch, d.Kind)
+		reported = append(reported, d.TransferID)
+	}
+	require.Contains(t, reported, orphan.ID)
+	require.NotContains(t, reported, transfer.Transfer.ID)
+	require.NotContains(t, reported, legacy)
+	require.Equal(t, 1, transfers.Skipped)
+}
+
+// createLegacyTransfer books a transfer the way it was done before entries
+// were linked to their transfer and returns its id. Every statement commits
+// on its own, like execTx did then, and the rows are dated before any linked
+// entry was written.
+func createLegacyTransfer(t *testing.T, fromAccountID, toAccountID, amount int) int {
+	var id int
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO transfers (from_account_id, to_account_id, amount, to_amount, created_at)
+		VALUES ($1, $2, $3, $3, now() - interval '10 years')
+		RETURNING id
+	`, fromAccountID, toAccountID, amount).Scan(&id)
+	require.NoError(t, err)
+
+	for _, entry := range []struct{ accountID, amount int }{
+		{fromAccountID, -amount},
+		{toAccountID, amount},
+	} {
+		_, err = db.ExecContext(ctx, `INSERT INTO entries (account_id, amount, created_at) VALUES ($1, $2, now() - interval '10 years')`,
+			entry.accountID, entry.amount)
+		require.NoError(t, err)
+	}
+
+	_, err = db.ExecContext(ctx, `UPDATE accounts SET balance = balance - $2 WHERE id = $1`, fromAccountID, amount)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `UPDATE accounts SET balance = balance + $2 WHERE id = $1`, toAccountID, amount)
+	require.NoError(t, err)
+
+	return id
+}
+
+func TestLedgerCheckBatches(t *testing.T) {
+	account1 := createAccountWithBalance(t, 0)
+	createAccountWithBalance(t, 0)
+
+	ledger := NewLedgerRepo(db)
+
+	batch, err := ledger.CheckAccountBalances(ctx, domain.LedgerBatchParams{
+		AfterID: account1.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, batch.Checked)
+	require.Equal(t, account1.ID, batch.LastID)
+
+	// reading past the last account ends the scan
+	batch, err = ledger.CheckAccountBalances(ctx, domain.LedgerBatchParams{
+		AfterID: 1 << 30,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Zero(t, batch.Checked)
+	require.Zero(t, batch.LastID)
+}
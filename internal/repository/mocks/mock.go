@@ -7,6 +7,7 @@ package mock_repository
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/begenov/backend/internal/domain"
 	gomock "github.com/golang/mock/gomock"
@@ -65,18 +66,19 @@ func (mr *MockAccountMockRecorder) CreateAccount(ctx, arg interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccount)(nil).CreateAccount), ctx, arg)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockAccount) DeleteAccount(ctx context.Context, id int) error {
+// CreateAccountStatusChange mocks base method.
+func (m *MockAccount) CreateAccountStatusChange(ctx context.Context, arg domain.CreateAccountStatusChangeParams) (domain.AccountStatusChange, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "CreateAccountStatusChange", ctx, arg)
+	ret0, _ := ret[0].(domain.AccountStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockAccountMockRecorder) DeleteAccount(ctx, id interface{}) *gomock.Call {
+// CreateAccountStatusChange indicates an expected call of CreateAccountStatusChange.
+func (mr *MockAccountMockRecorder) CreateAccountStatusChange(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccount)(nil).DeleteAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountStatusChange", reflect.TypeOf((*MockAccount)(nil).CreateAccountStatusChange), ctx, arg)
 }
 
 // GetAccount mocks base method.
@@ -94,6 +96,21 @@ func (mr *MockAccountMockRecorder) GetAccount(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockAccount)(nil).GetAccount), ctx, id)
 }
 
+// GetAccountByOwner mocks base method.
+func (m *MockAccount) GetAccountByOwner(ctx context.Context, owner, currency string) (domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByOwner", ctx, owner, currency)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByOwner indicates an expected call of GetAccountByOwner.
+func (mr *MockAccountMockRecorder) GetAccountByOwner(ctx, owner, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByOwner", reflect.TypeOf((*MockAccount)(nil).GetAccountByOwner), ctx, owner, currency)
+}
+
 // GetAccountForUpdate mocks base method.
 func (m *MockAccount) GetAccountForUpdate(ctx context.Context, id int) (domain.Account, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +126,21 @@ func (mr *MockAccountMockRecorder) GetAccountForUpdate(ctx, id interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockAccount)(nil).GetAccountForUpdate), ctx, id)
 }
 
+// ListAccountStatusChanges mocks base method.
+func (m *MockAccount) ListAccountStatusChanges(ctx context.Context, arg domain.ListAccountStatusChangesParams) ([]domain.AccountStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountStatusChanges", ctx, arg)
+	ret0, _ := ret[0].([]domain.AccountStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountStatusChanges indicates an expected call of ListAccountStatusChanges.
+func (mr *MockAccountMockRecorder) ListAccountStatusChanges(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountStatusChanges", reflect.TypeOf((*MockAccount)(nil).ListAccountStatusChanges), ctx, arg)
+}
+
 // ListAccounts mocks base method.
 func (m *MockAccount) ListAccounts(ctx context.Context, arg domain.ListAccountsParams) ([]domain.Account, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +156,56 @@ func (mr *MockAccountMockRecorder) ListAccounts(ctx, arg interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockAccount)(nil).ListAccounts), ctx, arg)
 }
 
+// LockAccounts mocks base method.
+func (m *MockAccount) LockAccounts(ctx context.Context, ids ...int) ([]domain.Account, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "LockAccounts", varargs...)
+	ret0, _ := ret[0].([]domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockAccounts indicates an expected call of LockAccounts.
+func (mr *MockAccountMockRecorder) LockAccounts(ctx interface{}, ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAccounts", reflect.TypeOf((*MockAccount)(nil).LockAccounts), varargs...)
+}
+
+// SetAccountStatus mocks base method.
+func (m *MockAccount) SetAccountStatus(ctx context.Context, id int, status string) (domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountStatus", ctx, id, status)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAccountStatus indicates an expected call of SetAccountStatus.
+func (mr *MockAccountMockRecorder) SetAccountStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountStatus", reflect.TypeOf((*MockAccount)(nil).SetAccountStatus), ctx, id, status)
+}
+
+// SetOverdraftLimit mocks base method.
+func (m *MockAccount) SetOverdraftLimit(ctx context.Context, arg domain.SetOverdraftLimitParams) (domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOverdraftLimit", ctx, arg)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOverdraftLimit indicates an expected call of SetOverdraftLimit.
+func (mr *MockAccountMockRecorder) SetOverdraftLimit(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOverdraftLimit", reflect.TypeOf((*MockAccount)(nil).SetOverdraftLimit), ctx, arg)
+}
+
 // UpdateAccount mocks base method.
 func (m *MockAccount) UpdateAccount(ctx context.Context, arg domain.UpdateAccountParams) (domain.Account, error) {
 	m.ctrl.T.Helper()
@@ -192,6 +274,21 @@ func (mr *MockEntryMockRecorder) GetEntry(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockEntry)(nil).GetEntry), ctx, id)
 }
 
+// GetStatement mocks base method.
+func (m *MockEntry) GetStatement(ctx context.Context, arg domain.StatementParams) (domain.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatement", ctx, arg)
+	ret0, _ := ret[0].(domain.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatement indicates an expected call of GetStatement.
+func (mr *MockEntryMockRecorder) GetStatement(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatement", reflect.TypeOf((*MockEntry)(nil).GetStatement), ctx, arg)
+}
+
 // ListEntries mocks base method.
 func (m *MockEntry) ListEntries(ctx context.Context, arg domain.ListEntriesParams) ([]domain.Entry, error) {
 	m.ctrl.T.Helper()
@@ -207,6 +304,20 @@ func (mr *MockEntryMockRecorder) ListEntries(ctx, arg interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockEntry)(nil).ListEntries), ctx, arg)
 }
 
+// StreamStatement mocks base method.
+func (m *MockEntry) StreamStatement(ctx context.Context, arg domain.StatementParams, begin func(domain.Statement) error, entry func(domain.StatementEntry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamStatement", ctx, arg, begin, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamStatement indicates an expected call of StreamStatement.
+func (mr *MockEntryMockRecorder) StreamStatement(ctx, arg, begin, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamStatement", reflect.TypeOf((*MockEntry)(nil).StreamStatement), ctx, arg, begin, entry)
+}
+
 // MockTransfer is a mock of Transfer interface.
 type MockTransfer struct {
 	ctrl     *gomock.Controller
@@ -245,6 +356,21 @@ func (mr *MockTransferMockRecorder) CreateTransfer(ctx, arg interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockTransfer)(nil).CreateTransfer), ctx, arg)
 }
 
+// GetReversedTotals mocks base method.
+func (m *MockTransfer) GetReversedTotals(ctx context.Context, id int) (domain.ReversedTotals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReversedTotals", ctx, id)
+	ret0, _ := ret[0].(domain.ReversedTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReversedTotals indicates an expected call of GetReversedTotals.
+func (mr *MockTransferMockRecorder) GetReversedTotals(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReversedTotals", reflect.TypeOf((*MockTransfer)(nil).GetReversedTotals), ctx, id)
+}
+
 // GetTransfer mocks base method.
 func (m *MockTransfer) GetTransfer(ctx context.Context, id int) (domain.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -260,6 +386,21 @@ func (mr *MockTransferMockRecorder) GetTransfer(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockTransfer)(nil).GetTransfer), ctx, id)
 }
 
+// GetTransferForUpdate mocks base method.
+func (m *MockTransfer) GetTransferForUpdate(ctx context.Context, id int) (domain.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferForUpdate", ctx, id)
+	ret0, _ := ret[0].(domain.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferForUpdate indicates an expected call of GetTransferForUpdate.
+func (mr *MockTransferMockRecorder) GetTransferForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferForUpdate", reflect.TypeOf((*MockTransfer)(nil).GetTransferForUpdate), ctx, id)
+}
+
 // ListTransfers mocks base method.
 func (m *MockTransfer) ListTransfers(ctx context.Context, arg domain.ListTransfersParams) ([]domain.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -328,40 +469,853 @@ func (mr *MockUserMockRecorder) GetUser(ctx, username interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUser)(nil).GetUser), ctx, username)
 }
 
-// MockTx is a mock of Tx interface.
-type MockTx struct {
+// MockSession is a mock of Session interface.
+type MockSession struct {
 	ctrl     *gomock.Controller
-	recorder *MockTxMockRecorder
+	recorder *MockSessionMockRecorder
 }
 
-// MockTxMockRecorder is the mock recorder for MockTx.
-type MockTxMockRecorder struct {
-	mock *MockTx
+// MockSessionMockRecorder is the mock recorder for MockSession.
+type MockSessionMockRecorder struct {
+	mock *MockSession
 }
 
-// NewMockTx creates a new mock instance.
-func NewMockTx(ctrl *gomock.Controller) *MockTx {
-	mock := &MockTx{ctrl: ctrl}
-	mock.recorder = &MockTxMockRecorder{mock}
+// NewMockSession creates a new mock instance.
+func NewMockSession(ctrl *gomock.Controller) *MockSession {
+	mock := &MockSession{ctrl: ctrl}
+	mock.recorder = &MockSessionMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockTx) EXPECT() *MockTxMockRecorder {
+func (m *MockSession) EXPECT() *MockSessionMockRecorder {
 	return m.recorder
 }
 
-// TransferTx mocks base method.
-func (m *MockTx) TransferTx(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxResult, error) {
+// BlockSession mocks base method.
+func (m *MockSession) BlockSession(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransferTx", ctx, arg)
-	ret0, _ := ret[0].(domain.TransferTxResult)
+	ret := m.ctrl.Call(m, "BlockSession", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockSessionMockRecorder) BlockSession(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockSession)(nil).BlockSession), ctx, id)
+}
+
+// CreateSession mocks base method.
+func (m *MockSession) CreateSession(ctx context.Context, arg domain.CreateSessionParams) (domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, arg)
+	ret0, _ := ret[0].(domain.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// TransferTx indicates an expected call of TransferTx.
-func (mr *MockTxMockRecorder) TransferTx(ctx, arg interface{}) *gomock.Call {
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockSessionMockRecorder) CreateSession(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockTx)(nil).TransferTx), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSession)(nil).CreateSession), ctx, arg)
+}
+
+// GetSessionByRefreshToken mocks base method.
+func (m *MockSession) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionByRefreshToken", ctx, refreshTokenHash)
+	ret0, _ := ret[0].(domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionByRefreshToken indicates an expected call of GetSessionByRefreshToken.
+func (mr *MockSessionMockRecorder) GetSessionByRefreshToken(ctx, refreshTokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionByRefreshToken", reflect.TypeOf((*MockSession)(nil).GetSessionByRefreshToken), ctx, refreshTokenHash)
+}
+
+// RotateSession mocks base method.
+func (m *MockSession) RotateSession(ctx context.Context, arg domain.RotateSessionParams) (domain.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSession", ctx, arg)
+	ret0, _ := ret[0].(domain.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSession indicates an expected call of RotateSession.
+func (mr *MockSessionMockRecorder) RotateSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSession", reflect.TypeOf((*MockSession)(nil).RotateSession), ctx, arg)
+}
+
+// MockIdempotency is a mock of Idempotency interface.
+type MockIdempotency struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyMockRecorder
+}
+
+// MockIdempotencyMockRecorder is the mock recorder for MockIdempotency.
+type MockIdempotencyMockRecorder struct {
+	mock *MockIdempotency
+}
+
+// NewMockIdempotency creates a new mock instance.
+func NewMockIdempotency(ctrl *gomock.Controller) *MockIdempotency {
+	mock := &MockIdempotency{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotency) EXPECT() *MockIdempotencyMockRecorder {
+	return m.recorder
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockIdempotency) CreateIdempotencyKey(ctx context.Context, arg domain.CreateIdempotencyKeyParams) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockIdempotencyMockRecorder) CreateIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockIdempotency)(nil).CreateIdempotencyKey), ctx, arg)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockIdempotency) DeleteExpiredIdempotencyKeys(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockIdempotencyMockRecorder) DeleteExpiredIdempotencyKeys(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockIdempotency)(nil).DeleteExpiredIdempotencyKeys), ctx, limit)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockIdempotency) GetIdempotencyKey(ctx context.Context, owner, key string) (domain.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, owner, key)
+	ret0, _ := ret[0].(domain.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockIdempotencyMockRecorder) GetIdempotencyKey(ctx, owner, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockIdempotency)(nil).GetIdempotencyKey), ctx, owner, key)
+}
+
+// UpdateIdempotencyKey mocks base method.
+func (m *MockIdempotency) UpdateIdempotencyKey(ctx context.Context, arg domain.UpdateIdempotencyKeyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIdempotencyKey indicates an expected call of UpdateIdempotencyKey.
+func (mr *MockIdempotencyMockRecorder) UpdateIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIdempotencyKey", reflect.TypeOf((*MockIdempotency)(nil).UpdateIdempotencyKey), ctx, arg)
+}
+
+// MockScheduledTransfer is a mock of ScheduledTransfer interface.
+type MockScheduledTransfer struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledTransferMockRecorder
+}
+
+// MockScheduledTransferMockRecorder is the mock recorder for MockScheduledTransfer.
+type MockScheduledTransferMockRecorder struct {
+	mock *MockScheduledTransfer
+}
+
+// NewMockScheduledTransfer creates a new mock instance.
+func NewMockScheduledTransfer(ctrl *gomock.Controller) *MockScheduledTransfer {
+	mock := &MockScheduledTransfer{ctrl: ctrl}
+	mock.recorder = &MockScheduledTransferMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledTransfer) EXPECT() *MockScheduledTransferMockRecorder {
+	return m.recorder
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) CreateScheduledTransfer(ctx context.Context, arg domain.CreateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) CreateScheduledTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).CreateScheduledTransfer), ctx, arg)
+}
+
+// CreateScheduledTransferRun mocks base method.
+func (m *MockScheduledTransfer) CreateScheduledTransferRun(ctx context.Context, arg domain.CreateScheduledTransferRunParams) (domain.ScheduledTransferRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransferRun", ctx, arg)
+	ret0, _ := ret[0].(domain.ScheduledTransferRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransferRun indicates an expected call of CreateScheduledTransferRun.
+func (mr *MockScheduledTransferMockRecorder) CreateScheduledTransferRun(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransferRun", reflect.TypeOf((*MockScheduledTransfer)(nil).CreateScheduledTransferRun), ctx, arg)
+}
+
+// DeleteScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) DeleteScheduledTransfer(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteScheduledTransfer indicates an expected call of DeleteScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) DeleteScheduledTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).DeleteScheduledTransfer), ctx, id)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) GetScheduledTransfer(ctx context.Context, id int) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) GetScheduledTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).GetScheduledTransfer), ctx, id)
+}
+
+// ListDueScheduledTransfers mocks base method.
+func (m *MockScheduledTransfer) ListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueScheduledTransfers", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueScheduledTransfers indicates an expected call of ListDueScheduledTransfers.
+func (mr *MockScheduledTransferMockRecorder) ListDueScheduledTransfers(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueScheduledTransfers", reflect.TypeOf((*MockScheduledTransfer)(nil).ListDueScheduledTransfers), ctx, now, limit)
+}
+
+// ListScheduledTransferRuns mocks base method.
+func (m *MockScheduledTransfer) ListScheduledTransferRuns(ctx context.Context, arg domain.ListScheduledTransferRunsParams) ([]domain.ScheduledTransferRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransferRuns", ctx, arg)
+	ret0, _ := ret[0].([]domain.ScheduledTransferRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransferRuns indicates an expected call of ListScheduledTransferRuns.
+func (mr *MockScheduledTransferMockRecorder) ListScheduledTransferRuns(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransferRuns", reflect.TypeOf((*MockScheduledTransfer)(nil).ListScheduledTransferRuns), ctx, arg)
+}
+
+// ListScheduledTransfers mocks base method.
+func (m *MockScheduledTransfer) ListScheduledTransfers(ctx context.Context, arg domain.ListScheduledTransfersParams) ([]domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", ctx, arg)
+	ret0, _ := ret[0].([]domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockScheduledTransferMockRecorder) ListScheduledTransfers(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockScheduledTransfer)(nil).ListScheduledTransfers), ctx, arg)
+}
+
+// SetNextRunAt mocks base method.
+func (m *MockScheduledTransfer) SetNextRunAt(ctx context.Context, id int, nextRunAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNextRunAt", ctx, id, nextRunAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNextRunAt indicates an expected call of SetNextRunAt.
+func (mr *MockScheduledTransferMockRecorder) SetNextRunAt(ctx, id, nextRunAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNextRunAt", reflect.TypeOf((*MockScheduledTransfer)(nil).SetNextRunAt), ctx, id, nextRunAt)
+}
+
+// UpdateScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) UpdateScheduledTransfer(ctx context.Context, arg domain.UpdateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransfer indicates an expected call of UpdateScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) UpdateScheduledTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).UpdateScheduledTransfer), ctx, arg)
+}
+
+// MockOutbox is a mock of Outbox interface.
+type MockOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxMockRecorder
+}
+
+// MockOutboxMockRecorder is the mock recorder for MockOutbox.
+type MockOutboxMockRecorder struct {
+	mock *MockOutbox
+}
+
+// NewMockOutbox creates a new mock instance.
+func NewMockOutbox(ctrl *gomock.Controller) *MockOutbox {
+	mock := &MockOutbox{ctrl: ctrl}
+	mock.recorder = &MockOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutbox) EXPECT() *MockOutboxMockRecorder {
+	return m.recorder
+}
+
+// CreateOutboxEvent mocks base method.
+func (m *MockOutbox) CreateOutboxEvent(ctx context.Context, arg domain.CreateOutboxEventParams) (domain.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxEvent", ctx, arg)
+	ret0, _ := ret[0].(domain.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOutboxEvent indicates an expected call of CreateOutboxEvent.
+func (mr *MockOutboxMockRecorder) CreateOutboxEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxEvent", reflect.TypeOf((*MockOutbox)(nil).CreateOutboxEvent), ctx, arg)
+}
+
+// ListUnpublishedOutboxEvents mocks base method.
+func (m *MockOutbox) ListUnpublishedOutboxEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnpublishedOutboxEvents", ctx, limit)
+	ret0, _ := ret[0].([]domain.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnpublishedOutboxEvents indicates an expected call of ListUnpublishedOutboxEvents.
+func (mr *MockOutboxMockRecorder) ListUnpublishedOutboxEvents(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnpublishedOutboxEvents", reflect.TypeOf((*MockOutbox)(nil).ListUnpublishedOutboxEvents), ctx, limit)
+}
+
+// MarkOutboxEventsPublished mocks base method.
+func (m *MockOutbox) MarkOutboxEventsPublished(ctx context.Context, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventsPublished", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventsPublished indicates an expected call of MarkOutboxEventsPublished.
+func (mr *MockOutboxMockRecorder) MarkOutboxEventsPublished(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventsPublished", reflect.TypeOf((*MockOutbox)(nil).MarkOutboxEventsPublished), ctx, ids)
+}
+
+// TryLockOutboxRelay mocks base method.
+func (m *MockOutbox) TryLockOutboxRelay(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLockOutboxRelay", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryLockOutboxRelay indicates an expected call of TryLockOutboxRelay.
+func (mr *MockOutboxMockRecorder) TryLockOutboxRelay(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLockOutboxRelay", reflect.TypeOf((*MockOutbox)(nil).TryLockOutboxRelay), ctx)
+}
+
+// MockWebhook is a mock of Webhook interface.
+type MockWebhook struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookMockRecorder
+}
+
+// MockWebhookMockRecorder is the mock recorder for MockWebhook.
+type MockWebhookMockRecorder struct {
+	mock *MockWebhook
+}
+
+// NewMockWebhook creates a new mock instance.
+func NewMockWebhook(ctrl *gomock.Controller) *MockWebhook {
+	mock := &MockWebhook{ctrl: ctrl}
+	mock.recorder = &MockWebhookMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhook) EXPECT() *MockWebhookMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockWebhook) CreateWebhookSubscription(ctx context.Context, arg domain.CreateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, arg)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockWebhookMockRecorder) CreateWebhookSubscription(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).CreateWebhookSubscription), ctx, arg)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockWebhook) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockWebhookMockRecorder) DeleteWebhookSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// EnqueueWebhookDeliveries mocks base method.
+func (m *MockWebhook) EnqueueWebhookDeliveries(ctx context.Context, event domain.OutboxEvent) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueWebhookDeliveries", ctx, event)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueWebhookDeliveries indicates an expected call of EnqueueWebhookDeliveries.
+func (mr *MockWebhookMockRecorder) EnqueueWebhookDeliveries(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueWebhookDeliveries", reflect.TypeOf((*MockWebhook)(nil).EnqueueWebhookDeliveries), ctx, event)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockWebhook) GetWebhookSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockWebhookMockRecorder) GetWebhookSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).GetWebhookSubscription), ctx, id)
+}
+
+// ListDueWebhookDeliveries mocks base method.
+func (m *MockWebhook) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.DueWebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueWebhookDeliveries", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.DueWebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueWebhookDeliveries indicates an expected call of ListDueWebhookDeliveries.
+func (mr *MockWebhookMockRecorder) ListDueWebhookDeliveries(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueWebhookDeliveries", reflect.TypeOf((*MockWebhook)(nil).ListDueWebhookDeliveries), ctx, now, limit)
+}
+
+// LeaseWebhookDeliveries mocks base method.
+func (m *MockWebhook) LeaseWebhookDeliveries(ctx context.Context, ids []int, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaseWebhookDeliveries", ctx, ids, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LeaseWebhookDeliveries indicates an expected call of LeaseWebhookDeliveries.
+func (mr *MockWebhookMockRecorder) LeaseWebhookDeliveries(ctx, ids, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaseWebhookDeliveries", reflect.TypeOf((*MockWebhook)(nil).LeaseWebhookDeliveries), ctx, ids, until)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockWebhook) ListWebhookDeliveries(ctx context.Context, arg domain.ListWebhookDeliveriesParams) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockWebhookMockRecorder) ListWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockWebhook)(nil).ListWebhookDeliveries), ctx, arg)
+}
+
+// ListWebhookSubscriptions mocks base method.
+func (m *MockWebhook) ListWebhookSubscriptions(ctx context.Context, arg domain.ListWebhookSubscriptionsParams) ([]domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx, arg)
+	ret0, _ := ret[0].([]domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockWebhookMockRecorder) ListWebhookSubscriptions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockWebhook)(nil).ListWebhookSubscriptions), ctx, arg)
+}
+
+// RecordWebhookAttempt mocks base method.
+func (m *MockWebhook) RecordWebhookAttempt(ctx context.Context, id int, attempt domain.WebhookAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookAttempt", ctx, id, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordWebhookAttempt indicates an expected call of RecordWebhookAttempt.
+func (mr *MockWebhookMockRecorder) RecordWebhookAttempt(ctx, id, attempt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookAttempt", reflect.TypeOf((*MockWebhook)(nil).RecordWebhookAttempt), ctx, id, attempt)
+}
+
+// UpdateWebhookSubscription mocks base method.
+func (m *MockWebhook) UpdateWebhookSubscription(ctx context.Context, arg domain.UpdateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookSubscription", ctx, arg)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookSubscription indicates an expected call of UpdateWebhookSubscription.
+func (mr *MockWebhookMockRecorder) UpdateWebhookSubscription(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).UpdateWebhookSubscription), ctx, arg)
+}
+
+// MockAudit is a mock of Audit interface.
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit.
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance.
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockAudit) CreateAuditEvent(ctx context.Context, arg domain.CreateAuditEventParams) (domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, arg)
+	ret0, _ := ret[0].(domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockAuditMockRecorder) CreateAuditEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockAudit)(nil).CreateAuditEvent), ctx, arg)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAudit) ListAuditEvents(ctx context.Context, arg domain.ListAuditEventsParams) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuditMockRecorder) ListAuditEvents(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAudit)(nil).ListAuditEvents), ctx, arg)
+}
+
+// MockLedger is a mock of Ledger interface.
+type MockLedger struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerMockRecorder
+}
+
+// MockLedgerMockRecorder is the mock recorder for MockLedger.
+type MockLedgerMockRecorder struct {
+	mock *MockLedger
+}
+
+// NewMockLedger creates a new mock instance.
+func NewMockLedger(ctrl *gomock.Controller) *MockLedger {
+	mock := &MockLedger{ctrl: ctrl}
+	mock.recorder = &MockLedgerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedger) EXPECT() *MockLedgerMockRecorder {
+	return m.recorder
+}
+
+// CheckAccountBalances mocks base method.
+func (m *MockLedger) CheckAccountBalances(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAccountBalances", ctx, arg)
+	ret0, _ := ret[0].(domain.LedgerBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckAccountBalances indicates an expected call of CheckAccountBalances.
+func (mr *MockLedgerMockRecorder) CheckAccountBalances(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAccountBalances", reflect.TypeOf((*MockLedger)(nil).CheckAccountBalances), ctx, arg)
+}
+
+// CheckTransferEntries mocks base method.
+func (m *MockLedger) CheckTransferEntries(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckTransferEntries", ctx, arg)
+	ret0, _ := ret[0].(domain.LedgerBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckTransferEntries indicates an expected call of CheckTransferEntries.
+func (mr *MockLedgerMockRecorder) CheckTransferEntries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckTransferEntries", reflect.TypeOf((*MockLedger)(nil).CheckTransferEntries), ctx, arg)
+}
+
+// MockTx is a mock of Tx interface.
+type MockTx struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxMockRecorder
+}
+
+// MockTxMockRecorder is the mock recorder for MockTx.
+type MockTxMockRecorder struct {
+	mock *MockTx
+}
+
+// NewMockTx creates a new mock instance.
+func NewMockTx(ctrl *gomock.Controller) *MockTx {
+	mock := &MockTx{ctrl: ctrl}
+	mock.recorder = &MockTxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTx) EXPECT() *MockTxMockRecorder {
+	return m.recorder
+}
+
+// BatchTransferTx mocks base method.
+func (m *MockTx) BatchTransferTx(ctx context.Context, transfers []domain.TransferTxParams) ([]domain.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchTransferTx", ctx, transfers)
+	ret0, _ := ret[0].([]domain.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchTransferTx indicates an expected call of BatchTransferTx.
+func (mr *MockTxMockRecorder) BatchTransferTx(ctx, transfers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchTransferTx", reflect.TypeOf((*MockTx)(nil).BatchTransferTx), ctx, transfers)
+}
+
+// ChangeAccountStatusTx mocks base method.
+func (m *MockTx) ChangeAccountStatusTx(ctx context.Context, arg domain.ChangeAccountStatusParams) (domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeAccountStatusTx", ctx, arg)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeAccountStatusTx indicates an expected call of ChangeAccountStatusTx.
+func (mr *MockTxMockRecorder) ChangeAccountStatusTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountStatusTx", reflect.TypeOf((*MockTx)(nil).ChangeAccountStatusTx), ctx, arg)
+}
+
+// CreateAccountTx mocks base method.
+func (m *MockTx) CreateAccountTx(ctx context.Context, arg domain.CreateAccountParams) (domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountTx", ctx, arg)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountTx indicates an expected call of CreateAccountTx.
+func (mr *MockTxMockRecorder) CreateAccountTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountTx", reflect.TypeOf((*MockTx)(nil).CreateAccountTx), ctx, arg)
+}
+
+// DepositTx mocks base method.
+func (m *MockTx) DepositTx(ctx context.Context, arg domain.CashTxParams) (domain.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepositTx", ctx, arg)
+	ret0, _ := ret[0].(domain.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepositTx indicates an expected call of DepositTx.
+func (mr *MockTxMockRecorder) DepositTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositTx", reflect.TypeOf((*MockTx)(nil).DepositTx), ctx, arg)
+}
+
+// ProcessDueScheduledTransfers mocks base method.
+func (m *MockTx) ProcessDueScheduledTransfers(ctx context.Context, now time.Time, limit int, run func(context.Context, domain.ScheduledTransfer) domain.ScheduledRunOutcome) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessDueScheduledTransfers", ctx, now, limit, run)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessDueScheduledTransfers indicates an expected call of ProcessDueScheduledTransfers.
+func (mr *MockTxMockRecorder) ProcessDueScheduledTransfers(ctx, now, limit, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessDueScheduledTransfers", reflect.TypeOf((*MockTx)(nil).ProcessDueScheduledTransfers), ctx, now, limit, run)
+}
+
+// ProcessDueWebhookDeliveries mocks base method.
+func (m *MockTx) ProcessDueWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration, deliver func(context.Context, domain.DueWebhookDelivery) domain.WebhookAttempt) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessDueWebhookDeliveries", ctx, now, limit, lease, deliver)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessDueWebhookDeliveries indicates an expected call of ProcessDueWebhookDeliveries.
+func (mr *MockTxMockRecorder) ProcessDueWebhookDeliveries(ctx, now, limit, lease, deliver interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessDueWebhookDeliveries", reflect.TypeOf((*MockTx)(nil).ProcessDueWebhookDeliveries), ctx, now, limit, lease, deliver)
+}
+
+// RelayOutboxEvents mocks base method.
+func (m *MockTx) RelayOutboxEvents(ctx context.Context, limit int, publish func(context.Context, domain.OutboxEvent) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelayOutboxEvents", ctx, limit, publish)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelayOutboxEvents indicates an expected call of RelayOutboxEvents.
+func (mr *MockTxMockRecorder) RelayOutboxEvents(ctx, limit, publish interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelayOutboxEvents", reflect.TypeOf((*MockTx)(nil).RelayOutboxEvents), ctx, limit, publish)
+}
+
+// ReverseTransferTx mocks base method.
+func (m *MockTx) ReverseTransferTx(ctx context.Context, arg domain.ReverseTransferParams) (domain.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransferTx", ctx, arg)
+	ret0, _ := ret[0].(domain.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockTxMockRecorder) ReverseTransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockTx)(nil).ReverseTransferTx), ctx, arg)
+}
+
+// TransferTx mocks base method.
+func (m *MockTx) TransferTx(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferTx", ctx, arg)
+	ret0, _ := ret[0].(domain.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferTx indicates an expected call of TransferTx.
+func (mr *MockTxMockRecorder) TransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockTx)(nil).TransferTx), ctx, arg)
+}
+
+// WithdrawTx mocks base method.
+func (m *MockTx) WithdrawTx(ctx context.Context, arg domain.CashTxParams) (domain.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawTx", ctx, arg)
+	ret0, _ := ret[0].(domain.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawTx indicates an expected call of WithdrawTx.
+func (mr *MockTxMockRecorder) WithdrawTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawTx", reflect.TypeOf((*MockTx)(nil).WithdrawTx), ctx, arg)
 }
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+// CreateAccountTx creates the account and records an account.created event
+// in the same transaction.
+func (r *Repository) CreateAccountTx(ctx context.Context, arg domain.CreateAccountParams) (domain.Account, error) {
+	var account domain.Account
+
+	_, err := r.execTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(q *Repository) error {
+		var err error
+
+		account, err = q.Account.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return writeOutboxEvent(ctx, q, account.ID, domain.EventAccountCreated, account)
+	})
+
+	return account, err
+}
+
+// RelayOutboxEvents hands up to limit unpublished events to publish, oldest
+// first, and marks the ones that went out as published. Once publishing an
+// event fails, the later events of the same account are held back so they
+// are never delivered ahead of it. Events are delivered at least once: if
+// the mark doesn't commit they are published again by the next call.
+//
+// Only one relay runs at a time, a call that finds another one busy returns
+// right away. The first publishing error is returned after the events that
+// did go out were marked.
+func (r *Repository) RelayOutboxEvents(ctx context.Context, limit int, publish func(ctx context.Context, event domain.OutboxEvent) error) (int, error) {
+	var published int
+	var publishErr error
+
+	_, err := r.execTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(q *Repository) error {
+		published, publishErr = 0, nil
+
+		locked, err := q.Outbox.TryLockOutboxRelay(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		events, err := q.Outbox.ListUnpublishedOutboxEvents(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		held := make(map[int]bool)
+		ids := make([]int64, 0, len(events))
+		for _, event := range events {
+			if held[event.AccountID] {
+				continue
+			}
+
+			if err := publish(ctx, event); err != nil {
+				held[event.AccountID] = true
+				if publishErr == nil {
+					publishErr = err
+				}
+				continue
+			}
+			ids = append(ids, event.ID)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+		published = len(ids)
+		return q.Outbox.MarkOutboxEventsPublished(ctx, ids)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, publishErr
+}
+
+// writeOutboxEvent records the event and queues it for the webhooks that
+// subscribed to it, so both commit together with the change they describe.
+func writeOutboxEvent(ctx context.Context, q *Repository, accountID int, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	event, err := q.Outbox.CreateOutboxEvent(ctx, domain.CreateOutboxEventParams{
+		AccountID: accountID,
+		EventType: eventType,
+		Payload:   data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Webhook.EnqueueWebhookDeliveries(ctx, event)
+	return err
+}
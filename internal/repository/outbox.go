@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/lib/pq"
+)
+
+// outboxRelayLockKey is the advisory lock that lets a single relay publish
+// at a time, which keeps the events of an account in order.
+const outboxRelayLockKey = 0x6f7574626f78 // "outbox"
+
+type OutboxRepo struct {
+	db DBTX
+}
+
+func NewOutboxRepo(db DBTX) *OutboxRepo {
+	return &OutboxRepo{
+		db: db,
+	}
+}
+
+func (r *OutboxRepo) CreateOutboxEvent(ctx context.Context, arg domain.CreateOutboxEventParams) (domain.OutboxEvent, error) {
+	stmt := `INSERT INTO outbox_events (
+		account_id,
+		event_type,
+		payload
+	) VALUES (
+		$1, $2, $3
+	) RETURNING id, account_id, event_type, payload, created_at, published_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.AccountID, arg.EventType, []byte(arg.Payload))
+	var i domain.OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.EventType,
+		&i.Payload,
+		&i.CreatedAt,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+// TryLockOutboxRelay takes the relay lock until the end of the transaction.
+// It returns false when another relay holds it.
+func (r *OutboxRepo) TryLockOutboxRelay(ctx context.Context) (bool, error) {
+	if _, ok := r.db.(txBeginner); ok {
+		return false, e.ErrLockOutsideTx
+	}
+
+	var locked bool
+	err := r.db.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLockKey).Scan(&locked)
+	return locked, err
+}
+
+// ListUnpublishedOutboxEvents returns the oldest events that haven't been
+// published yet, in the order they were written.
+func (r *OutboxRepo) ListUnpublishedOutboxEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error) {
+	stmt := `SELECT id, account_id, event_type, payload, created_at, published_at FROM outbox_events
+	WHERE published_at IS NULL
+	ORDER BY id
+	LIMIT $1`
+	rows, err := r.db.QueryContext(ctx, stmt, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.OutboxEvent
+	for rows.Next() {
+		var i domain.OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *OutboxRepo) MarkOutboxEventsPublished(ctx context.Context, ids []int64) error {
+	stmt := `UPDATE outbox_events SET published_at = now() WHERE id = ANY($1)`
+	_, err := r.db.ExecContext(ctx, stmt, pq.Array(ids))
+	return err
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAccountTxWritesEvent(t *testing.T) {
+	store := NewRepository(db)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(ctx, domain.CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	events := pendingEvents(t, account.ID)
+	require.Len(t, events, 1)
+	require.Equal(t, domain.EventAccountCreated, events[0].EventType)
+
+	var payload domain.Account
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, account.ID, payload.ID)
+}
+
+func TestTransferTxWritesEvents(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 100)
+	account2 := createAccountWithBalance(t, 100)
+
+	result, err := store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	for _, account := range []domain.Account{account1, account2} {
+		events := pendingEvents(t, account.ID)
+		require.Len(t, events, 1)
+		require.Equal(t, domain.EventTransferCompleted, events[0].EventType)
+
+		var payload domain.Transfer
+		require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+		require.Equal(t, result.Transfer.ID, payload.ID)
+	}
+
+	// a failed transfer leaves no event behind
+	_, err = store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1000,
+	})
+	require.Error(t, err)
+	require.Len(t, pendingEvents(t, account1.ID), 1)
+}
+
+func TestRelayOutboxEventsKeepsAccountOrder(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 100)
+	account2 := createAccountWithBalance(t, 100)
+	account3 := createAccountWithBalance(t, 100)
+
+	for i := 0; i < 3; i++ {
+		_, err := store.TransferTx(ctx, domain.TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1})
+		require.NoError(t, err)
+		_, err = store.TransferTx(ctx, domain.TransferTxParams{FromAccountID: account3.ID, ToAccountID: account2.ID, Amount: 1})
+		require.NoError(t, err)
+	}
+
+	ours := map[int]bool{account1.ID: true, account2.ID: true, account3.ID: true}
+	published := make(map[int][]int64)
+	failAccount1 := true
+	publish := func(ctx context.Context, event domain.OutboxEvent) error {
+		if !ours[event.AccountID] {
+			return nil
+		}
+		if event.AccountID == account1.ID && failAccount1 {
+			return fmt.Errorf("broker is down")
+		}
+		published[event.AccountID] = append(published[event.AccountID], event.ID)
+		return nil
+	}
+
+	for {
+		n, err := store.RelayOutboxEvents(ctx, 100, publish)
+		require.ErrorContains(t, err, "broker is down")
+		if n == 0 {
+			break
+		}
+	}
+
+	// account1 is held back, the others went out in order
+	require.Empty(t, published[account1.ID])
+	require.Len(t, pendingEvents(t, account1.ID), 3)
+	require.Len(t, published[account2.ID], 6)
+	require.IsIncreasing(t, published[account2.ID])
+	require.Len(t, published[account3.ID], 3)
+	require.Empty(t, pendingEvents(t, account2.ID))
+
+	failAccount1 = false
+	for {
+		n, err := store.RelayOutboxEvents(ctx, 100, publish)
+		require.NoError(t, err)
+		if n == 0 {
+			break
+		}
+	}
+
+	require.Len(t, published[account1.ID], 3)
+	require.IsIncreasing(t, published[account1.ID])
+	require.Len(t, published[account2.ID], 6)
+	require.Empty(t, pendingEvents(t, account1.ID))
+}
+
+func pendingEvents(t *testing.T, accountID int) []domain.OutboxEvent {
+	rows, err := db.QueryContext(ctx, `SELECT id, event_type, payload FROM outbox_events
+	WHERE account_id = $1 AND published_at IS NULL
+	ORDER BY id`, accountID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		event := domain.OutboxEvent{AccountID: accountID}
+		require.NoError(t, rows.Scan(&event.ID, &event.EventType, &event.Payload))
+		events = append(events, event)
+	}
+	require.NoError(t, rows.Err())
+	return events
+}
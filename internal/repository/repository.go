@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/begenov/backend/internal/domain"
 )
@@ -10,23 +11,32 @@ import (
 //go:generate mockgen -source=repository.go -destination=mocks/mock.go
 type Account interface {
 	CreateAccount(ctx context.Context, arg domain.CreateAccountParams) (domain.Account, error)
-	DeleteAccount(ctx context.Context, id int) error
 	GetAccount(ctx context.Context, id int) (domain.Account, error)
+	GetAccountByOwner(ctx context.Context, owner string, currency string) (domain.Account, error)
 	ListAccounts(ctx context.Context, arg domain.ListAccountsParams) ([]domain.Account, error)
 	UpdateAccount(ctx context.Context, arg domain.UpdateAccountParams) (domain.Account, error)
 	GetAccountForUpdate(ctx context.Context, id int) (domain.Account, error)
+	LockAccounts(ctx context.Context, ids ...int) ([]domain.Account, error)
 	AddAccountBalance(ctx context.Context, arg domain.AddAccountBalanceParams) (domain.Account, error)
+	SetOverdraftLimit(ctx context.Context, arg domain.SetOverdraftLimitParams) (domain.Account, error)
+	SetAccountStatus(ctx context.Context, id int, status string) (domain.Account, error)
+	CreateAccountStatusChange(ctx context.Context, arg domain.CreateAccountStatusChangeParams) (domain.AccountStatusChange, error)
+	ListAccountStatusChanges(ctx context.Context, arg domain.ListAccountStatusChangesParams) ([]domain.AccountStatusChange, error)
 }
 
 type Entry interface {
 	CreateEntry(ctx context.Context, arg domain.CreateEntryParams) (domain.Entry, error)
 	GetEntry(ctx context.Context, id int) (domain.Entry, error)
 	ListEntries(ctx context.Context, arg domain.ListEntriesParams) ([]domain.Entry, error)
+	GetStatement(ctx context.Context, arg domain.StatementParams) (domain.Statement, error)
+	StreamStatement(ctx context.Context, arg domain.StatementParams, begin func(domain.Statement) error, entry func(domain.StatementEntry) error) error
 }
 
 type Transfer interface {
 	CreateTransfer(ctx context.Context, arg domain.CreateTransferParams) (domain.Transfer, error)
 	GetTransfer(ctx context.Context, id int) (domain.Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int) (domain.Transfer, error)
+	GetReversedTotals(ctx context.Context, id int) (domain.ReversedTotals, error)
 	ListTransfers(ctx context.Context, arg domain.ListTransfersParams) ([]domain.Transfer, error)
 }
 
@@ -35,24 +45,113 @@ type User interface {
 	GetUser(ctx context.Context, username string) (domain.User, error)
 }
 
+type Session interface {
+	CreateSession(ctx context.Context, arg domain.CreateSessionParams) (domain.Session, error)
+	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error)
+	RotateSession(ctx context.Context, arg domain.RotateSessionParams) (domain.Session, error)
+	BlockSession(ctx context.Context, id int) error
+}
+
+type Idempotency interface {
+	CreateIdempotencyKey(ctx context.Context, arg domain.CreateIdempotencyKeyParams) (bool, error)
+	GetIdempotencyKey(ctx context.Context, owner, key string) (domain.IdempotencyKey, error)
+	UpdateIdempotencyKey(ctx context.Context, arg domain.UpdateIdempotencyKeyParams) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, limit int) (int, error)
+}
+
+type ScheduledTransfer interface {
+	CreateScheduledTransfer(ctx context.Context, arg domain.CreateScheduledTransferParams) (domain.ScheduledTransfer, error)
+	GetScheduledTransfer(ctx context.Context, id int) (domain.ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, arg domain.ListScheduledTransfersParams) ([]domain.ScheduledTransfer, error)
+	UpdateScheduledTransfer(ctx context.Context, arg domain.UpdateScheduledTransferParams) (domain.ScheduledTransfer, error)
+	DeleteScheduledTransfer(ctx context.Context, id int) error
+	ListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledTransfer, error)
+	SetNextRunAt(ctx context.Context, id int, nextRunAt time.Time) error
+	CreateScheduledTransferRun(ctx context.Context, arg domain.CreateScheduledTransferRunParams) (domain.ScheduledTransferRun, error)
+	ListScheduledTransferRuns(ctx context.Context, arg domain.ListScheduledTransferRunsParams) ([]domain.ScheduledTransferRun, error)
+}
+
+type Outbox interface {
+	CreateOutboxEvent(ctx context.Context, arg domain.CreateOutboxEventParams) (domain.OutboxEvent, error)
+	TryLockOutboxRelay(ctx context.Context) (bool, error)
+	ListUnpublishedOutboxEvents(ctx context.Context, limit int) ([]domain.OutboxEvent, error)
+	MarkOutboxEventsPublished(ctx context.Context, ids []int64) error
+}
+
+type Webhook interface {
+	CreateWebhookSubscription(ctx context.Context, arg domain.CreateWebhookSubscriptionParams) (domain.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, arg domain.ListWebhookSubscriptionsParams) ([]domain.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, arg domain.UpdateWebhookSubscriptionParams) (domain.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int) error
+	EnqueueWebhookDeliveries(ctx context.Context, event domain.OutboxEvent) (int, error)
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.DueWebhookDelivery, error)
+	LeaseWebhookDeliveries(ctx context.Context, ids []int, until time.Time) error
+	RecordWebhookAttempt(ctx context.Context, id int, attempt domain.WebhookAttempt) error
+	ListWebhookDeliveries(ctx context.Context, arg domain.ListWebhookDeliveriesParams) ([]domain.WebhookDelivery, error)
+}
+
+type Audit interface {
+	CreateAuditEvent(ctx context.Context, arg domain.CreateAuditEventParams) (domain.AuditEvent, error)
+	ListAuditEvents(ctx context.Context, arg domain.ListAuditEventsParams) ([]domain.AuditEvent, error)
+}
+
+type Ledger interface {
+	CheckAccountBalances(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error)
+	CheckTransferEntries(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error)
+}
+
 type Tx interface {
 	TransferTx(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxResult, error)
+	DepositTx(ctx context.Context, arg domain.CashTxParams) (domain.CashTxResult, error)
+	WithdrawTx(ctx context.Context, arg domain.CashTxParams) (domain.CashTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg domain.ReverseTransferParams) (domain.TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, transfers []domain.TransferTxParams) ([]domain.TransferTxResult, error)
+	CreateAccountTx(ctx context.Context, arg domain.CreateAccountParams) (domain.Account, error)
+	ChangeAccountStatusTx(ctx context.Context, arg domain.ChangeAccountStatusParams) (domain.Account, error)
+	RelayOutboxEvents(ctx context.Context, limit int, publish func(ctx context.Context, event domain.OutboxEvent) error) (int, error)
+	ProcessDueWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration, deliver func(ctx context.Context, delivery domain.DueWebhookDelivery) domain.WebhookAttempt) (int, error)
+	ProcessDueScheduledTransfers(ctx context.Context, now time.Time, limit int, run func(ctx context.Context, transfer domain.ScheduledTransfer) domain.ScheduledRunOutcome) (int, error)
 }
 
 type Repository struct {
-	db       *sql.DB
-	Account  Account
-	Entry    Entry
-	Transfer Transfer
-	User     User
+	db          *sql.DB
+	Account     Account
+	Entry       Entry
+	Transfer    Transfer
+	User        User
+	Session     Session
+	Idempotency Idempotency
+	Ledger      Ledger
+
+	ScheduledTransfer ScheduledTransfer
+	Outbox            Outbox
+	Webhook           Webhook
+	Audit             Audit
 }
 
 func NewRepository(db *sql.DB) *Repository {
+	r := newRepository(db)
+	r.db = db
+	return r
+}
+
+// newRepository builds the repos on top of q, which is either the pool or a
+// single transaction. Every query they run gets a span.
+func newRepository(q DBTX) *Repository {
+	q = traceDBTX(q)
 	return &Repository{
-		db:       db,
-		Account:  New(db),
-		Entry:    NewEntryRepo(db),
-		Transfer: NewTransferRepo(db),
-		User:     NewUserRepo(db),
+		Account:     New(q),
+		Entry:       NewEntryRepo(q),
+		Transfer:    NewTransferRepo(q),
+		User:        NewUserRepo(q),
+		Session:     NewSessionRepo(q),
+		Idempotency: NewIdempotencyRepo(q),
+		Ledger:      NewLedgerRepo(q),
+
+		ScheduledTransfer: NewScheduledTransferRepo(q),
+		Outbox:            NewOutboxRepo(q),
+		Webhook:           NewWebhookRepo(q),
+		Audit:             NewAuditRepo(q),
 	}
 }
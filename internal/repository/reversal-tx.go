@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"math"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+)
+
+// ReverseTransferTx books a compensating transfer that moves money from the
+// to account of the original transfer back to its from account. Partial
+// reversals are converted at the original rate and the last one takes
+// whatever is left, so the original is never reversed by more than it moved.
+func (r *Repository) ReverseTransferTx(ctx context.Context, arg domain.ReverseTransferParams) (domain.TransferTxResult, error) {
+	var result domain.TransferTxResult
+
+	retries, err := r.execTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(q *Repository) error {
+		original, err := q.Transfer.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if original.Kind != domain.TransferKindTransfer {
+			return e.ErrTransferNotReversible
+		}
+
+		reversed, err := q.Transfer.GetReversedTotals(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+
+		// credited back to the original from account
+		remaining := original.Amount - reversed.ToAmount
+		toAmount := arg.Amount
+		if toAmount == 0 {
+			toAmount = remaining
+		}
+		if toAmount <= 0 || toAmount > remaining {
+			return e.ErrReversalExceedsAmount
+		}
+
+		// debited from the original to account
+		left := original.ToAmount - reversed.Amount
+		amount := left
+		if toAmount < remaining {
+			amount = int(math.Round(float64(toAmount) * float64(original.ToAmount) / float64(original.Amount)))
+			if amount > left {
+				amount = left
+			}
+		}
+		if amount <= 0 {
+			return e.ErrAmountTooSmall
+		}
+
+		if err := checkFunds(ctx, q.Account, original.ToAccountID, original.FromAccountID, amount); err != nil {
+			return err
+		}
+
+		result, err = bookTransfer(ctx, q, domain.CreateTransferParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        amount,
+			ToAmount:      toAmount,
+			ExchangeRate:  math.Round(float64(toAmount)/float64(amount)*1e6) / 1e6,
+			Kind:          domain.TransferKindReversal,
+			ReversalOf:    original.ID,
+		})
+		return err
+	})
+	result.Retries = retries
+
+	return result, err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+// ProcessDueScheduledTransfers claims up to limit scheduled transfers due at
+// now and hands each one to run. The claim holds the rows locked until every
+// outcome is recorded and the next run is stored, so no other worker picks
+// them up in the meantime. run executes the transfer in its own transaction.
+func (r *Repository) ProcessDueScheduledTransfers(ctx context.Context, now time.Time, limit int, run func(ctx context.Context, transfer domain.ScheduledTransfer) domain.ScheduledRunOutcome) (int, error) {
+	var processed int
+
+	_, err := r.execTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted}, func(q *Repository) error {
+		processed = 0
+
+		due, err := q.ScheduledTransfer.ListDueScheduledTransfers(ctx, now, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, transfer := range due {
+			outcome := run(ctx, transfer)
+
+			if _, err := q.ScheduledTransfer.CreateScheduledTransferRun(ctx, outcome.Run); err != nil {
+				return err
+			}
+			if err := q.ScheduledTransfer.SetNextRunAt(ctx, transfer.ID, outcome.NextRunAt); err != nil {
+				return err
+			}
+			processed++
+		}
+		return nil
+	})
+
+	return processed, err
+}
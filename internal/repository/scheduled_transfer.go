@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+)
+
+type ScheduledTransferRepo struct {
+	db DBTX
+}
+
+func NewScheduledTransferRepo(db DBTX) *ScheduledTransferRepo {
+	return &ScheduledTransferRepo{
+		db: db,
+	}
+}
+
+func (r *ScheduledTransferRepo) CreateScheduledTransfer(ctx context.Context, arg domain.CreateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	stmt := `INSERT INTO scheduled_transfers (
+		owner,
+		from_account_id,
+		to_account_id,
+		amount,
+		schedule,
+		next_run_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	) RETURNING id, owner, from_account_id, to_account_id, amount, schedule, next_run_at, active, created_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.Owner, arg.FromAccountID, arg.ToAccountID, arg.Amount, arg.Schedule, arg.NextRunAt)
+	var i domain.ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Schedule,
+		&i.NextRunAt,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *ScheduledTransferRepo) GetScheduledTransfer(ctx context.Context, id int) (domain.ScheduledTransfer, error) {
+	stmt := `SELECT id, owner, from_account_id, to_account_id, amount, schedule, next_run_at, active, created_at FROM scheduled_transfers
+	WHERE id = $1 LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, id)
+	var i domain.ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Schedule,
+		&i.NextRunAt,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *ScheduledTransferRepo) ListScheduledTransfers(ctx context.Context, arg domain.ListScheduledTransfersParams) ([]domain.ScheduledTransfer, error) {
+	stmt := `SELECT id, owner, from_account_id, to_account_id, amount, schedule, next_run_at, active, created_at FROM scheduled_transfers
+	WHERE owner = $1
+	ORDER BY id
+	LIMIT $2
+	OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, stmt, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.ScheduledTransfer{}
+	for rows.Next() {
+		var i domain.ScheduledTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Schedule,
+			&i.NextRunAt,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *ScheduledTransferRepo) UpdateScheduledTransfer(ctx context.Context, arg domain.UpdateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	stmt := `UPDATE scheduled_transfers
+	SET amount = $2, schedule = $3, next_run_at = $4, active = $5
+	WHERE id = $1
+	RETURNING id, owner, from_account_id, to_account_id, amount, schedule, next_run_at, active, created_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.ID, arg.Amount, arg.Schedule, arg.NextRunAt, arg.Active)
+	var i domain.ScheduledTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Schedule,
+		&i.NextRunAt,
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *ScheduledTransferRepo) DeleteScheduledTransfer(ctx context.Context, id int) error {
+	stmt := `DELETE FROM scheduled_transfers WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, stmt, id)
+	return err
+}
+
+// ListDueScheduledTransfers locks up to limit active transfers due at now.
+// Rows another worker already holds are skipped, so several workers can
+// drain the queue side by side without running a transfer twice.
+func (r *ScheduledTransferRepo) ListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	if _, ok := r.db.(txBeginner); ok {
+		return nil, e.ErrLockOutsideTx
+	}
+
+	stmt := `SELECT id, owner, from_account_id, to_account_id, amount, schedule, next_run_at, active, created_at FROM scheduled_transfers
+	WHERE active AND next_run_at <= $1
+	ORDER BY next_run_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`
+	rows, err := r.db.QueryContext(ctx, stmt, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.ScheduledTransfer
+	for rows.Next() {
+		var i domain.ScheduledTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Schedule,
+			&i.NextRunAt,
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *ScheduledTransferRepo) SetNextRunAt(ctx context.Context, id int, nextRunAt time.Time) error {
+	stmt := `UPDATE scheduled_transfers SET next_run_at = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, stmt, id, nextRunAt)
+	return err
+}
+
+func (r *ScheduledTransferRepo) CreateScheduledTransferRun(ctx context.Context, arg domain.CreateScheduledTransferRunParams) (domain.ScheduledTransferRun, error) {
+	stmt := `INSERT INTO scheduled_transfer_runs (
+		scheduled_transfer_id,
+		scheduled_for,
+		status,
+		transfer_id,
+		error
+	) VALUES (
+		$1, $2, $3, $4, $5
+	) RETURNING id, scheduled_transfer_id, scheduled_for, status, transfer_id, error, created_at`
+	transferID := sql.NullInt64{Int64: int64(arg.TransferID), Valid: arg.TransferID != 0}
+	row := r.db.QueryRowContext(ctx, stmt, arg.ScheduledTransferID, arg.ScheduledFor, arg.Status, transferID, arg.Error)
+	var i domain.ScheduledTransferRun
+	err := row.Scan(
+		&i.ID,
+		&i.ScheduledTransferID,
+		&i.ScheduledFor,
+		&i.Status,
+		&transferID,
+		&i.Error,
+		&i.CreatedAt,
+	)
+	i.TransferID = int(transferID.Int64)
+	return i, err
+}
+
+func (r *ScheduledTransferRepo) ListScheduledTransferRuns(ctx context.Context, arg domain.ListScheduledTransferRunsParams) ([]domain.ScheduledTransferRun, error) {
+	stmt := `SELECT id, scheduled_transfer_id, scheduled_for, status, transfer_id, error, created_at FROM scheduled_transfer_runs
+	WHERE scheduled_transfer_id = $1
+	ORDER BY id DESC
+	LIMIT $2
+	OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, stmt, arg.ScheduledTransferID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.ScheduledTransferRun{}
+	for rows.Next() {
+		var i domain.ScheduledTransferRun
+		var transferID sql.NullInt64
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScheduledTransferID,
+			&i.ScheduledFor,
+			&i.Status,
+			&transferID,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		i.TransferID = int(transferID.Int64)
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
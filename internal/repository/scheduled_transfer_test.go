@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledTransferCRUD(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	scheduled := createScheduledTransfer(t, account1, account2, time.Now().Add(time.Hour))
+	require.True(t, scheduled.Active)
+	require.Equal(t, account1.Owner, scheduled.Owner)
+
+	got, err := store.ScheduledTransfer.GetScheduledTransfer(ctx, scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.Schedule, got.Schedule)
+	require.WithinDuration(t, scheduled.NextRunAt, got.NextRunAt, time.Second)
+
+	list, err := store.ScheduledTransfer.ListScheduledTransfers(ctx, domain.ListScheduledTransfersParams{
+		Owner: account1.Owner,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	updated, err := store.ScheduledTransfer.UpdateScheduledTransfer(ctx, domain.UpdateScheduledTransferParams{
+		ID:        scheduled.ID,
+		Amount:    20,
+		Schedule:  "@weekly",
+		NextRunAt: scheduled.NextRunAt.Add(time.Hour),
+		Active:    false,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 20, updated.Amount)
+	require.False(t, updated.Active)
+
+	err = store.ScheduledTransfer.DeleteScheduledTransfer(ctx, scheduled.ID)
+	require.NoError(t, err)
+
+	_, err = store.ScheduledTransfer.GetScheduledTransfer(ctx, scheduled.ID)
+	require.Error(t, err)
+}
+
+func TestListDueScheduledTransfersOutsideTx(t *testing.T) {
+	store := NewRepository(db)
+
+	_, err := store.ScheduledTransfer.ListDueScheduledTransfers(ctx, time.Now(), 10)
+	require.ErrorIs(t, err, e.ErrLockOutsideTx)
+}
+
+func TestProcessDueScheduledTransfersConcurrent(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	n := 10
+	due := make(map[int]bool)
+	for i := 0; i < n; i++ {
+		scheduled := createScheduledTransfer(t, account1, account2, time.Now().Add(-time.Minute))
+		due[scheduled.ID] = true
+	}
+	// not due yet, must be left alone
+	later := createScheduledTransfer(t, account1, account2, time.Now().Add(time.Hour))
+
+	var mu sync.Mutex
+	seen := make(map[int]int)
+	run := func(ctx context.Context, transfer domain.ScheduledTransfer) domain.ScheduledRunOutcome {
+		mu.Lock()
+		seen[transfer.ID]++
+		mu.Unlock()
+
+		// hold the claim for a moment so the workers overlap
+		time.Sleep(10 * time.Millisecond)
+
+		return domain.ScheduledRunOutcome{
+			Run: domain.CreateScheduledTransferRunParams{
+				ScheduledTransferID: transfer.ID,
+				ScheduledFor:        transfer.NextRunAt,
+				Status:              domain.ScheduledRunFailed,
+				Error:               "test",
+			},
+			NextRunAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	workers := 4
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				processed, err := store.ProcessDueScheduledTransfers(ctx, time.Now(), 3, run)
+				require.NoError(t, err)
+				if processed == 0 {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// other tests may leave due rows behind, only look at ours
+	for id := range due {
+		require.Equal(t, 1, seen[id], "scheduled transfer %d", id)
+
+		runs, err := store.ScheduledTransfer.ListScheduledTransferRuns(ctx, domain.ListScheduledTransferRunsParams{
+			ScheduledTransferID: id,
+			Limit:               10,
+		})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		require.Equal(t, domain.ScheduledRunFailed, runs[0].Status)
+		require.Zero(t, runs[0].TransferID)
+
+		scheduled, err := store.ScheduledTransfer.GetScheduledTransfer(ctx, id)
+		require.NoError(t, err)
+		require.True(t, scheduled.NextRunAt.After(time.Now()))
+	}
+	require.Zero(t, seen[later.ID])
+}
+
+func createScheduledTransfer(t *testing.T, from, to domain.Account, nextRunAt time.Time) domain.ScheduledTransfer {
+	store := NewRepository(db)
+
+	scheduled, err := store.ScheduledTransfer.CreateScheduledTransfer(ctx, domain.CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		Schedule:      "@every 1h",
+		NextRunAt:     nextRunAt,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, scheduled.ID)
+	return scheduled
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+type SessionRepo struct {
+	db DBTX
+}
+
+func NewSessionRepo(db DBTX) *SessionRepo {
+	return &SessionRepo{
+		db: db,
+	}
+}
+
+func (r *SessionRepo) CreateSession(ctx context.Context, arg domain.CreateSessionParams) (domain.Session, error) {
+	stmt := `INSERT INTO sessions (
+		username,
+		refresh_token_hash,
+		user_agent,
+		client_ip,
+		expires_at
+	) VALUES (
+		$1, $2, $3, $4, $5
+	) RETURNING id, username, refresh_token_hash, user_agent, client_ip, is_blocked, expires_at, created_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.Username, arg.RefreshTokenHash, arg.UserAgent, arg.ClientIP, arg.ExpiresAt)
+	return scanSession(row)
+}
+
+func (r *SessionRepo) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (domain.Session, error) {
+	stmt := `SELECT id, username, refresh_token_hash, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions
+	WHERE refresh_token_hash = $1 LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, refreshTokenHash)
+	return scanSession(row)
+}
+
+// RotateSession replaces the refresh token of the session. It only succeeds
+// when the old token is still current, so a refresh token can be used once.
+func (r *SessionRepo) RotateSession(ctx context.Context, arg domain.RotateSessionParams) (domain.Session, error) {
+	stmt := `UPDATE sessions
+	SET refresh_token_hash = $3, expires_at = $4
+	WHERE id = $1 AND refresh_token_hash = $2 AND NOT is_blocked
+	RETURNING id, username, refresh_token_hash, user_agent, client_ip, is_blocked, expires_at, created_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.ID, arg.OldRefreshTokenHash, arg.RefreshTokenHash, arg.ExpiresAt)
+	return scanSession(row)
+}
+
+func (r *SessionRepo) BlockSession(ctx context.Context, id int) error {
+	stmt := `UPDATE sessions SET is_blocked = true WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, stmt, id)
+	return err
+}
+
+func scanSession(row *sql.Row) (domain.Session, error) {
+	var i domain.Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshTokenHash,
+		&i.UserAgent,
+		&i.ClientIP,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
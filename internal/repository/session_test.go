@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+var sessionRepo *SessionRepo
+
+func TestCreateSession(t *testing.T) {
+	createRandomSession(t)
+}
+
+func TestGetSessionByRefreshToken(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	session2, err := sessionRepo.GetSessionByRefreshToken(ctx, session1.RefreshTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.Equal(t, session1.Username, session2.Username)
+	require.False(t, session2.IsBlocked)
+	require.WithinDuration(t, session1.ExpiresAt, session2.ExpiresAt, time.Second)
+}
+
+func TestRotateSession(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	arg := domain.RotateSessionParams{
+		ID:                  session1.ID,
+		OldRefreshTokenHash: session1.RefreshTokenHash,
+		RefreshTokenHash:    util.RandomString(64),
+		ExpiresAt:           time.Now().Add(time.Hour),
+	}
+	session2, err := sessionRepo.RotateSession(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.Equal(t, arg.RefreshTokenHash, session2.RefreshTokenHash)
+
+	// the old refresh token can't be rotated a second time
+	_, err = sessionRepo.RotateSession(ctx, arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestBlockSession(t *testing.T) {
+	session1 := createRandomSession(t)
+
+	err := sessionRepo.BlockSession(ctx, session1.ID)
+	require.NoError(t, err)
+
+	session2, err := sessionRepo.GetSessionByRefreshToken(ctx, session1.RefreshTokenHash)
+	require.NoError(t, err)
+	require.True(t, session2.IsBlocked)
+}
+
+func createRandomSession(t *testing.T) domain.Session {
+	user := createRandomUser(t)
+
+	arg := domain.CreateSessionParams{
+		Username:         user.Username,
+		RefreshTokenHash: util.RandomString(64),
+		UserAgent:        util.RandomString(10),
+		ClientIP:         "127.0.0.1",
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+
+	session, err := sessionRepo.CreateSession(ctx, arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshTokenHash, session.RefreshTokenHash)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIP, session.ClientIP)
+	require.False(t, session.IsBlocked)
+	require.NotZero(t, session.ID)
+	require.NotZero(t, session.CreatedAt)
+	return session
+}
@@ -3,21 +3,87 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (r *Repository) execTx(ctx context.Context, fn func(Account, Transfer, Entry) error) error {
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
+const (
+	// maxTxRetries bounds how many times a transaction is rerun after
+	// Postgres aborted it with a serialization failure or a deadlock.
+	maxTxRetries = 5
+
+	txRetryBaseDelay = 5 * time.Millisecond
+	txRetryMaxDelay  = 200 * time.Millisecond
+)
+
+// execTx runs fn with a set of repos bound to a single transaction, which is
+// committed when fn succeeds and rolled back otherwise. Serialization failures
+// and deadlocks rerun the whole transaction after a jittered backoff, so fn
+// must not keep state from a failed attempt. The number of retries is
+// returned along with the final error.
+func (r *Repository) execTx(ctx context.Context, opts *sql.TxOptions, fn func(q *Repository) error) (retries int, err error) {
+	ctx, span := startTxSpan(ctx, opts)
+	defer func() {
+		span.SetAttributes(attribute.Int("db.tx.retries", retries))
+		endQuerySpan(span, err)
+		span.End()
+	}()
+
+	for retries = 0; ; retries++ {
+		err = r.runTx(ctx, opts, fn)
+		if err == nil || !isRetryableTxError(err) || retries == maxTxRetries {
+			return retries, err
+		}
+		logger.FromContext(ctx).Warn("retrying transaction", "attempt", retries+1, "err", err)
+		span.AddEvent("retry", trace.WithAttributes(attribute.String("error", err.Error())))
+
+		select {
+		case <-ctx.Done():
+			return retries, ctx.Err()
+		case <-time.After(txRetryBackoff(retries)):
+		}
+	}
+}
+
+func (r *Repository) runTx(ctx context.Context, opts *sql.TxOptions, fn func(q *Repository) error) error {
+	tx, err := r.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
-	err = fn(r.Account, r.Transfer, r.Entry)
+	err = fn(newRepository(tx))
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v rb err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w rb err: %v", err, rbErr)
 		}
 		return err
 	}
 	return tx.Commit()
 }
+
+// isRetryableTxError reports whether Postgres aborted the transaction with a
+// serialization failure (40001) or a deadlock (40P01).
+func isRetryableTxError(err error) bool {
+	var pgErr *pq.Error
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+// txRetryBackoff returns a random delay up to an exponentially growing,
+// capped bound, so transactions that collided don't collide again.
+func txRetryBackoff(retry int) time.Duration {
+	bound := txRetryBaseDelay << retry
+	if bound <= 0 || bound > txRetryMaxDelay {
+		bound = txRetryMaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(bound))) + 1
+}
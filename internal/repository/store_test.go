@@ -2,27 +2,30 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewRepository(db)
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
 	n := 5
 	amount := 10
 
 	errs := make(chan error)
 	results := make(chan domain.TransferTxResult)
 	for i := 0; i < n; i++ {
-		txName := fmt.Sprintf("tx %d", i+1)
 		go func() {
-			ctx := context.WithValue(context.Background(), txKey, txName)
-			result, err := store.TransferTx(ctx, domain.TransferTxParams{
+			result, err := store.TransferTx(context.Background(), domain.TransferTxParams{
 				FromAccountID: account1.ID,
 				ToAccountID:   account2.ID,
 				Amount:        amount,
@@ -106,8 +109,8 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewRepository(db)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
 	n := 40
 	amount := 10
 	errs := make(chan error)
@@ -144,3 +147,617 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updateAccount1.Balance)
 	require.Equal(t, account2.Balance, updateAccount2.Balance)
 }
+
+func TestTransferTxIdempotency(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+	amount := 10
+
+	arg := domain.TransferTxParams{
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         amount,
+		Currency:       account1.Currency,
+		IdempotencyKey: util.RandomString(32),
+		Owner:          account1.Owner,
+	}
+
+	result1, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+	require.False(t, result1.Replayed)
+
+	result2, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+	require.True(t, result2.Replayed)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+	require.Equal(t, result1.FromAccount.Balance, result2.FromAccount.Balance)
+
+	updateAccount1, err := store.Account.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updateAccount1.Balance)
+
+	arg.Amount = amount + 1
+	_, err = store.TransferTx(ctx, arg)
+	require.ErrorIs(t, err, e.ErrIdempotencyKeyConflict)
+
+	arg.Amount = amount
+	arg.Currency = "XXX"
+	_, err = store.TransferTx(ctx, arg)
+	require.ErrorIs(t, err, e.ErrIdempotencyKeyConflict)
+
+	// the same key of another user is another request
+	arg.Currency = account1.Currency
+	arg.Owner = util.RandomOwner()
+	result3, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+	require.False(t, result3.Replayed)
+	require.NotEqual(t, result1.Transfer.ID, result3.Transfer.ID)
+}
+
+func TestTransferTxExpiredIdempotencyKey(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	arg := domain.TransferTxParams{
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         10,
+		Currency:       account1.Currency,
+		IdempotencyKey: util.RandomString(32),
+		Owner:          account1.Owner,
+	}
+
+	created, err := store.Idempotency.CreateIdempotencyKey(ctx, domain.CreateIdempotencyKeyParams{
+		Owner:       arg.Owner,
+		Key:         arg.IdempotencyKey,
+		RequestHash: "stale",
+		TTL:         -time.Second,
+	})
+	require.NoError(t, err)
+	require.True(t, created)
+
+	// an expired key is taken over by the next request
+	result, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+	require.False(t, result.Replayed)
+
+	key, err := store.Idempotency.GetIdempotencyKey(ctx, arg.Owner, arg.IdempotencyKey)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, key.TransferID)
+	require.True(t, key.ExpiresAt.After(time.Now()))
+}
+
+func TestDeleteExpiredIdempotencyKeys(t *testing.T) {
+	store := NewRepository(db)
+	owner := util.RandomOwner()
+
+	for _, ttl := range []time.Duration{-time.Second, time.Hour} {
+		_, err := store.Idempotency.CreateIdempotencyKey(ctx, domain.CreateIdempotencyKeyParams{
+			Owner:       owner,
+			Key:         ttl.String(),
+			RequestHash: "hash",
+			TTL:         ttl,
+		})
+		require.NoError(t, err)
+	}
+
+	for {
+		n, err := store.Idempotency.DeleteExpiredIdempotencyKeys(ctx, 100)
+		require.NoError(t, err)
+		if n < 100 {
+			break
+		}
+	}
+
+	_, err := store.Idempotency.GetIdempotencyKey(ctx, owner, (-time.Second).String())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.Idempotency.GetIdempotencyKey(ctx, owner, time.Hour.String())
+	require.NoError(t, err)
+}
+
+func TestTransferTxCrossCurrency(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createRandomAccount(t)
+
+	arg := domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		ToAmount:      9,
+		ExchangeRate:  0.9,
+	}
+
+	result, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Amount, result.Transfer.Amount)
+	require.Equal(t, arg.ToAmount, result.Transfer.ToAmount)
+	require.Equal(t, arg.ExchangeRate, result.Transfer.ExchangeRate)
+
+	require.Equal(t, -arg.Amount, result.FromEntry.Amount)
+	require.Equal(t, arg.ToAmount, result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-arg.Amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+arg.ToAmount, result.ToAccount.Balance)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 10)
+	account2 := createRandomAccount(t)
+
+	arg := domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        15,
+	}
+
+	_, err := store.TransferTx(ctx, arg)
+	require.ErrorIs(t, err, e.ErrInsufficientFunds)
+
+	updateAccount1, err := store.Account.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updateAccount1.Balance)
+
+	_, err = store.Account.SetOverdraftLimit(ctx, domain.SetOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 5,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, -5, result.FromAccount.Balance)
+
+	_, err = store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, e.ErrInsufficientFunds)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 100)
+	account2 := createAccountWithBalance(t, 100)
+
+	_, err := store.Account.SetAccountStatus(ctx, account2.ID, domain.AccountFrozen)
+	require.NoError(t, err)
+
+	// neither debited nor credited
+	_, err = store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, e.ErrAccountFrozen)
+
+	_, err = store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, e.ErrAccountFrozen)
+
+	_, err = store.Account.SetAccountStatus(ctx, account2.ID, domain.AccountClosed)
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, e.ErrAccountClosed)
+
+	updated, err := store.Account.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated.Balance)
+}
+
+func TestExecTxRollback(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+	n := 10
+	amount := 10
+	errRollback := fmt.Errorf("rollback")
+
+	errs := make(chan error)
+	transferIDs := make(chan int)
+	for i := 0; i < n; i++ {
+		go func() {
+			var transferID int
+			_, err := store.execTx(ctx, &sql.TxOptions{}, func(q *Repository) error {
+				transfer, err := q.Transfer.CreateTransfer(ctx, domain.CreateTransferParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					ToAmount:      amount,
+					ExchangeRate:  1,
+				})
+				if err != nil {
+					return err
+				}
+				transferID = transfer.ID
+
+				_, err = q.Entry.CreateEntry(ctx, domain.CreateEntryParams{
+					AccountID:  account1.ID,
+					Amount:     -amount,
+					TransferID: transfer.ID,
+				})
+				if err != nil {
+					return err
+				}
+
+				_, _, err = addMoney(ctx, q.Account, account1.ID, -amount, account2.ID, amount)
+				if err != nil {
+					return err
+				}
+
+				return errRollback
+			})
+			errs <- err
+			transferIDs <- transferID
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.ErrorIs(t, err, errRollback)
+
+		transferID := <-transferIDs
+		require.NotZero(t, transferID)
+
+		_, err = store.Transfer.GetTransfer(ctx, transferID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	for _, account := range []domain.Account{account1, account2} {
+		entries, err := store.Entry.ListEntries(ctx, domain.ListEntriesParams{
+			AccountID: account.ID,
+			Limit:     n,
+		})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		updated, err := store.Account.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updated.Balance)
+	}
+}
+
+func TestTransferTxRollbackReleasesIdempotencyKey(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 0)
+	account2 := createRandomAccount(t)
+
+	arg := domain.TransferTxParams{
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         10,
+		Currency:       account1.Currency,
+		IdempotencyKey: util.RandomString(32),
+		Owner:          account1.Owner,
+	}
+
+	// the key is reserved before the funds check fails, so it must be
+	// rolled back together with the rest of the transfer
+	_, err := store.TransferTx(ctx, arg)
+	require.ErrorIs(t, err, e.ErrInsufficientFunds)
+
+	_, err = store.Idempotency.GetIdempotencyKey(ctx, arg.Owner, arg.IdempotencyKey)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.Account.SetOverdraftLimit(ctx, domain.SetOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: arg.Amount,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(ctx, arg)
+	require.NoError(t, err)
+	require.False(t, result.Replayed)
+}
+
+func TestTransferTxStress(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 10000)
+	account2 := createAccountWithBalance(t, 10000)
+	n := 400
+	amount := 10
+
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+
+		if i%2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+		go func() {
+			_, err := store.TransferTx(ctx, domain.TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updateAccount1, err := store.Account.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	updateAccount2, err := store.Account.GetAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updateAccount1.Balance)
+	require.Equal(t, account2.Balance, updateAccount2.Balance)
+}
+
+func TestTransferTxRetriesSerializationFailure(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 100)
+	account2 := createAccountWithBalance(t, 100)
+
+	// another transaction updates the from account and holds on to its lock
+	other, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer other.Rollback()
+	_, err = other.ExecContext(ctx, `UPDATE accounts SET balance = balance + 1 WHERE id = $1`, account1.ID)
+	require.NoError(t, err)
+
+	type txResult struct {
+		result domain.TransferTxResult
+		err    error
+	}
+	done := make(chan txResult, 1)
+	go func() {
+		result, err := store.TransferTx(ctx, domain.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+			Isolation:     sql.LevelRepeatableRead,
+		})
+		done <- txResult{result: result, err: err}
+	}()
+
+	// the transfer has taken its snapshot and waits for the lock, once the
+	// update commits its first attempt fails with 40001
+	waitForLockWait(t)
+	require.NoError(t, other.Commit())
+
+	res := <-done
+	require.NoError(t, res.err)
+	require.Equal(t, 1, res.result.Retries)
+	require.Equal(t, 100+1-10, res.result.FromAccount.Balance)
+	require.Equal(t, 100+10, res.result.ToAccount.Balance)
+}
+
+// waitForLockWait waits until a backend of the test database is blocked on
+// a lock.
+func waitForLockWait(t *testing.T) {
+	require.Eventually(t, func() bool {
+		var waiting int
+		err := db.QueryRowContext(ctx, `SELECT count(*) FROM pg_stat_activity
+		WHERE datname = current_database() AND wait_event_type = 'Lock'`).Scan(&waiting)
+		require.NoError(t, err)
+		return waiting > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	require.True(t, isRetryableTxError(&pq.Error{Code: "40001"}))
+	require.True(t, isRetryableTxError(&pq.Error{Code: "40P01"}))
+	require.True(t, isRetryableTxError(fmt.Errorf("tx err: %w rb err: %v", &pq.Error{Code: "40P01"}, sql.ErrTxDone)))
+	require.False(t, isRetryableTxError(&pq.Error{Code: "23505"}))
+	require.False(t, isRetryableTxError(e.ErrInsufficientFunds))
+}
+
+func TestTxRetryBackoff(t *testing.T) {
+	for retry := 0; retry < 64; retry++ {
+		delay := txRetryBackoff(retry)
+		require.Positive(t, delay)
+		require.LessOrEqual(t, delay, txRetryMaxDelay)
+	}
+}
+
+func TestDepositWithdrawTx(t *testing.T) {
+	store := NewRepository(db)
+
+	account := createAccountWithBalance(t, 0)
+	settlement := createAccountWithBalance(t, 0)
+	amount := 100
+
+	deposit := domain.CashTxParams{
+		AccountID:           account.ID,
+		SettlementAccountID: settlement.ID,
+		Amount:              amount,
+		ExternalRef:         util.RandomString(32),
+	}
+	result, err := store.DepositTx(ctx, deposit)
+	require.NoError(t, err)
+	require.Equal(t, domain.TransferKindDeposit, result.Transfer.Kind)
+	require.Equal(t, deposit.ExternalRef, result.Transfer.ExternalRef)
+	require.Equal(t, amount, result.Entry.Amount)
+	require.Equal(t, amount, result.Account.Balance)
+
+	_, err = store.DepositTx(ctx, deposit)
+	require.ErrorIs(t, err, e.ErrDuplicateExternalRef)
+
+	withdraw := domain.CashTxParams{
+		AccountID:           account.ID,
+		SettlementAccountID: settlement.ID,
+		Amount:              amount + 1,
+		ExternalRef:         util.RandomString(32),
+	}
+	_, err = store.WithdrawTx(ctx, withdraw)
+	require.ErrorIs(t, err, e.ErrInsufficientFunds)
+
+	withdraw.Amount = amount
+	result, err = store.WithdrawTx(ctx, withdraw)
+	require.NoError(t, err)
+	require.Equal(t, domain.TransferKindWithdrawal, result.Transfer.Kind)
+	require.Equal(t, -amount, result.Entry.Amount)
+	require.Zero(t, result.Account.Balance)
+
+	// every booking is balanced by the settlement account
+	updatedSettlement, err := store.Account.GetAccount(ctx, settlement.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedSettlement.Balance+result.Account.Balance)
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	original, err := store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		ToAmount:      90,
+		ExchangeRate:  0.9,
+	})
+	require.NoError(t, err)
+
+	partial, err := store.ReverseTransferTx(ctx, domain.ReverseTransferParams{
+		TransferID: original.Transfer.ID,
+		Amount:     40,
+	})
+	require.NoError(t, err)
+	require.Equal(t, domain.TransferKindReversal, partial.Transfer.Kind)
+	require.Equal(t, original.Transfer.ID, partial.Transfer.ReversalOf)
+	require.Equal(t, account2.ID, partial.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, partial.Transfer.ToAccountID)
+	require.Equal(t, 36, partial.Transfer.Amount)
+	require.Equal(t, 40, partial.Transfer.ToAmount)
+
+	_, err = store.ReverseTransferTx(ctx, domain.ReverseTransferParams{
+		TransferID: original.Transfer.ID,
+		Amount:     61,
+	})
+	require.ErrorIs(t, err, e.ErrReversalExceedsAmount)
+
+	// without an amount the rest is reversed
+	rest, err := store.ReverseTransferTx(ctx, domain.ReverseTransferParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 54, rest.Transfer.Amount)
+	require.Equal(t, 60, rest.Transfer.ToAmount)
+
+	_, err = store.ReverseTransferTx(ctx, domain.ReverseTransferParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.ErrorIs(t, err, e.ErrReversalExceedsAmount)
+
+	_, err = store.ReverseTransferTx(ctx, domain.ReverseTransferParams{
+		TransferID: rest.Transfer.ID,
+	})
+	require.ErrorIs(t, err, e.ErrTransferNotReversible)
+
+	updateAccount1, err := store.Account.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	updateAccount2, err := store.Account.GetAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updateAccount1.Balance)
+	require.Equal(t, account2.Balance, updateAccount2.Balance)
+}
+
+func TestReverseTransferTxConcurrent(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 1000)
+	account2 := createAccountWithBalance(t, 1000)
+
+	original, err := store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	// only ten of the twenty reversals fit into the original amount
+	n := 20
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.ReverseTransferTx(ctx, domain.ReverseTransferParams{
+				TransferID: original.Transfer.ID,
+				Amount:     10,
+			})
+			errs <- err
+		}()
+	}
+
+	var reversed int
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			reversed++
+			continue
+		}
+		require.ErrorIs(t, err, e.ErrReversalExceedsAmount)
+	}
+	require.Equal(t, 10, reversed)
+
+	totals, err := store.Transfer.GetReversedTotals(ctx, original.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, original.Transfer.Amount, totals.ToAmount)
+}
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 100)
+	account2 := createAccountWithBalance(t, 0)
+	account3 := createAccountWithBalance(t, 0)
+
+	results, err := store.BatchTransferTx(ctx, []domain.TransferTxParams{
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 60},
+		{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 40},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, 40, results[0].FromAccount.Balance)
+	require.Equal(t, 0, results[1].FromAccount.Balance)
+	require.Equal(t, 60, results[0].ToAccount.Balance)
+	require.Equal(t, 40, results[1].ToAccount.Balance)
+
+	// the second transfer overdraws account2, so the first must not stick
+	_, err = store.BatchTransferTx(ctx, []domain.TransferTxParams{
+		{FromAccountID: account3.ID, ToAccountID: account2.ID, Amount: 40},
+		{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 200},
+	})
+	require.ErrorIs(t, err, e.ErrInsufficientFunds)
+
+	var batchErr *e.BatchTransferError
+	require.ErrorAs(t, err, &batchErr)
+	require.Equal(t, 1, batchErr.Index)
+
+	updateAccount2, err := store.Account.GetAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, 60, updateAccount2.Balance)
+
+	updateAccount3, err := store.Account.GetAccount(ctx, account3.ID)
+	require.NoError(t, err)
+	require.Equal(t, 40, updateAccount3.Balance)
+}
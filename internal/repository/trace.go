@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/begenov/backend/internal/repository")
+
+// tracedDBTX starts a span for every query run on q. Only the call is timed,
+// rows read afterwards aren't, which is what tells lock waits apart from the
+// rest of a request.
+type tracedDBTX struct {
+	q DBTX
+}
+
+// tracedPool is a tracedDBTX that can still begin transactions, the repos
+// tell the pool from a transaction by it.
+type tracedPool struct {
+	tracedDBTX
+	txBeginner
+}
+
+func traceDBTX(q DBTX) DBTX {
+	traced := tracedDBTX{q: q}
+	if beginner, ok := q.(txBeginner); ok {
+		return &tracedPool{tracedDBTX: traced, txBeginner: beginner}
+	}
+	return &traced
+}
+
+func (t *tracedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	result, err := t.q.ExecContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+func (t *tracedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func (t *tracedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	row := t.q.QueryRowContext(ctx, query, args...)
+	endQuerySpan(span, row.Err())
+	return row
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := queryOperation(query)
+	return tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(operation),
+			semconv.DBStatement(strings.TrimSpace(query)),
+		),
+	)
+}
+
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// queryOperation names a span after the statement it runs, like SELECT.
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// startTxSpan starts the span of execTx, the queries of every attempt are
+// its children.
+func startTxSpan(ctx context.Context, opts *sql.TxOptions) (context.Context, trace.Span) {
+	isolation := sql.LevelDefault
+	if opts != nil {
+		isolation = opts.Isolation
+	}
+	return tracer.Start(ctx, "execTx", trace.WithAttributes(
+		semconv.DBSystemPostgreSQL,
+		attribute.String("db.isolation_level", isolation.String()),
+	))
+}
@@ -2,68 +2,220 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
 )
 
-var txKey = struct{}{}
-
 func (r *Repository) TransferTx(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxResult, error) {
 	var result domain.TransferTxResult
 
-	err := r.execTx(ctx, func(account Account, transfer Transfer, entry Entry) error {
+	// the from account is debited in its currency and the to account is
+	// credited in its own, which is the same amount without a conversion
+	toAmount, exchangeRate := arg.ToAmount, arg.ExchangeRate
+	if toAmount == 0 {
+		toAmount, exchangeRate = arg.Amount, 1
+	}
+
+	// the row locks are what keeps the balances right, stricter levels only
+	// add serialization failures that are retried
+	isolation := arg.Isolation
+	if isolation == sql.LevelDefault {
+		isolation = sql.LevelReadCommitted
+	}
+
+	retries, err := r.execTx(ctx, &sql.TxOptions{Isolation: isolation}, func(q *Repository) error {
 		var err error
+		result = domain.TransferTxResult{}
 
-		txName := ctx.Value(txKey)
+		if arg.IdempotencyKey != "" {
+			result.Replayed, err = reserveIdempotencyKey(ctx, q.Idempotency, arg, &result)
+			if err != nil || result.Replayed {
+				return err
+			}
+		}
 
-		fmt.Println(txName, "create transfer")
-		result.Transfer, err = transfer.CreateTransfer(ctx, domain.CreateTransferParams{
+		if err := checkFunds(ctx, q.Account, arg.FromAccountID, arg.ToAccountID, arg.Amount); err != nil {
+			return err
+		}
+
+		result, err = bookTransfer(ctx, q, domain.CreateTransferParams{
 			FromAccountID: arg.FromAccountID,
 			ToAccountID:   arg.ToAccountID,
 			Amount:        arg.Amount,
+			ToAmount:      toAmount,
+			ExchangeRate:  exchangeRate,
 		})
 		if err != nil {
 			return err
 		}
 
-		fmt.Println(txName, "create entry 1")
-		result.FromEntry, err = entry.CreateEntry(ctx, domain.CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
+		if arg.IdempotencyKey != "" {
+			return saveIdempotencyKey(ctx, q.Idempotency, arg.Owner, arg.IdempotencyKey, result)
 		}
+		return nil
+	})
+	result.Retries = retries
 
-		fmt.Println(txName, "create entry 2")
+	return result, err
+}
 
-		result.ToEntry, err = entry.CreateEntry(ctx, domain.CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// bookTransfer writes the transfer with an entry on each side, moves the
+// balances and records a transfer.completed event for both accounts. Both
+// accounts must already be locked by the transaction.
+func bookTransfer(ctx context.Context, q *Repository, arg domain.CreateTransferParams) (domain.TransferTxResult, error) {
+	var result domain.TransferTxResult
+	var err error
+
+	result.Transfer, err = q.Transfer.CreateTransfer(ctx, arg)
+	if err != nil {
+		return result, err
+	}
+
+	result.FromEntry, err = q.Entry.CreateEntry(ctx, domain.CreateEntryParams{
+		AccountID:  arg.FromAccountID,
+		Amount:     -arg.Amount,
+		TransferID: result.Transfer.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.Entry.CreateEntry(ctx, domain.CreateEntryParams{
+		AccountID:  arg.ToAccountID,
+		Amount:     arg.ToAmount,
+		TransferID: result.Transfer.ID,
+	})
+	if err != nil {
+		return result, err
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = r.addMoney(ctx, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = r.addMoney(ctx, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q.Account, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.ToAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q.Account, arg.ToAccountID, arg.ToAmount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
 
+	// one event per side, so that each account sees its own events in order
+	for _, accountID := range []int{arg.FromAccountID, arg.ToAccountID} {
+		if err := writeOutboxEvent(ctx, q, accountID, domain.EventTransferCompleted, result.Transfer); err != nil {
+			return result, err
 		}
+	}
+	return result, nil
+}
 
-		fmt.Println(txName, "updated account 2 balance:", result.ToAccount.Balance)
-		log.Println(result.FromAccount.ID == arg.FromAccountID, result.ToAccount.ID == arg.ToAccountID)
+// reserveIdempotencyKey claims the key of arg.Owner for this request. When
+// the key is already known the stored result is loaded into result and true
+// is returned.
+func reserveIdempotencyKey(ctx context.Context, idempotency Idempotency, arg domain.TransferTxParams, result *domain.TransferTxResult) (bool, error) {
+	requestHash, err := transferRequestHash(arg)
+	if err != nil {
+		return false, err
+	}
+
+	created, err := idempotency.CreateIdempotencyKey(ctx, domain.CreateIdempotencyKeyParams{
+		Owner:       arg.Owner,
+		Key:         arg.IdempotencyKey,
+		RequestHash: requestHash,
+		TTL:         domain.IdempotencyKeyTTL,
+	})
+	if err != nil || created {
+		return false, err
+	}
+
+	key, err := idempotency.GetIdempotencyKey(ctx, arg.Owner, arg.IdempotencyKey)
+	if err != nil {
+		return false, err
+	}
+
+	if key.RequestHash != requestHash {
+		return false, e.ErrIdempotencyKeyConflict
+	}
+
+	if key.Response == nil {
+		return false, e.ErrIdempotencyKeyInProgress
+	}
+
+	if err := json.Unmarshal(key.Response, result); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func saveIdempotencyKey(ctx context.Context, idempotency Idempotency, owner, key string, result domain.TransferTxResult) error {
+	response, err := json.Marshal(result)
+	if err != nil {
 		return err
+	}
+
+	return idempotency.UpdateIdempotencyKey(ctx, domain.UpdateIdempotencyKeyParams{
+		Owner:      owner,
+		Key:        key,
+		TransferID: result.Transfer.ID,
+		Response:   response,
 	})
+}
 
-	return result, err
+// transferRequestHash covers everything the client sent. The converted
+// amount and the rate aren't part of it, a retry after the rate moved is
+// still the same request.
+func transferRequestHash(arg domain.TransferTxParams) (string, error) {
+	request, err := json.Marshal(struct {
+		FromAccountID int    `json:"from_account_id"`
+		ToAccountID   int    `json:"to_account_id"`
+		Amount        int    `json:"amount"`
+		Currency      string `json:"currency"`
+	}{arg.FromAccountID, arg.ToAccountID, arg.Amount, arg.Currency})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(request)
+	return fmt.Sprintf("%x", sum), nil
+}
+
+// checkFunds locks both accounts for the rest of the transaction and checks
+// that both are open and that debiting amount keeps the from account within
+// its overdraft limit.
+func checkFunds(ctx context.Context, account Account, fromAccountID, toAccountID, amount int) error {
+	accounts, err := account.LockAccounts(ctx, fromAccountID, toAccountID)
+	if err != nil {
+		return err
+	}
+
+	for _, locked := range accounts {
+		if err := checkOpen(locked); err != nil {
+			return err
+		}
+		if locked.ID == fromAccountID && locked.Balance-amount < -locked.OverdraftLimit {
+			return e.ErrInsufficientFunds
+		}
+	}
+	return nil
+}
+
+// checkOpen refuses to move money in or out of an account that is frozen or
+// closed.
+func checkOpen(account domain.Account) error {
+	switch account.Status {
+	case domain.AccountFrozen:
+		return fmt.Errorf("account [%d]: %w", account.ID, e.ErrAccountFrozen)
+	case domain.AccountClosed:
+		return fmt.Errorf("account [%d]: %w", account.ID, e.ErrAccountClosed)
+	}
+	return nil
 }
 
-func (r *Repository) addMoney(ctx context.Context, fromAccountID int, fromAmount int, toAccountID int, toAmount int) (account1 domain.Account, account2 domain.Account, err error) {
-	account1, err = r.Account.AddAccountBalance(ctx, domain.AddAccountBalanceParams{
+func addMoney(ctx context.Context, account Account, fromAccountID int, fromAmount int, toAccountID int, toAmount int) (account1 domain.Account, account2 domain.Account, err error) {
+	account1, err = account.AddAccountBalance(ctx, domain.AddAccountBalanceParams{
 		ID:     fromAccountID,
 		Amount: fromAmount,
 	})
@@ -71,7 +223,7 @@ func (r *Repository) addMoney(ctx context.Context, fromAccountID int, fromAmount
 	if err != nil {
 		return
 	}
-	account2, err = r.Account.AddAccountBalance(ctx, domain.AddAccountBalanceParams{
+	account2, err = account.AddAccountBalance(ctx, domain.AddAccountBalanceParams{
 		ID:     toAccountID,
 		Amount: toAmount,
 	})
@@ -2,16 +2,16 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 
 	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
 )
 
 type TransferRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewTransferRepo(db *sql.DB) *TransferRepo {
+func NewTransferRepo(db DBTX) *TransferRepo {
 	return &TransferRepo{
 		db: db,
 	}
@@ -21,24 +21,38 @@ func (r *TransferRepo) CreateTransfer(ctx context.Context, arg domain.CreateTran
 	stmt := `INSERT INTO transfers (
 		from_account_id,
 		to_account_id,
-		amount
+		amount,
+		to_amount,
+		exchange_rate,
+		kind,
+		external_ref,
+		reversal_of
 	) VALUES (
-		$1, $2, $3
-	) RETURNING id, from_account_id, to_account_id, amount, "created_at"`
-	row := r.db.QueryRowContext(ctx, stmt, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+		$1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, 0)
+	) RETURNING id, from_account_id, to_account_id, amount, to_amount, exchange_rate, kind, COALESCE(external_ref, ''), COALESCE(reversal_of, 0), "created_at"`
+	kind := arg.Kind
+	if kind == "" {
+		kind = domain.TransferKindTransfer
+	}
+	row := r.db.QueryRowContext(ctx, stmt, arg.FromAccountID, arg.ToAccountID, arg.Amount, arg.ToAmount, arg.ExchangeRate, kind, arg.ExternalRef, arg.ReversalOf)
 	var i domain.Transfer
 	err := row.Scan(
 		&i.ID,
 		&i.FromAccountID,
 		&i.ToAccountID,
 		&i.Amount,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Kind,
+		&i.ExternalRef,
+		&i.ReversalOf,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 func (r *TransferRepo) GetTransfer(ctx context.Context, id int) (domain.Transfer, error) {
-	stmt := `SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+	stmt := `SELECT id, from_account_id, to_account_id, amount, to_amount, exchange_rate, kind, COALESCE(external_ref, ''), COALESCE(reversal_of, 0), created_at FROM transfers
 	WHERE id = $1 LIMIT 1`
 	row := r.db.QueryRowContext(ctx, stmt, id)
 	var i domain.Transfer
@@ -47,13 +61,54 @@ func (r *TransferRepo) GetTransfer(ctx context.Context, id int) (domain.Transfer
 		&i.FromAccountID,
 		&i.ToAccountID,
 		&i.Amount,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Kind,
+		&i.ExternalRef,
+		&i.ReversalOf,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
+// GetTransferForUpdate reads the transfer and locks it until the transaction
+// ends, so reversals of the same transfer are booked one at a time.
+func (r *TransferRepo) GetTransferForUpdate(ctx context.Context, id int) (domain.Transfer, error) {
+	if _, ok := r.db.(txBeginner); ok {
+		return domain.Transfer{}, e.ErrLockOutsideTx
+	}
+
+	stmt := `SELECT id, from_account_id, to_account_id, amount, to_amount, exchange_rate, kind, COALESCE(external_ref, ''), COALESCE(reversal_of, 0), created_at FROM transfers
+	WHERE id = $1 LIMIT 1
+	FOR NO KEY UPDATE`
+	row := r.db.QueryRowContext(ctx, stmt, id)
+	var i domain.Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Kind,
+		&i.ExternalRef,
+		&i.ReversalOf,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *TransferRepo) GetReversedTotals(ctx context.Context, id int) (domain.ReversedTotals, error) {
+	stmt := `SELECT COALESCE(SUM(amount), 0)::bigint, COALESCE(SUM(to_amount), 0)::bigint FROM transfers
+	WHERE reversal_of = $1`
+	row := r.db.QueryRowContext(ctx, stmt, id)
+	var i domain.ReversedTotals
+	err := row.Scan(&i.Amount, &i.ToAmount)
+	return i, err
+}
+
 func (r *TransferRepo) ListTransfers(ctx context.Context, arg domain.ListTransfersParams) ([]domain.Transfer, error) {
-	stmt := `SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+	stmt := `SELECT id, from_account_id, to_account_id, amount, to_amount, exchange_rate, kind, COALESCE(external_ref, ''), COALESCE(reversal_of, 0), created_at FROM transfers
 	WHERE 
 		from_account_id = $1 OR
 		to_account_id = $2
@@ -72,6 +127,11 @@ func (r *TransferRepo) ListTransfers(ctx context.Context, arg domain.ListTransfe
 			&i.FromAccountID,
 			&i.ToAccountID,
 			&i.Amount,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Kind,
+			&i.ExternalRef,
+			&i.ReversalOf,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
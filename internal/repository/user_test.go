@@ -49,6 +49,7 @@ func createRandomUser(t *testing.T) domain.User {
 	require.Equal(t, arg.HashedPassword, user.HashedPassword)
 	require.Equal(t, arg.FullName, user.FullName)
 	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, util.DepositorRole, user.Role)
 	require.NotZero(t, user.CreatedAt)
 	require.True(t, user.PasswordChangedAt.IsZero())
 	return user
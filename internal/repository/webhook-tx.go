@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+)
+
+// ProcessDueWebhookDeliveries claims up to limit deliveries due at now, hands
+// each one to deliver and records the attempts. Claiming moves the next
+// attempt of the batch lease ahead in a short transaction, so other workers
+// skip the rows while they are posted and no transaction is held open across
+// the requests. The attempts are recorded in a second transaction. If the
+// worker dies in between, the deliveries are picked up again once the lease
+// has run out.
+func (r *Repository) ProcessDueWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration, deliver func(ctx context.Context, delivery domain.DueWebhookDelivery) domain.WebhookAttempt) (int, error) {
+	opts := &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+
+	var due []domain.DueWebhookDelivery
+	_, err := r.execTx(ctx, opts, func(q *Repository) error {
+		var err error
+		due, err = q.Webhook.ListDueWebhookDeliveries(ctx, now, limit)
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		ids := make([]int, len(due))
+		for i, delivery := range due {
+			ids[i] = delivery.ID
+		}
+		return q.Webhook.LeaseWebhookDeliveries(ctx, ids, now.Add(lease))
+	})
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	attempts := make([]domain.WebhookAttempt, len(due))
+	for i, delivery := range due {
+		attempts[i] = deliver(ctx, delivery)
+	}
+
+	_, err = r.execTx(ctx, opts, func(q *Repository) error {
+		for i, delivery := range due {
+			if err := q.Webhook.RecordWebhookAttempt(ctx, delivery.ID, attempts[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(due), nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/lib/pq"
+)
+
+type WebhookRepo struct {
+	db DBTX
+}
+
+func NewWebhookRepo(db DBTX) *WebhookRepo {
+	return &WebhookRepo{
+		db: db,
+	}
+}
+
+func (r *WebhookRepo) CreateWebhookSubscription(ctx context.Context, arg domain.CreateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	stmt := `INSERT INTO webhook_subscriptions (
+		owner,
+		url,
+		secret,
+		event_types
+	) VALUES (
+		$1, $2, $3, $4
+	) RETURNING id, owner, url, secret, event_types, active, created_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.Owner, arg.URL, arg.Secret, pq.Array(eventTypes(arg.EventTypes)))
+	var i domain.WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.URL,
+		&i.Secret,
+		pq.Array(&i.EventTypes),
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *WebhookRepo) GetWebhookSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error) {
+	stmt := `SELECT id, owner, url, secret, event_types, active, created_at FROM webhook_subscriptions
+	WHERE id = $1 LIMIT 1`
+	row := r.db.QueryRowContext(ctx, stmt, id)
+	var i domain.WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.URL,
+		&i.Secret,
+		pq.Array(&i.EventTypes),
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *WebhookRepo) ListWebhookSubscriptions(ctx context.Context, arg domain.ListWebhookSubscriptionsParams) ([]domain.WebhookSubscription, error) {
+	stmt := `SELECT id, owner, url, secret, event_types, active, created_at FROM webhook_subscriptions
+	WHERE owner = $1
+	ORDER BY id
+	LIMIT $2
+	OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, stmt, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.WebhookSubscription{}
+	for rows.Next() {
+		var i domain.WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.URL,
+			&i.Secret,
+			pq.Array(&i.EventTypes),
+			&i.Active,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *WebhookRepo) UpdateWebhookSubscription(ctx context.Context, arg domain.UpdateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	stmt := `UPDATE webhook_subscriptions
+	SET url = $2, event_types = $3, active = $4
+	WHERE id = $1
+	RETURNING id, owner, url, secret, event_types, active, created_at`
+	row := r.db.QueryRowContext(ctx, stmt, arg.ID, arg.URL, pq.Array(eventTypes(arg.EventTypes)), arg.Active)
+	var i domain.WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.URL,
+		&i.Secret,
+		pq.Array(&i.EventTypes),
+		&i.Active,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+func (r *WebhookRepo) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	stmt := `DELETE FROM webhook_subscriptions WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, stmt, id)
+	return err
+}
+
+// EnqueueWebhookDeliveries queues the event for every active subscription of
+// the account's owner that asked for its type. Queuing the same event again
+// is a no-op, so the outbox may hand it over more than once.
+func (r *WebhookRepo) EnqueueWebhookDeliveries(ctx context.Context, event domain.OutboxEvent) (int, error) {
+	stmt := `INSERT INTO webhook_deliveries (
+		subscription_id,
+		event_id,
+		account_id,
+		event_type,
+		payload
+	)
+	SELECT s.id, $1, $2, $3, $4 FROM webhook_subscriptions s
+	JOIN accounts a ON a.owner = s.owner
+	WHERE a.id = $2
+	AND s.active
+	AND (cardinality(s.event_types) = 0 OR $3 = ANY(s.event_types))
+	ON CONFLICT (subscription_id, event_id) DO NOTHING`
+	res, err := r.db.ExecContext(ctx, stmt, event.ID, event.AccountID, event.EventType, []byte(event.Payload))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// ListDueWebhookDeliveries locks up to limit pending deliveries that are due
+// at now. Rows another worker already holds are skipped.
+func (r *WebhookRepo) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.DueWebhookDelivery, error) {
+	if _, ok := r.db.(txBeginner); ok {
+		return nil, e.ErrLockOutsideTx
+	}
+
+	stmt := `SELECT d.id, d.subscription_id, d.event_id, d.account_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.response_status, d.last_error, d.created_at, d.updated_at, s.url, s.secret
+	FROM webhook_deliveries d
+	JOIN webhook_subscriptions s ON s.id = d.subscription_id
+	WHERE d.status = 'pending' AND d.next_attempt_at <= $1
+	ORDER BY d.next_attempt_at
+	LIMIT $2
+	FOR UPDATE OF d SKIP LOCKED`
+	rows, err := r.db.QueryContext(ctx, stmt, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.DueWebhookDelivery
+	for rows.Next() {
+		var i domain.DueWebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.AccountID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.URL,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// LeaseWebhookDeliveries pushes the next attempt of the deliveries to until,
+// which hides them from ListDueWebhookDeliveries while they are posted.
+func (r *WebhookRepo) LeaseWebhookDeliveries(ctx context.Context, ids []int, until time.Time) error {
+	stmt := `UPDATE webhook_deliveries
+	SET next_attempt_at = $2, updated_at = now()
+	WHERE id = ANY($1)`
+	_, err := r.db.ExecContext(ctx, stmt, pq.Array(ids), until)
+	return err
+}
+
+func (r *WebhookRepo) RecordWebhookAttempt(ctx context.Context, id int, attempt domain.WebhookAttempt) error {
+	stmt := `UPDATE webhook_deliveries
+	SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4, next_attempt_at = $5, updated_at = now()
+	WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, stmt, id, attempt.Status, attempt.ResponseStatus, attempt.Error, attempt.NextAttemptAt)
+	return err
+}
+
+func (r *WebhookRepo) ListWebhookDeliveries(ctx context.Context, arg domain.ListWebhookDeliveriesParams) ([]domain.WebhookDelivery, error) {
+	stmt := `SELECT id, subscription_id, event_id, account_id, event_type, payload, status, attempts, next_attempt_at, response_status, last_error, created_at, updated_at FROM webhook_deliveries
+	WHERE subscription_id = $1
+	ORDER BY id DESC
+	LIMIT $2
+	OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, stmt, arg.SubscriptionID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.WebhookDelivery{}
+	for rows.Next() {
+		var i domain.WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.AccountID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// eventTypes stores a missing list as an empty array, the column is not null.
+func eventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+	return types
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSubscriptionCRUD(t *testing.T) {
+	store := NewRepository(db)
+	user := createRandomUser(t)
+
+	subscription := createWebhookSubscription(t, user.Username, "https://example.com/hooks", nil)
+	require.Empty(t, subscription.EventTypes)
+	require.True(t, subscription.Active)
+
+	got, err := store.Webhook.GetWebhookSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	require.Equal(t, subscription.Secret, got.Secret)
+
+	updated, err := store.Webhook.UpdateWebhookSubscription(ctx, domain.UpdateWebhookSubscriptionParams{
+		ID:         subscription.ID,
+		URL:        "https://example.com/other",
+		EventTypes: []string{domain.EventTransferCompleted},
+		Active:     false,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{domain.EventTransferCompleted}, updated.EventTypes)
+	require.False(t, updated.Active)
+
+	list, err := store.Webhook.ListWebhookSubscriptions(ctx, domain.ListWebhookSubscriptionsParams{
+		Owner: user.Username,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	err = store.Webhook.DeleteWebhookSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+
+	_, err = store.Webhook.GetWebhookSubscription(ctx, subscription.ID)
+	require.Error(t, err)
+}
+
+func TestListDueWebhookDeliveriesOutsideTx(t *testing.T) {
+	store := NewRepository(db)
+
+	_, err := store.Webhook.ListDueWebhookDeliveries(ctx, time.Now(), 10)
+	require.ErrorIs(t, err, e.ErrLockOutsideTx)
+}
+
+func TestTransferTxEnqueuesWebhookDeliveries(t *testing.T) {
+	store := NewRepository(db)
+
+	account1 := createAccountWithBalance(t, 100)
+	account2 := createAccountWithBalance(t, 100)
+
+	all := createWebhookSubscription(t, account1.Owner, "https://example.com/all", nil)
+	created := createWebhookSubscription(t, account1.Owner, "https://example.com/created", []string{domain.EventAccountCreated})
+
+	_, err := store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	deliveries, err := store.Webhook.ListWebhookDeliveries(ctx, domain.ListWebhookDeliveriesParams{SubscriptionID: all.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, domain.EventTransferCompleted, deliveries[0].EventType)
+	require.Equal(t, account1.ID, deliveries[0].AccountID)
+	require.Equal(t, domain.WebhookDeliveryPending, deliveries[0].Status)
+
+	deliveries, err = store.Webhook.ListWebhookDeliveries(ctx, domain.ListWebhookDeliveriesParams{SubscriptionID: created.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+func TestProcessDueWebhookDeliveries(t *testing.T) {
+	store := NewRepository(db)
+
+	var failing bool
+	received := make(chan string, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		secret := r.URL.Query().Get("secret")
+		if failing || !webhook.Verify(secret, timestamp, body, r.Header.Get(webhook.HeaderSignature)) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received <- r.Header.Get(webhook.HeaderEvent)
+	}))
+	defer receiver.Close()
+
+	account1 := createAccountWithBalance(t, 100)
+	account2 := createAccountWithBalance(t, 100)
+
+	subscription := createWebhookSubscription(t, account1.Owner, receiver.URL, nil)
+	// the receiver checks the signature against the secret it is handed
+	subscription, err := store.Webhook.UpdateWebhookSubscription(ctx, domain.UpdateWebhookSubscriptionParams{
+		ID:     subscription.ID,
+		URL:    receiver.URL + "?secret=" + subscription.Secret,
+		Active: true,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	sender := webhook.NewPrivateSender(time.Second)
+	later := func(ctx context.Context, delivery domain.DueWebhookDelivery) domain.WebhookAttempt {
+		return domain.WebhookAttempt{Status: domain.WebhookDeliveryPending, NextAttemptAt: time.Now().Add(time.Hour)}
+	}
+	deliver := func(ctx context.Context, delivery domain.DueWebhookDelivery) domain.WebhookAttempt {
+		if delivery.SubscriptionID != subscription.ID {
+			// left over by other tests, try again later
+			return later(ctx, delivery)
+		}
+
+		// the claimed delivery is hidden from other workers while it is posted
+		var seen []int
+		_, err := store.ProcessDueWebhookDeliveries(ctx, time.Now(), 100, time.Minute, func(ctx context.Context, other domain.DueWebhookDelivery) domain.WebhookAttempt {
+			seen = append(seen, other.ID)
+			return later(ctx, other)
+		})
+		require.NoError(t, err)
+		require.NotContains(t, seen, delivery.ID)
+		code, err := sender.Send(ctx, delivery.URL, delivery.Secret, webhook.Event{
+			ID:        delivery.EventID,
+			Type:      delivery.EventType,
+			AccountID: delivery.AccountID,
+			Data:      delivery.Payload,
+		})
+		if err != nil {
+			return domain.WebhookAttempt{Status: domain.WebhookDeliveryPending, ResponseStatus: code, Error: err.Error(), NextAttemptAt: time.Now()}
+		}
+		return domain.WebhookAttempt{Status: domain.WebhookDeliverySucceeded, ResponseStatus: code, NextAttemptAt: delivery.NextAttemptAt}
+	}
+
+	failing = true
+	_, err = store.ProcessDueWebhookDeliveries(ctx, time.Now(), 100, time.Minute, deliver)
+	require.NoError(t, err)
+
+	deliveries, err := store.Webhook.ListWebhookDeliveries(ctx, domain.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, domain.WebhookDeliveryPending, deliveries[0].Status)
+	require.Equal(t, 1, deliveries[0].Attempts)
+	require.Equal(t, http.StatusInternalServerError, deliveries[0].ResponseStatus)
+	require.NotEmpty(t, deliveries[0].LastError)
+
+	failing = false
+	_, err = store.ProcessDueWebhookDeliveries(ctx, time.Now(), 100, time.Minute, deliver)
+	require.NoError(t, err)
+	require.Equal(t, domain.EventTransferCompleted, <-received)
+
+	deliveries, err = store.Webhook.ListWebhookDeliveries(ctx, domain.ListWebhookDeliveriesParams{SubscriptionID: subscription.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, domain.WebhookDeliverySucceeded, deliveries[0].Status)
+	require.Equal(t, 2, deliveries[0].Attempts)
+	require.Equal(t, http.StatusOK, deliveries[0].ResponseStatus)
+}
+
+func createWebhookSubscription(t *testing.T, owner, url string, eventTypes []string) domain.WebhookSubscription {
+	store := NewRepository(db)
+
+	subscription, err := store.Webhook.CreateWebhookSubscription(ctx, domain.CreateWebhookSubscriptionParams{
+		Owner:      owner,
+		URL:        url,
+		Secret:     "secret-" + owner,
+		EventTypes: eventTypes,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, subscription.ID)
+	return subscription
+}
@@ -23,6 +23,20 @@ func NewServer(cfg *config.Config, handler http.Handler) *Server {
 	}
 }
 
+// NewAdminServer serves operational endpoints like /metrics on an address of
+// their own, loopback by default, so that they are kept off the public
+// network.
+func NewAdminServer(cfg *config.Config, handler http.Handler) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Server.AdminAddr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.Server.ReadTimeout,
+			WriteTimeout:      cfg.Server.WriteTimeout,
+		},
+	}
+}
+
 func (s *Server) Run() error {
 	return s.httpServer.ListenAndServe()
 }
@@ -9,16 +9,20 @@ import (
 
 type AccountService struct {
 	repo repository.Account
+	tx   repository.Tx
 }
 
-func NewAccountService(repo repository.Account) *AccountService {
+func NewAccountService(repo repository.Account, tx repository.Tx) *AccountService {
 	return &AccountService{
 		repo: repo,
+		tx:   tx,
 	}
 }
 
+// CreateAccount creates the account and records an account.created event
+// for downstream services.
 func (s *AccountService) CreateAccount(ctx context.Context, arg domain.CreateAccountParams) (domain.Account, error) {
-	return s.repo.CreateAccount(ctx, arg)
+	return s.tx.CreateAccountTx(ctx, arg)
 }
 
 func (s *AccountService) GetAccountByID(ctx context.Context, id int) (domain.Account, error) {
@@ -28,3 +32,17 @@ func (s *AccountService) GetAccountByID(ctx context.Context, id int) (domain.Acc
 func (s *AccountService) ListAccounts(ctx context.Context, arg domain.ListAccountsParams) ([]domain.Account, error) {
 	return s.repo.ListAccounts(ctx, arg)
 }
+
+func (s *AccountService) SetOverdraftLimit(ctx context.Context, arg domain.SetOverdraftLimitParams) (domain.Account, error) {
+	return s.repo.SetOverdraftLimit(ctx, arg)
+}
+
+// ChangeAccountStatus freezes, unfreezes or closes the account. Who may ask
+// for which change is up to the caller.
+func (s *AccountService) ChangeAccountStatus(ctx context.Context, arg domain.ChangeAccountStatusParams) (domain.Account, error) {
+	return s.tx.ChangeAccountStatusTx(ctx, arg)
+}
+
+func (s *AccountService) ListAccountStatusChanges(ctx context.Context, arg domain.ListAccountStatusChangesParams) ([]domain.AccountStatusChange, error) {
+	return s.repo.ListAccountStatusChanges(ctx, arg)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+)
+
+type AuditService struct {
+	repo repository.Audit
+}
+
+func NewAuditService(repo repository.Audit) *AuditService {
+	return &AuditService{
+		repo: repo,
+	}
+}
+
+// Record appends the outcome of a state-changing call to the audit log.
+func (s *AuditService) Record(ctx context.Context, arg domain.CreateAuditEventParams) error {
+	_, err := s.repo.CreateAuditEvent(ctx, arg)
+	return err
+}
+
+func (s *AuditService) ListAuditEvents(ctx context.Context, arg domain.ListAuditEventsParams) ([]domain.AuditEvent, error) {
+	return s.repo.ListAuditEvents(ctx, arg)
+}
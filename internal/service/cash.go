@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+	"github.com/begenov/backend/pkg/e"
+)
+
+type CashService struct {
+	repo     repository.Tx
+	accounts repository.Account
+}
+
+func NewCashService(repo repository.Tx, accounts repository.Account) *CashService {
+	return &CashService{
+		repo:     repo,
+		accounts: accounts,
+	}
+}
+
+func (s *CashService) Deposit(ctx context.Context, arg domain.CashParams) (domain.CashTxResult, error) {
+	txArg, err := s.cashTxParams(ctx, arg)
+	if err != nil {
+		return domain.CashTxResult{}, err
+	}
+	return s.repo.DepositTx(ctx, txArg)
+}
+
+func (s *CashService) Withdraw(ctx context.Context, arg domain.CashParams) (domain.CashTxResult, error) {
+	txArg, err := s.cashTxParams(ctx, arg)
+	if err != nil {
+		return domain.CashTxResult{}, err
+	}
+	return s.repo.WithdrawTx(ctx, txArg)
+}
+
+// cashTxParams books the movement against the settlement account of the
+// account's currency.
+func (s *CashService) cashTxParams(ctx context.Context, arg domain.CashParams) (domain.CashTxParams, error) {
+	account, err := s.accounts.GetAccount(ctx, arg.AccountID)
+	if err != nil {
+		return domain.CashTxParams{}, err
+	}
+	if account.Owner == domain.SettlementOwner {
+		return domain.CashTxParams{}, e.ErrNoSettlementAccount
+	}
+
+	settlement, err := s.accounts.GetAccountByOwner(ctx, domain.SettlementOwner, account.Currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.CashTxParams{}, e.ErrNoSettlementAccount
+		}
+		return domain.CashTxParams{}, err
+	}
+
+	return domain.CashTxParams{
+		AccountID:           arg.AccountID,
+		SettlementAccountID: settlement.ID,
+		Amount:              arg.Amount,
+		ExternalRef:         arg.ExternalRef,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+)
+
+type EntryService struct {
+	repo repository.Entry
+}
+
+func NewEntryService(repo repository.Entry) *EntryService {
+	return &EntryService{
+		repo: repo,
+	}
+}
+
+func (s *EntryService) ListEntries(ctx context.Context, arg domain.ListEntriesParams) ([]domain.Entry, error) {
+	return s.repo.ListEntries(ctx, arg)
+}
+
+func (s *EntryService) GetStatement(ctx context.Context, arg domain.StatementParams) (domain.Statement, error) {
+	return s.repo.GetStatement(ctx, arg)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/export"
+	"github.com/begenov/backend/internal/repository"
+)
+
+type ExportService struct {
+	repo repository.Entry
+}
+
+func NewExportService(repo repository.Entry) *ExportService {
+	return &ExportService{
+		repo: repo,
+	}
+}
+
+// ExportStatement writes the statement for the period to w as the entries
+// are read, without loading them all. Nothing is written when the account
+// can't be loaded, so callers can still report the error.
+func (s *ExportService) ExportStatement(ctx context.Context, w io.Writer, format export.Format, arg domain.StatementParams) error {
+	enc, err := export.NewEncoder(w, format, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.StreamStatement(ctx, arg, enc.Begin, enc.Entry); err != nil {
+		return err
+	}
+	return enc.End()
+}
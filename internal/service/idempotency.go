@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/begenov/backend/internal/repository"
+	"github.com/begenov/backend/pkg/logger"
+)
+
+// IdempotencySweeper deletes idempotency keys once they have expired.
+type IdempotencySweeper struct {
+	repo      repository.Idempotency
+	batchSize int
+}
+
+func NewIdempotencySweeper(repo repository.Idempotency, batchSize int) *IdempotencySweeper {
+	return &IdempotencySweeper{
+		repo:      repo,
+		batchSize: batchSize,
+	}
+}
+
+// Run sweeps expired keys every interval until ctx is cancelled.
+func (s *IdempotencySweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// keep deleting while full batches come back
+		for {
+			n, err := s.Sweep(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Error("idempotency key sweep", "err", err)
+			}
+			if err != nil || n < s.batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep deletes one batch of expired keys and returns how many went.
+func (s *IdempotencySweeper) Sweep(ctx context.Context) (int, error) {
+	return s.repo.DeleteExpiredIdempotencyKeys(ctx, s.batchSize)
+}
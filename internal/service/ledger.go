@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+)
+
+const defaultLedgerBatchSize = 1000
+
+type LedgerService struct {
+	repo repository.Ledger
+}
+
+func NewLedgerService(repo repository.Ledger) *LedgerService {
+	return &LedgerService{
+		repo: repo,
+	}
+}
+
+// CheckLedger scans all accounts and transfers in batches and collects every
+// violation of the double-entry invariants.
+func (s *LedgerService) CheckLedger(ctx context.Context, arg domain.LedgerCheckParams) (domain.LedgerReport, error) {
+	batchSize := arg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultLedgerBatchSize
+	}
+
+	report := domain.LedgerReport{Discrepancies: []domain.LedgerDiscrepancy{}}
+
+	accounts, err := s.scan(ctx, batchSize, s.repo.CheckAccountBalances, &report)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+	report.AccountsChecked = accounts.Checked
+
+	transfers, err := s.scan(ctx, batchSize, s.repo.CheckTransferEntries, &report)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+	report.TransfersChecked = transfers.Checked - transfers.Skipped
+	report.TransfersSkipped = transfers.Skipped
+
+	return report, nil
+}
+
+// CheckAccountBalances checks a single batch of accounts, so a caller with a
+// deadline can walk the table page by page.
+func (s *LedgerService) CheckAccountBalances(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	return s.repo.CheckAccountBalances(ctx, arg)
+}
+
+// CheckTransferEntries checks a single batch of transfers.
+func (s *LedgerService) CheckTransferEntries(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	return s.repo.CheckTransferEntries(ctx, arg)
+}
+
+// scan runs check over the whole table and returns the number of rows read
+// and skipped.
+func (s *LedgerService) scan(ctx context.Context, batchSize int, check func(context.Context, domain.LedgerBatchParams) (domain.LedgerBatch, error), report *domain.LedgerReport) (domain.LedgerBatch, error) {
+	var total domain.LedgerBatch
+	arg := domain.LedgerBatchParams{Limit: batchSize}
+	for {
+		batch, err := check(ctx, arg)
+		if err != nil {
+			return domain.LedgerBatch{}, err
+		}
+		total.Checked += batch.Checked
+		total.Skipped += batch.Skipped
+		report.Discrepancies = append(report.Discrepancies, batch.Discrepancies...)
+
+		if batch.LastID == 0 {
+			return total, nil
+		}
+		arg.AfterID = batch.LastID
+	}
+}
@@ -6,9 +6,12 @@ package mock_service
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/begenov/backend/internal/domain"
+	export "github.com/begenov/backend/internal/export"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,6 +38,21 @@ func (m *MockAccount) EXPECT() *MockAccountMockRecorder {
 	return m.recorder
 }
 
+// ChangeAccountStatus mocks base method.
+func (m *MockAccount) ChangeAccountStatus(ctx context.Context, arg domain.ChangeAccountStatusParams) (domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeAccountStatus", ctx, arg)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeAccountStatus indicates an expected call of ChangeAccountStatus.
+func (mr *MockAccountMockRecorder) ChangeAccountStatus(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountStatus", reflect.TypeOf((*MockAccount)(nil).ChangeAccountStatus), ctx, arg)
+}
+
 // CreateAccount mocks base method.
 func (m *MockAccount) CreateAccount(ctx context.Context, arg domain.CreateAccountParams) (domain.Account, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +83,21 @@ func (mr *MockAccountMockRecorder) GetAccountByID(ctx, id interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByID", reflect.TypeOf((*MockAccount)(nil).GetAccountByID), ctx, id)
 }
 
+// ListAccountStatusChanges mocks base method.
+func (m *MockAccount) ListAccountStatusChanges(ctx context.Context, arg domain.ListAccountStatusChangesParams) ([]domain.AccountStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountStatusChanges", ctx, arg)
+	ret0, _ := ret[0].([]domain.AccountStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountStatusChanges indicates an expected call of ListAccountStatusChanges.
+func (mr *MockAccountMockRecorder) ListAccountStatusChanges(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountStatusChanges", reflect.TypeOf((*MockAccount)(nil).ListAccountStatusChanges), ctx, arg)
+}
+
 // ListAccounts mocks base method.
 func (m *MockAccount) ListAccounts(ctx context.Context, arg domain.ListAccountsParams) ([]domain.Account, error) {
 	m.ctrl.T.Helper()
@@ -79,3 +112,908 @@ func (mr *MockAccountMockRecorder) ListAccounts(ctx, arg interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockAccount)(nil).ListAccounts), ctx, arg)
 }
+
+// SetOverdraftLimit mocks base method.
+func (m *MockAccount) SetOverdraftLimit(ctx context.Context, arg domain.SetOverdraftLimitParams) (domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOverdraftLimit", ctx, arg)
+	ret0, _ := ret[0].(domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOverdraftLimit indicates an expected call of SetOverdraftLimit.
+func (mr *MockAccountMockRecorder) SetOverdraftLimit(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOverdraftLimit", reflect.TypeOf((*MockAccount)(nil).SetOverdraftLimit), ctx, arg)
+}
+
+// MockTransferTx is a mock of TransferTx interface.
+type MockTransferTx struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransferTxMockRecorder
+}
+
+// MockTransferTxMockRecorder is the mock recorder for MockTransferTx.
+type MockTransferTxMockRecorder struct {
+	mock *MockTransferTx
+}
+
+// NewMockTransferTx creates a new mock instance.
+func NewMockTransferTx(ctrl *gomock.Controller) *MockTransferTx {
+	mock := &MockTransferTx{ctrl: ctrl}
+	mock.recorder = &MockTransferTxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransferTx) EXPECT() *MockTransferTxMockRecorder {
+	return m.recorder
+}
+
+// BatchTransfer mocks base method.
+func (m *MockTransferTx) BatchTransfer(ctx context.Context, arg domain.BatchTransferParams) (domain.BatchTransferResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchTransfer", ctx, arg)
+	ret0, _ := ret[0].(domain.BatchTransferResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchTransfer indicates an expected call of BatchTransfer.
+func (mr *MockTransferTxMockRecorder) BatchTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchTransfer", reflect.TypeOf((*MockTransferTx)(nil).BatchTransfer), ctx, arg)
+}
+
+// ReverseTransfer mocks base method.
+func (m *MockTransferTx) ReverseTransfer(ctx context.Context, arg domain.ReverseTransferParams) (domain.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransfer", ctx, arg)
+	ret0, _ := ret[0].(domain.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransfer indicates an expected call of ReverseTransfer.
+func (mr *MockTransferTxMockRecorder) ReverseTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransfer", reflect.TypeOf((*MockTransferTx)(nil).ReverseTransfer), ctx, arg)
+}
+
+// TransferTx mocks base method.
+func (m *MockTransferTx) TransferTx(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferTx", ctx, arg)
+	ret0, _ := ret[0].(domain.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferTx indicates an expected call of TransferTx.
+func (mr *MockTransferTxMockRecorder) TransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockTransferTx)(nil).TransferTx), ctx, arg)
+}
+
+// MockCash is a mock of Cash interface.
+type MockCash struct {
+	ctrl     *gomock.Controller
+	recorder *MockCashMockRecorder
+}
+
+// MockCashMockRecorder is the mock recorder for MockCash.
+type MockCashMockRecorder struct {
+	mock *MockCash
+}
+
+// NewMockCash creates a new mock instance.
+func NewMockCash(ctrl *gomock.Controller) *MockCash {
+	mock := &MockCash{ctrl: ctrl}
+	mock.recorder = &MockCashMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCash) EXPECT() *MockCashMockRecorder {
+	return m.recorder
+}
+
+// Deposit mocks base method.
+func (m *MockCash) Deposit(ctx context.Context, arg domain.CashParams) (domain.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deposit", ctx, arg)
+	ret0, _ := ret[0].(domain.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deposit indicates an expected call of Deposit.
+func (mr *MockCashMockRecorder) Deposit(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deposit", reflect.TypeOf((*MockCash)(nil).Deposit), ctx, arg)
+}
+
+// Withdraw mocks base method.
+func (m *MockCash) Withdraw(ctx context.Context, arg domain.CashParams) (domain.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Withdraw", ctx, arg)
+	ret0, _ := ret[0].(domain.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Withdraw indicates an expected call of Withdraw.
+func (mr *MockCashMockRecorder) Withdraw(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Withdraw", reflect.TypeOf((*MockCash)(nil).Withdraw), ctx, arg)
+}
+
+// MockTransfer is a mock of Transfer interface.
+type MockTransfer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransferMockRecorder
+}
+
+// MockTransferMockRecorder is the mock recorder for MockTransfer.
+type MockTransferMockRecorder struct {
+	mock *MockTransfer
+}
+
+// NewMockTransfer creates a new mock instance.
+func NewMockTransfer(ctrl *gomock.Controller) *MockTransfer {
+	mock := &MockTransfer{ctrl: ctrl}
+	mock.recorder = &MockTransferMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransfer) EXPECT() *MockTransferMockRecorder {
+	return m.recorder
+}
+
+// GetTransfer mocks base method.
+func (m *MockTransfer) GetTransfer(ctx context.Context, id int) (domain.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", ctx, id)
+	ret0, _ := ret[0].(domain.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockTransferMockRecorder) GetTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockTransfer)(nil).GetTransfer), ctx, id)
+}
+
+// ListTransfers mocks base method.
+func (m *MockTransfer) ListTransfers(ctx context.Context, arg domain.ListTransfersParams) ([]domain.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", ctx, arg)
+	ret0, _ := ret[0].([]domain.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockTransferMockRecorder) ListTransfers(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockTransfer)(nil).ListTransfers), ctx, arg)
+}
+
+// MockEntry is a mock of Entry interface.
+type MockEntry struct {
+	ctrl     *gomock.Controller
+	recorder *MockEntryMockRecorder
+}
+
+// MockEntryMockRecorder is the mock recorder for MockEntry.
+type MockEntryMockRecorder struct {
+	mock *MockEntry
+}
+
+// NewMockEntry creates a new mock instance.
+func NewMockEntry(ctrl *gomock.Controller) *MockEntry {
+	mock := &MockEntry{ctrl: ctrl}
+	mock.recorder = &MockEntryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEntry) EXPECT() *MockEntryMockRecorder {
+	return m.recorder
+}
+
+// GetStatement mocks base method.
+func (m *MockEntry) GetStatement(ctx context.Context, arg domain.StatementParams) (domain.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatement", ctx, arg)
+	ret0, _ := ret[0].(domain.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatement indicates an expected call of GetStatement.
+func (mr *MockEntryMockRecorder) GetStatement(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatement", reflect.TypeOf((*MockEntry)(nil).GetStatement), ctx, arg)
+}
+
+// ListEntries mocks base method.
+func (m *MockEntry) ListEntries(ctx context.Context, arg domain.ListEntriesParams) ([]domain.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", ctx, arg)
+	ret0, _ := ret[0].([]domain.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockEntryMockRecorder) ListEntries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockEntry)(nil).ListEntries), ctx, arg)
+}
+
+// MockExport is a mock of Export interface.
+type MockExport struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportMockRecorder
+}
+
+// MockExportMockRecorder is the mock recorder for MockExport.
+type MockExportMockRecorder struct {
+	mock *MockExport
+}
+
+// NewMockExport creates a new mock instance.
+func NewMockExport(ctrl *gomock.Controller) *MockExport {
+	mock := &MockExport{ctrl: ctrl}
+	mock.recorder = &MockExportMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExport) EXPECT() *MockExportMockRecorder {
+	return m.recorder
+}
+
+// ExportStatement mocks base method.
+func (m *MockExport) ExportStatement(ctx context.Context, w io.Writer, format export.Format, arg domain.StatementParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportStatement", ctx, w, format, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportStatement indicates an expected call of ExportStatement.
+func (mr *MockExportMockRecorder) ExportStatement(ctx, w, format, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportStatement", reflect.TypeOf((*MockExport)(nil).ExportStatement), ctx, w, format, arg)
+}
+
+// MockScheduledTransfer is a mock of ScheduledTransfer interface.
+type MockScheduledTransfer struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledTransferMockRecorder
+}
+
+// MockScheduledTransferMockRecorder is the mock recorder for MockScheduledTransfer.
+type MockScheduledTransferMockRecorder struct {
+	mock *MockScheduledTransfer
+}
+
+// NewMockScheduledTransfer creates a new mock instance.
+func NewMockScheduledTransfer(ctrl *gomock.Controller) *MockScheduledTransfer {
+	mock := &MockScheduledTransfer{ctrl: ctrl}
+	mock.recorder = &MockScheduledTransferMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledTransfer) EXPECT() *MockScheduledTransferMockRecorder {
+	return m.recorder
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) CreateScheduledTransfer(ctx context.Context, arg domain.CreateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) CreateScheduledTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).CreateScheduledTransfer), ctx, arg)
+}
+
+// DeleteScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) DeleteScheduledTransfer(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteScheduledTransfer indicates an expected call of DeleteScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) DeleteScheduledTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).DeleteScheduledTransfer), ctx, id)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) GetScheduledTransfer(ctx context.Context, id int) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) GetScheduledTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).GetScheduledTransfer), ctx, id)
+}
+
+// ListScheduledTransferRuns mocks base method.
+func (m *MockScheduledTransfer) ListScheduledTransferRuns(ctx context.Context, arg domain.ListScheduledTransferRunsParams) ([]domain.ScheduledTransferRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransferRuns", ctx, arg)
+	ret0, _ := ret[0].([]domain.ScheduledTransferRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransferRuns indicates an expected call of ListScheduledTransferRuns.
+func (mr *MockScheduledTransferMockRecorder) ListScheduledTransferRuns(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransferRuns", reflect.TypeOf((*MockScheduledTransfer)(nil).ListScheduledTransferRuns), ctx, arg)
+}
+
+// ListScheduledTransfers mocks base method.
+func (m *MockScheduledTransfer) ListScheduledTransfers(ctx context.Context, arg domain.ListScheduledTransfersParams) ([]domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", ctx, arg)
+	ret0, _ := ret[0].([]domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockScheduledTransferMockRecorder) ListScheduledTransfers(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockScheduledTransfer)(nil).ListScheduledTransfers), ctx, arg)
+}
+
+// UpdateScheduledTransfer mocks base method.
+func (m *MockScheduledTransfer) UpdateScheduledTransfer(ctx context.Context, arg domain.UpdateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransfer indicates an expected call of UpdateScheduledTransfer.
+func (mr *MockScheduledTransferMockRecorder) UpdateScheduledTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransfer", reflect.TypeOf((*MockScheduledTransfer)(nil).UpdateScheduledTransfer), ctx, arg)
+}
+
+// MockScheduler is a mock of Scheduler interface.
+type MockScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchedulerMockRecorder
+}
+
+// MockSchedulerMockRecorder is the mock recorder for MockScheduler.
+type MockSchedulerMockRecorder struct {
+	mock *MockScheduler
+}
+
+// NewMockScheduler creates a new mock instance.
+func NewMockScheduler(ctrl *gomock.Controller) *MockScheduler {
+	mock := &MockScheduler{ctrl: ctrl}
+	mock.recorder = &MockSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduler) EXPECT() *MockSchedulerMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method.
+func (m *MockScheduler) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockSchedulerMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockScheduler)(nil).Run), ctx, interval)
+}
+
+// RunDue mocks base method.
+func (m *MockScheduler) RunDue(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDue", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunDue indicates an expected call of RunDue.
+func (mr *MockSchedulerMockRecorder) RunDue(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDue", reflect.TypeOf((*MockScheduler)(nil).RunDue), ctx)
+}
+
+// MockRelay is a mock of Relay interface.
+type MockRelay struct {
+	ctrl     *gomock.Controller
+	recorder *MockRelayMockRecorder
+}
+
+// MockRelayMockRecorder is the mock recorder for MockRelay.
+type MockRelayMockRecorder struct {
+	mock *MockRelay
+}
+
+// NewMockRelay creates a new mock instance.
+func NewMockRelay(ctrl *gomock.Controller) *MockRelay {
+	mock := &MockRelay{ctrl: ctrl}
+	mock.recorder = &MockRelayMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRelay) EXPECT() *MockRelayMockRecorder {
+	return m.recorder
+}
+
+// RelayPending mocks base method.
+func (m *MockRelay) RelayPending(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelayPending", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelayPending indicates an expected call of RelayPending.
+func (mr *MockRelayMockRecorder) RelayPending(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelayPending", reflect.TypeOf((*MockRelay)(nil).RelayPending), ctx)
+}
+
+// Run mocks base method.
+func (m *MockRelay) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockRelayMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRelay)(nil).Run), ctx, interval)
+}
+
+// MockSweeper is a mock of Sweeper interface.
+type MockSweeper struct {
+	ctrl     *gomock.Controller
+	recorder *MockSweeperMockRecorder
+}
+
+// MockSweeperMockRecorder is the mock recorder for MockSweeper.
+type MockSweeperMockRecorder struct {
+	mock *MockSweeper
+}
+
+// NewMockSweeper creates a new mock instance.
+func NewMockSweeper(ctrl *gomock.Controller) *MockSweeper {
+	mock := &MockSweeper{ctrl: ctrl}
+	mock.recorder = &MockSweeperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSweeper) EXPECT() *MockSweeperMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method.
+func (m *MockSweeper) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockSweeperMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockSweeper)(nil).Run), ctx, interval)
+}
+
+// Sweep mocks base method.
+func (m *MockSweeper) Sweep(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sweep", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sweep indicates an expected call of Sweep.
+func (mr *MockSweeperMockRecorder) Sweep(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sweep", reflect.TypeOf((*MockSweeper)(nil).Sweep), ctx)
+}
+
+// MockWebhook is a mock of Webhook interface.
+type MockWebhook struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookMockRecorder
+}
+
+// MockWebhookMockRecorder is the mock recorder for MockWebhook.
+type MockWebhookMockRecorder struct {
+	mock *MockWebhook
+}
+
+// NewMockWebhook creates a new mock instance.
+func NewMockWebhook(ctrl *gomock.Controller) *MockWebhook {
+	mock := &MockWebhook{ctrl: ctrl}
+	mock.recorder = &MockWebhookMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhook) EXPECT() *MockWebhookMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockWebhook) CreateWebhookSubscription(ctx context.Context, arg domain.CreateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, arg)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockWebhookMockRecorder) CreateWebhookSubscription(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).CreateWebhookSubscription), ctx, arg)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockWebhook) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockWebhookMockRecorder) DeleteWebhookSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockWebhook) GetWebhookSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockWebhookMockRecorder) GetWebhookSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).GetWebhookSubscription), ctx, id)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockWebhook) ListWebhookDeliveries(ctx context.Context, arg domain.ListWebhookDeliveriesParams) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockWebhookMockRecorder) ListWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockWebhook)(nil).ListWebhookDeliveries), ctx, arg)
+}
+
+// ListWebhookSubscriptions mocks base method.
+func (m *MockWebhook) ListWebhookSubscriptions(ctx context.Context, arg domain.ListWebhookSubscriptionsParams) ([]domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx, arg)
+	ret0, _ := ret[0].([]domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockWebhookMockRecorder) ListWebhookSubscriptions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockWebhook)(nil).ListWebhookSubscriptions), ctx, arg)
+}
+
+// UpdateWebhookSubscription mocks base method.
+func (m *MockWebhook) UpdateWebhookSubscription(ctx context.Context, arg domain.UpdateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookSubscription", ctx, arg)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookSubscription indicates an expected call of UpdateWebhookSubscription.
+func (mr *MockWebhookMockRecorder) UpdateWebhookSubscription(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookSubscription", reflect.TypeOf((*MockWebhook)(nil).UpdateWebhookSubscription), ctx, arg)
+}
+
+// MockWebhookDispatcher is a mock of WebhookDispatcher interface.
+type MockWebhookDispatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookDispatcherMockRecorder
+}
+
+// MockWebhookDispatcherMockRecorder is the mock recorder for MockWebhookDispatcher.
+type MockWebhookDispatcherMockRecorder struct {
+	mock *MockWebhookDispatcher
+}
+
+// NewMockWebhookDispatcher creates a new mock instance.
+func NewMockWebhookDispatcher(ctrl *gomock.Controller) *MockWebhookDispatcher {
+	mock := &MockWebhookDispatcher{ctrl: ctrl}
+	mock.recorder = &MockWebhookDispatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookDispatcher) EXPECT() *MockWebhookDispatcherMockRecorder {
+	return m.recorder
+}
+
+// DeliverDue mocks base method.
+func (m *MockWebhookDispatcher) DeliverDue(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeliverDue", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeliverDue indicates an expected call of DeliverDue.
+func (mr *MockWebhookDispatcherMockRecorder) DeliverDue(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliverDue", reflect.TypeOf((*MockWebhookDispatcher)(nil).DeliverDue), ctx)
+}
+
+// Run mocks base method.
+func (m *MockWebhookDispatcher) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockWebhookDispatcherMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockWebhookDispatcher)(nil).Run), ctx, interval)
+}
+
+// MockAudit is a mock of Audit interface.
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit.
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance.
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAudit) ListAuditEvents(ctx context.Context, arg domain.ListAuditEventsParams) ([]domain.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]domain.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuditMockRecorder) ListAuditEvents(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAudit)(nil).ListAuditEvents), ctx, arg)
+}
+
+// Record mocks base method.
+func (m *MockAudit) Record(ctx context.Context, arg domain.CreateAuditEventParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditMockRecorder) Record(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAudit)(nil).Record), ctx, arg)
+}
+
+// MockLedger is a mock of Ledger interface.
+type MockLedger struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerMockRecorder
+}
+
+// MockLedgerMockRecorder is the mock recorder for MockLedger.
+type MockLedgerMockRecorder struct {
+	mock *MockLedger
+}
+
+// NewMockLedger creates a new mock instance.
+func NewMockLedger(ctrl *gomock.Controller) *MockLedger {
+	mock := &MockLedger{ctrl: ctrl}
+	mock.recorder = &MockLedgerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedger) EXPECT() *MockLedgerMockRecorder {
+	return m.recorder
+}
+
+// CheckAccountBalances mocks base method.
+func (m *MockLedger) CheckAccountBalances(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAccountBalances", ctx, arg)
+	ret0, _ := ret[0].(domain.LedgerBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckAccountBalances indicates an expected call of CheckAccountBalances.
+func (mr *MockLedgerMockRecorder) CheckAccountBalances(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAccountBalances", reflect.TypeOf((*MockLedger)(nil).CheckAccountBalances), ctx, arg)
+}
+
+// CheckLedger mocks base method.
+func (m *MockLedger) CheckLedger(ctx context.Context, arg domain.LedgerCheckParams) (domain.LedgerReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckLedger", ctx, arg)
+	ret0, _ := ret[0].(domain.LedgerReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckLedger indicates an expected call of CheckLedger.
+func (mr *MockLedgerMockRecorder) CheckLedger(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckLedger", reflect.TypeOf((*MockLedger)(nil).CheckLedger), ctx, arg)
+}
+
+// CheckTransferEntries mocks base method.
+func (m *MockLedger) CheckTransferEntries(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckTransferEntries", ctx, arg)
+	ret0, _ := ret[0].(domain.LedgerBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckTransferEntries indicates an expected call of CheckTransferEntries.
+func (mr *MockLedgerMockRecorder) CheckTransferEntries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckTransferEntries", reflect.TypeOf((*MockLedger)(nil).CheckTransferEntries), ctx, arg)
+}
+
+// MockUser is a mock of User interface.
+type MockUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserMockRecorder
+}
+
+// MockUserMockRecorder is the mock recorder for MockUser.
+type MockUserMockRecorder struct {
+	mock *MockUser
+}
+
+// NewMockUser creates a new mock instance.
+func NewMockUser(ctrl *gomock.Controller) *MockUser {
+	mock := &MockUser{ctrl: ctrl}
+	mock.recorder = &MockUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUser) EXPECT() *MockUserMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockUser) CreateUser(ctx context.Context, arg domain.CreateUserParams) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, arg)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUserMockRecorder) CreateUser(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUser)(nil).CreateUser), ctx, arg)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockUser) GetUserByUsername(ctx context.Context, arg domain.LoginUserParams) (domain.LoginUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, arg)
+	ret0, _ := ret[0].(domain.LoginUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockUserMockRecorder) GetUserByUsername(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockUser)(nil).GetUserByUsername), ctx, arg)
+}
+
+// Logout mocks base method.
+func (m *MockUser) Logout(ctx context.Context, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUserMockRecorder) Logout(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUser)(nil).Logout), ctx, refreshToken)
+}
+
+// RenewAccessToken mocks base method.
+func (m *MockUser) RenewAccessToken(ctx context.Context, refreshToken string) (domain.RenewAccessTokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewAccessToken", ctx, refreshToken)
+	ret0, _ := ret[0].(domain.RenewAccessTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewAccessToken indicates an expected call of RenewAccessToken.
+func (mr *MockUserMockRecorder) RenewAccessToken(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewAccessToken", reflect.TypeOf((*MockUser)(nil).RenewAccessToken), ctx, refreshToken)
+}
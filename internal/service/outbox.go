@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/publisher"
+)
+
+// OutboxRelay publishes the events written to the outbox.
+type OutboxRelay struct {
+	repo      repository.Tx
+	publisher publisher.Publisher
+	batchSize int
+}
+
+func NewOutboxRelay(repo repository.Tx, publisher publisher.Publisher, batchSize int) *OutboxRelay {
+	return &OutboxRelay{
+		repo:      repo,
+		publisher: publisher,
+		batchSize: batchSize,
+	}
+}
+
+// Run relays pending events every interval until ctx is cancelled. A batch
+// that is in flight when ctx is cancelled is finished first.
+func (r *OutboxRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// keep draining while full batches come back
+		for {
+			n, err := r.RelayPending(context.WithoutCancel(ctx))
+			if err != nil {
+				logger.FromContext(ctx).Error("outbox relay", "err", err)
+			}
+			if err != nil || n < r.batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes one batch of pending events and returns how many
+// of them went out.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	return r.repo.RelayOutboxEvents(ctx, r.batchSize, r.publish)
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, event domain.OutboxEvent) error {
+	return r.publisher.Publish(ctx, publisher.Message{
+		ID:      event.ID,
+		Key:     strconv.Itoa(event.AccountID),
+		Type:    event.EventType,
+		Payload: event.Payload,
+	})
+}
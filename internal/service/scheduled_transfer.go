@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/robfig/cron/v3"
+)
+
+type ScheduledTransferService struct {
+	repo repository.ScheduledTransfer
+}
+
+func NewScheduledTransferService(repo repository.ScheduledTransfer) *ScheduledTransferService {
+	return &ScheduledTransferService{
+		repo: repo,
+	}
+}
+
+// CreateScheduledTransfer stores a standing order that first runs at the
+// next time its schedule fires.
+func (s *ScheduledTransferService) CreateScheduledTransfer(ctx context.Context, arg domain.CreateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	next, err := nextRun(arg.Schedule, time.Now())
+	if err != nil {
+		return domain.ScheduledTransfer{}, err
+	}
+	arg.NextRunAt = next
+
+	return s.repo.CreateScheduledTransfer(ctx, arg)
+}
+
+func (s *ScheduledTransferService) GetScheduledTransfer(ctx context.Context, id int) (domain.ScheduledTransfer, error) {
+	return s.repo.GetScheduledTransfer(ctx, id)
+}
+
+func (s *ScheduledTransferService) ListScheduledTransfers(ctx context.Context, arg domain.ListScheduledTransfersParams) ([]domain.ScheduledTransfer, error) {
+	return s.repo.ListScheduledTransfers(ctx, arg)
+}
+
+// UpdateScheduledTransfer replaces the amount, schedule and active flag. The
+// next run is worked out again from now, so an update never triggers a run
+// that was missed while the transfer was paused.
+func (s *ScheduledTransferService) UpdateScheduledTransfer(ctx context.Context, arg domain.UpdateScheduledTransferParams) (domain.ScheduledTransfer, error) {
+	next, err := nextRun(arg.Schedule, time.Now())
+	if err != nil {
+		return domain.ScheduledTransfer{}, err
+	}
+	arg.NextRunAt = next
+
+	return s.repo.UpdateScheduledTransfer(ctx, arg)
+}
+
+func (s *ScheduledTransferService) DeleteScheduledTransfer(ctx context.Context, id int) error {
+	return s.repo.DeleteScheduledTransfer(ctx, id)
+}
+
+func (s *ScheduledTransferService) ListScheduledTransferRuns(ctx context.Context, arg domain.ListScheduledTransferRunsParams) ([]domain.ScheduledTransferRun, error) {
+	return s.repo.ListScheduledTransferRuns(ctx, arg)
+}
+
+// nextRun parses a standard five field cron expression or a descriptor like
+// "@weekly" or "@every 24h" and returns when it fires after now.
+func nextRun(schedule string, now time.Time) (time.Time, error) {
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", e.ErrInvalidSchedule, err)
+	}
+
+	next := sched.Next(now)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: %q never fires", e.ErrInvalidSchedule, schedule)
+	}
+	return next, nil
+}
+
+// ScheduledTransferWorker executes scheduled transfers once they are due.
+type ScheduledTransferWorker struct {
+	repo      repository.Tx
+	transfers TransferTx
+	batchSize int
+}
+
+func NewScheduledTransferWorker(repo repository.Tx, transfers TransferTx, batchSize int) *ScheduledTransferWorker {
+	return &ScheduledTransferWorker{
+		repo:      repo,
+		transfers: transfers,
+		batchSize: batchSize,
+	}
+}
+
+// Run polls for due transfers every interval until ctx is cancelled. A batch
+// that is in flight when ctx is cancelled is finished first, it runs on a
+// context that ctx doesn't cancel so that the claimed rows aren't rolled back
+// half way.
+func (w *ScheduledTransferWorker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// keep draining while full batches come back
+		for {
+			n, err := w.RunDue(context.WithoutCancel(ctx))
+			if err != nil {
+				logger.FromContext(ctx).Error("scheduled transfers", "err", err)
+			}
+			if err != nil || n < w.batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue executes one batch of due transfers and returns how many of them
+// were attempted.
+func (w *ScheduledTransferWorker) RunDue(ctx context.Context) (int, error) {
+	return w.repo.ProcessDueScheduledTransfers(ctx, time.Now(), w.batchSize, w.execute)
+}
+
+// execute moves the money for one occurrence. The idempotency key is tied to
+// the occurrence, so if recording the run fails after the transfer went
+// through, the next attempt replays it instead of paying twice. Missed
+// occurrences collapse into this one: the next run is counted from now.
+func (w *ScheduledTransferWorker) execute(ctx context.Context, transfer domain.ScheduledTransfer) domain.ScheduledRunOutcome {
+	outcome := domain.ScheduledRunOutcome{
+		Run: domain.CreateScheduledTransferRunParams{
+			ScheduledTransferID: transfer.ID,
+			ScheduledFor:        transfer.NextRunAt,
+		},
+	}
+
+	now := time.Now()
+	next, err := nextRun(transfer.Schedule, now)
+	if err != nil {
+		// schedules are validated on write, so this only happens if the
+		// parser changes under stored rows; look again tomorrow
+		next = now.Add(24 * time.Hour)
+	}
+	outcome.NextRunAt = next
+
+	result, err := w.transfers.TransferTx(ctx, domain.TransferTxParams{
+		FromAccountID:  transfer.FromAccountID,
+		ToAccountID:    transfer.ToAccountID,
+		Amount:         transfer.Amount,
+		IdempotencyKey: fmt.Sprintf("scheduled:%d:%d", transfer.ID, transfer.NextRunAt.Unix()),
+		Owner:          domain.SchedulerIdempotencyOwner,
+	})
+	if err != nil {
+		outcome.Run.Status = domain.ScheduledRunFailed
+		outcome.Run.Error = err.Error()
+		return outcome
+	}
+
+	outcome.Run.Status = domain.ScheduledRunSucceeded
+	outcome.Run.TransferID = result.Transfer.ID
+	return outcome
+}
@@ -2,12 +2,17 @@ package service
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/export"
 	"github.com/begenov/backend/internal/repository"
 	"github.com/begenov/backend/pkg/auth"
+	"github.com/begenov/backend/pkg/exchange"
 	"github.com/begenov/backend/pkg/hash"
+	"github.com/begenov/backend/pkg/publisher"
+	"github.com/begenov/backend/pkg/webhook"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
@@ -15,27 +20,132 @@ type Account interface {
 	CreateAccount(ctx context.Context, arg domain.CreateAccountParams) (domain.Account, error)
 	GetAccountByID(ctx context.Context, id int) (domain.Account, error)
 	ListAccounts(ctx context.Context, arg domain.ListAccountsParams) ([]domain.Account, error)
+	SetOverdraftLimit(ctx context.Context, arg domain.SetOverdraftLimitParams) (domain.Account, error)
+	ChangeAccountStatus(ctx context.Context, arg domain.ChangeAccountStatusParams) (domain.Account, error)
+	ListAccountStatusChanges(ctx context.Context, arg domain.ListAccountStatusChangesParams) ([]domain.AccountStatusChange, error)
 }
 
 type TransferTx interface {
 	TransferTx(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxResult, error)
+	ReverseTransfer(ctx context.Context, arg domain.ReverseTransferParams) (domain.TransferTxResult, error)
+	BatchTransfer(ctx context.Context, arg domain.BatchTransferParams) (domain.BatchTransferResult, error)
+}
+
+type Cash interface {
+	Deposit(ctx context.Context, arg domain.CashParams) (domain.CashTxResult, error)
+	Withdraw(ctx context.Context, arg domain.CashParams) (domain.CashTxResult, error)
+}
+
+type Transfer interface {
+	GetTransfer(ctx context.Context, id int) (domain.Transfer, error)
+	ListTransfers(ctx context.Context, arg domain.ListTransfersParams) ([]domain.Transfer, error)
+}
+
+type Entry interface {
+	ListEntries(ctx context.Context, arg domain.ListEntriesParams) ([]domain.Entry, error)
+	GetStatement(ctx context.Context, arg domain.StatementParams) (domain.Statement, error)
+}
+
+type Export interface {
+	ExportStatement(ctx context.Context, w io.Writer, format export.Format, arg domain.StatementParams) error
+}
+
+type ScheduledTransfer interface {
+	CreateScheduledTransfer(ctx context.Context, arg domain.CreateScheduledTransferParams) (domain.ScheduledTransfer, error)
+	GetScheduledTransfer(ctx context.Context, id int) (domain.ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, arg domain.ListScheduledTransfersParams) ([]domain.ScheduledTransfer, error)
+	UpdateScheduledTransfer(ctx context.Context, arg domain.UpdateScheduledTransferParams) (domain.ScheduledTransfer, error)
+	DeleteScheduledTransfer(ctx context.Context, id int) error
+	ListScheduledTransferRuns(ctx context.Context, arg domain.ListScheduledTransferRunsParams) ([]domain.ScheduledTransferRun, error)
+}
+
+type Scheduler interface {
+	Run(ctx context.Context, interval time.Duration)
+	RunDue(ctx context.Context) (int, error)
+}
+
+type Relay interface {
+	Run(ctx context.Context, interval time.Duration)
+	RelayPending(ctx context.Context) (int, error)
+}
+
+type Sweeper interface {
+	Run(ctx context.Context, interval time.Duration)
+	Sweep(ctx context.Context) (int, error)
+}
+
+type Webhook interface {
+	CreateWebhookSubscription(ctx context.Context, arg domain.CreateWebhookSubscriptionParams) (domain.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, arg domain.ListWebhookSubscriptionsParams) ([]domain.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, arg domain.UpdateWebhookSubscriptionParams) (domain.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int) error
+	ListWebhookDeliveries(ctx context.Context, arg domain.ListWebhookDeliveriesParams) ([]domain.WebhookDelivery, error)
+}
+
+type WebhookDispatcher interface {
+	Run(ctx context.Context, interval time.Duration)
+	DeliverDue(ctx context.Context) (int, error)
+}
+
+type Audit interface {
+	Record(ctx context.Context, arg domain.CreateAuditEventParams) error
+	ListAuditEvents(ctx context.Context, arg domain.ListAuditEventsParams) ([]domain.AuditEvent, error)
+}
+
+type Ledger interface {
+	CheckLedger(ctx context.Context, arg domain.LedgerCheckParams) (domain.LedgerReport, error)
+	CheckAccountBalances(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error)
+	CheckTransferEntries(ctx context.Context, arg domain.LedgerBatchParams) (domain.LedgerBatch, error)
 }
 
 type User interface {
 	CreateUser(ctx context.Context, arg domain.CreateUserParams) (domain.User, error)
-	GetUserByUsername(ctx context.Context, username string, password string) (domain.LoginUserResponse, error)
+	GetUserByUsername(ctx context.Context, arg domain.LoginUserParams) (domain.LoginUserResponse, error)
+	RenewAccessToken(ctx context.Context, refreshToken string) (domain.RenewAccessTokenResponse, error)
+	Logout(ctx context.Context, refreshToken string) error
 }
 
 type Service struct {
 	Account    Account
 	TransferTx TransferTx
+	Cash       Cash
+	Transfer   Transfer
+	Entry      Entry
+	Export     Export
+	Ledger     Ledger
+	Audit      Audit
 	User       User
+
+	ScheduledTransfer ScheduledTransfer
+	Scheduler         Scheduler
+	Relay             Relay
+	Sweeper           Sweeper
+
+	Webhook           Webhook
+	WebhookDispatcher WebhookDispatcher
 }
 
-func NewService(repo *repository.Repository, hash hash.PasswordHasher, token auth.TokenManager, accessTokenDuration time.Duration) *Service {
+func NewService(repo *repository.Repository, hash hash.PasswordHasher, token auth.TokenManager, rates exchange.RateProvider, scheduledBatchSize int, publisher publisher.Publisher, relayBatchSize int, sender *webhook.Sender, webhookBatchSize int, sweepBatchSize int, accessTokenDuration time.Duration, refreshTokenDuration time.Duration) *Service {
+	transferTx := NewTransferService(repo, repo.Account, rates)
+
 	return &Service{
-		Account:    NewAccountService(repo.Account),
-		TransferTx: NewTransferService(repo),
-		User:       NewUserService(repo.User, hash, token, accessTokenDuration),
+		Account:    NewAccountService(repo.Account, repo),
+		TransferTx: transferTx,
+		Cash:       NewCashService(repo, repo.Account),
+		Transfer:   NewTransfersService(repo.Transfer),
+		Entry:      NewEntryService(repo.Entry),
+		Export:     NewExportService(repo.Entry),
+		Ledger:     NewLedgerService(repo.Ledger),
+		Audit:      NewAuditService(repo.Audit),
+		User:       NewUserService(repo.User, repo.Session, hash, token, accessTokenDuration, refreshTokenDuration),
+
+		ScheduledTransfer: NewScheduledTransferService(repo.ScheduledTransfer),
+		Scheduler:         NewScheduledTransferWorker(repo, transferTx, scheduledBatchSize),
+		Relay:             NewOutboxRelay(repo, publisher, relayBatchSize),
+		Sweeper:           NewIdempotencySweeper(repo.Idempotency, sweepBatchSize),
+
+		Webhook:           NewWebhookService(repo.Webhook),
+		WebhookDispatcher: NewWebhookWorker(repo, sender, webhookBatchSize),
 	}
 }
@@ -2,21 +2,195 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"math"
 
 	"github.com/begenov/backend/internal/domain"
 	"github.com/begenov/backend/internal/repository"
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/exchange"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/metrics"
 )
 
 type TransferTxService struct {
-	repo repository.Tx
+	repo     repository.Tx
+	accounts repository.Account
+	rates    exchange.RateProvider
 }
 
-func NewTransferService(repo repository.Tx) *TransferTxService {
+func NewTransferService(repo repository.Tx, accounts repository.Account, rates exchange.RateProvider) *TransferTxService {
 	return &TransferTxService{
-		repo: repo,
+		repo:     repo,
+		accounts: accounts,
+		rates:    rates,
 	}
 }
 
+// TransferTx moves arg.Amount out of the from account in its currency and
+// credits the converted amount to the to account in its currency.
 func (s *TransferTxService) TransferTx(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxResult, error) {
-	return s.repo.TransferTx(ctx, arg)
+	log := logger.FromContext(ctx).With("from_account_id", arg.FromAccountID, "to_account_id", arg.ToAccountID, "amount", arg.Amount)
+
+	arg, err := s.convert(ctx, arg)
+	if err != nil {
+		log.Warn("transfer failed", "err", err)
+		observeTransferFailure(err)
+		return domain.TransferTxResult{}, err
+	}
+
+	result, err := s.repo.TransferTx(ctx, arg)
+	if err != nil {
+		log.Warn("transfer failed", "err", err)
+		observeTransferFailure(err)
+		return domain.TransferTxResult{}, err
+	}
+	log.Info("transfer booked", "transfer_id", result.Transfer.ID, "retries", result.Retries)
+	observeTransfer(result)
+	return result, nil
+}
+
+// BatchTransfer books a list of transfers. In atomic mode the whole batch
+// fails with an *e.BatchTransferError naming the first transfer that could
+// not be booked. In best effort mode every transfer is booked on its own and
+// failures are only reported in the result.
+func (s *TransferTxService) BatchTransfer(ctx context.Context, arg domain.BatchTransferParams) (domain.BatchTransferResult, error) {
+	result := domain.BatchTransferResult{
+		Mode:  arg.Mode,
+		Items: make([]domain.BatchTransferItem, 0, len(arg.Transfers)),
+	}
+
+	if arg.Mode == domain.BatchBestEffort {
+		for i, transfer := range arg.Transfers {
+			item := domain.BatchTransferItem{Index: i}
+
+			transferResult, err := s.TransferTx(ctx, transfer)
+			if err != nil {
+				item.Error = err.Error()
+				result.Failed++
+			} else {
+				item.Result = &transferResult
+				result.Succeeded++
+			}
+			result.Items = append(result.Items, item)
+		}
+		return result, nil
+	}
+
+	transfers := make([]domain.TransferTxParams, 0, len(arg.Transfers))
+	for i, transfer := range arg.Transfers {
+		transfer, err := s.convert(ctx, transfer)
+		if err != nil {
+			observeTransferFailure(err)
+			return domain.BatchTransferResult{}, &e.BatchTransferError{Index: i, Err: err}
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	results, err := s.repo.BatchTransferTx(ctx, transfers)
+	if err != nil {
+		observeTransferFailure(err)
+		return domain.BatchTransferResult{}, err
+	}
+
+	for i := range results {
+		observeTransfer(results[i])
+		result.Items = append(result.Items, domain.BatchTransferItem{Index: i, Result: &results[i]})
+	}
+	result.Succeeded = len(results)
+	return result, nil
+}
+
+// convert fills in the rate between the currencies of the two accounts and
+// the amount credited to the to account. Money only leaves a settlement
+// account through cash bookings and reversals, never through a transfer.
+func (s *TransferTxService) convert(ctx context.Context, arg domain.TransferTxParams) (domain.TransferTxParams, error) {
+	fromAccount, err := s.accounts.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return arg, err
+	}
+	if fromAccount.Owner == domain.SettlementOwner {
+		return arg, e.ErrSettlementAccount
+	}
+	toAccount, err := s.accounts.GetAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return arg, err
+	}
+
+	arg.ExchangeRate, err = s.rates.Rate(ctx, fromAccount.Currency, toAccount.Currency)
+	if err != nil {
+		return arg, err
+	}
+
+	arg.ToAmount = int(math.Round(float64(arg.Amount) * arg.ExchangeRate))
+	if arg.ToAmount <= 0 {
+		return arg, e.ErrAmountTooSmall
+	}
+	return arg, nil
+}
+
+// observeTransfer counts a booked transfer, replays of a known idempotency
+// key were counted when they were booked.
+func observeTransfer(result domain.TransferTxResult) {
+	metrics.TransferRetries.Add(float64(result.Retries))
+	if result.Replayed {
+		return
+	}
+	metrics.TransfersCreated.WithLabelValues(result.FromAccount.Currency).Inc()
+	metrics.AmountMoved.WithLabelValues(result.FromAccount.Currency).Add(float64(result.Transfer.Amount))
+}
+
+func observeTransferFailure(err error) {
+	metrics.TransfersFailed.WithLabelValues(transferFailureReason(err)).Inc()
+}
+
+// transferFailureReason keeps the reason label to a known set of values.
+func transferFailureReason(err error) string {
+	switch {
+	case errors.Is(err, e.ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, e.ErrAccountFrozen):
+		return "account_frozen"
+	case errors.Is(err, e.ErrAccountClosed):
+		return "account_closed"
+	case errors.Is(err, sql.ErrNoRows):
+		return "account_not_found"
+	case errors.Is(err, e.ErrRateNotFound):
+		return "rate_not_found"
+	case errors.Is(err, e.ErrAmountTooSmall):
+		return "amount_too_small"
+	case errors.Is(err, e.ErrSettlementAccount):
+		return "settlement_account"
+	case errors.Is(err, e.ErrIdempotencyKeyConflict), errors.Is(err, e.ErrIdempotencyKeyInProgress):
+		return "idempotency_conflict"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "internal"
+	}
+}
+
+// ReverseTransfer compensates arg.Amount of the transfer, or all of what is
+// left of it when no amount is given.
+func (s *TransferTxService) ReverseTransfer(ctx context.Context, arg domain.ReverseTransferParams) (domain.TransferTxResult, error) {
+	return s.repo.ReverseTransferTx(ctx, arg)
+}
+
+type TransferService struct {
+	repo repository.Transfer
+}
+
+func NewTransfersService(repo repository.Transfer) *TransferService {
+	return &TransferService{
+		repo: repo,
+	}
+}
+
+func (s *TransferService) GetTransfer(ctx context.Context, id int) (domain.Transfer, error) {
+	return s.repo.GetTransfer(ctx, id)
+}
+
+func (s *TransferService) ListTransfers(ctx context.Context, arg domain.ListTransfersParams) ([]domain.Transfer, error) {
+	return s.repo.ListTransfers(ctx, arg)
 }
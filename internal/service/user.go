@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/begenov/backend/internal/domain"
@@ -12,18 +16,22 @@ import (
 )
 
 type UserService struct {
-	repo                repository.User
-	hash                hash.PasswordHasher
-	token               auth.TokenManager
-	accessTokenDuration time.Duration
+	repo                 repository.User
+	session              repository.Session
+	hash                 hash.PasswordHasher
+	token                auth.TokenManager
+	accessTokenDuration  time.Duration
+	refreshTokenDuration time.Duration
 }
 
-func NewUserService(repo repository.User, hash hash.PasswordHasher, token auth.TokenManager, accessTokenDuration time.Duration) *UserService {
+func NewUserService(repo repository.User, session repository.Session, hash hash.PasswordHasher, token auth.TokenManager, accessTokenDuration time.Duration, refreshTokenDuration time.Duration) *UserService {
 	return &UserService{
-		repo:                repo,
-		hash:                hash,
-		token:               token,
-		accessTokenDuration: accessTokenDuration,
+		repo:                 repo,
+		session:              session,
+		hash:                 hash,
+		token:                token,
+		accessTokenDuration:  accessTokenDuration,
+		refreshTokenDuration: refreshTokenDuration,
 	}
 }
 
@@ -36,23 +44,43 @@ func (s *UserService) CreateUser(ctx context.Context, arg domain.CreateUserParam
 	return s.repo.CreateUser(ctx, arg)
 }
 
-func (s *UserService) GetUserByUsername(ctx context.Context, username string, password string) (domain.LoginUserResponse, error) {
+func (s *UserService) GetUserByUsername(ctx context.Context, arg domain.LoginUserParams) (domain.LoginUserResponse, error) {
 
-	user, err := s.repo.GetUser(ctx, username)
+	user, err := s.repo.GetUser(ctx, arg.Username)
 	if err != nil {
 		return domain.LoginUserResponse{}, err
 	}
-	err = s.hash.CompareHashAndPassword(user.HashedPassword, password)
+	err = s.hash.CompareHashAndPassword(user.HashedPassword, arg.Password)
 	if err != nil {
 		return domain.LoginUserResponse{}, e.ErrPassword
 	}
-	accessToken, err := s.token.NewJWT(user.Username, s.accessTokenDuration)
+	accessToken, err := s.token.NewJWT(user.Username, user.Role, s.accessTokenDuration)
 	if err != nil {
 		return domain.LoginUserResponse{}, e.ErrInvalidToken
 	}
 
+	refreshToken, err := s.token.NewRefreshToken()
+	if err != nil {
+		return domain.LoginUserResponse{}, err
+	}
+
+	session, err := s.session.CreateSession(ctx, domain.CreateSessionParams{
+		Username:         user.Username,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		UserAgent:        arg.UserAgent,
+		ClientIP:         arg.ClientIP,
+		ExpiresAt:        time.Now().Add(s.refreshTokenDuration),
+	})
+	if err != nil {
+		return domain.LoginUserResponse{}, err
+	}
+
 	response := domain.LoginUserResponse{
-		AccessToken: accessToken,
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  time.Now().Add(s.accessTokenDuration),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
 		User: domain.UserResponse{
 			Username:          user.Username,
 			FullName:          user.FullName,
@@ -63,3 +91,84 @@ func (s *UserService) GetUserByUsername(ctx context.Context, username string, pa
 	}
 	return response, nil
 }
+
+// RenewAccessToken issues a new access token for a valid refresh token and
+// rotates the refresh token, so the one passed in can't be used again.
+func (s *UserService) RenewAccessToken(ctx context.Context, refreshToken string) (domain.RenewAccessTokenResponse, error) {
+	session, err := s.activeSession(ctx, refreshToken)
+	if err != nil {
+		return domain.RenewAccessTokenResponse{}, err
+	}
+
+	newRefreshToken, err := s.token.NewRefreshToken()
+	if err != nil {
+		return domain.RenewAccessTokenResponse{}, err
+	}
+
+	session, err = s.session.RotateSession(ctx, domain.RotateSessionParams{
+		ID:                  session.ID,
+		OldRefreshTokenHash: session.RefreshTokenHash,
+		RefreshTokenHash:    hashRefreshToken(newRefreshToken),
+		ExpiresAt:           time.Now().Add(s.refreshTokenDuration),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// another request rotated the token first
+			return domain.RenewAccessTokenResponse{}, e.ErrInvalidToken
+		}
+		return domain.RenewAccessTokenResponse{}, err
+	}
+
+	// the role is read again so a changed role applies from the next renewal
+	user, err := s.repo.GetUser(ctx, session.Username)
+	if err != nil {
+		return domain.RenewAccessTokenResponse{}, err
+	}
+
+	accessToken, err := s.token.NewJWT(user.Username, user.Role, s.accessTokenDuration)
+	if err != nil {
+		return domain.RenewAccessTokenResponse{}, e.ErrInvalidToken
+	}
+
+	return domain.RenewAccessTokenResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  time.Now().Add(s.accessTokenDuration),
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// Logout revokes the session the refresh token belongs to.
+func (s *UserService) Logout(ctx context.Context, refreshToken string) error {
+	session, err := s.activeSession(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.session.BlockSession(ctx, session.ID)
+}
+
+func (s *UserService) activeSession(ctx context.Context, refreshToken string) (domain.Session, error) {
+	session, err := s.session.GetSessionByRefreshToken(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Session{}, e.ErrInvalidToken
+		}
+		return domain.Session{}, err
+	}
+
+	if session.IsBlocked {
+		return domain.Session{}, e.ErrBlockedSession
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return domain.Session{}, e.ErrExpiredToken
+	}
+
+	return session, nil
+}
+
+// hashRefreshToken returns the form of the token kept in the database, so a
+// leaked sessions table can't be used to renew tokens.
+func hashRefreshToken(refreshToken string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(refreshToken)))
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/begenov/backend/internal/domain"
+	"github.com/begenov/backend/internal/repository"
+	"github.com/begenov/backend/pkg/logger"
+	"github.com/begenov/backend/pkg/webhook"
+)
+
+const (
+	// a delivery is given up after this many failed attempts
+	maxWebhookAttempts = 8
+	webhookRetryBase   = 30 * time.Second
+	webhookRetryMax    = 6 * time.Hour
+)
+
+type WebhookService struct {
+	repo repository.Webhook
+}
+
+func NewWebhookService(repo repository.Webhook) *WebhookService {
+	return &WebhookService{
+		repo: repo,
+	}
+}
+
+// CreateWebhookSubscription stores the subscription with a fresh signing
+// secret. The secret is only returned here.
+func (s *WebhookService) CreateWebhookSubscription(ctx context.Context, arg domain.CreateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	arg.Secret = hex.EncodeToString(secret)
+
+	return s.repo.CreateWebhookSubscription(ctx, arg)
+}
+
+func (s *WebhookService) GetWebhookSubscription(ctx context.Context, id int) (domain.WebhookSubscription, error) {
+	return s.repo.GetWebhookSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListWebhookSubscriptions(ctx context.Context, arg domain.ListWebhookSubscriptionsParams) ([]domain.WebhookSubscription, error) {
+	return s.repo.ListWebhookSubscriptions(ctx, arg)
+}
+
+func (s *WebhookService) UpdateWebhookSubscription(ctx context.Context, arg domain.UpdateWebhookSubscriptionParams) (domain.WebhookSubscription, error) {
+	return s.repo.UpdateWebhookSubscription(ctx, arg)
+}
+
+func (s *WebhookService) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	return s.repo.DeleteWebhookSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListWebhookDeliveries(ctx context.Context, arg domain.ListWebhookDeliveriesParams) ([]domain.WebhookDelivery, error) {
+	return s.repo.ListWebhookDeliveries(ctx, arg)
+}
+
+// WebhookWorker posts queued deliveries to their subscribers.
+type WebhookWorker struct {
+	repo      repository.Tx
+	sender    *webhook.Sender
+	batchSize int
+	// how long a claimed batch is hidden from other workers, long enough
+	// for every receiver of the batch to time out
+	lease time.Duration
+}
+
+func NewWebhookWorker(repo repository.Tx, sender *webhook.Sender, batchSize int) *WebhookWorker {
+	return &WebhookWorker{
+		repo:      repo,
+		sender:    sender,
+		batchSize: batchSize,
+		lease:     time.Duration(batchSize)*sender.Timeout() + time.Minute,
+	}
+}
+
+// Run delivers due webhooks every interval until ctx is cancelled. A batch
+// that is in flight when ctx is cancelled is finished first.
+func (w *WebhookWorker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// keep draining while full batches come back
+		for {
+			n, err := w.DeliverDue(context.WithoutCancel(ctx))
+			if err != nil {
+				logger.FromContext(ctx).Error("webhook worker", "err", err)
+			}
+			if err != nil || n < w.batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes one attempt at every delivery that is due and returns how
+// many were attempted.
+func (w *WebhookWorker) DeliverDue(ctx context.Context) (int, error) {
+	return w.repo.ProcessDueWebhookDeliveries(ctx, time.Now(), w.batchSize, w.lease, w.deliver)
+}
+
+// deliver posts the delivery once. A failure is retried with exponential
+// backoff until maxWebhookAttempts is reached, then the delivery is dead.
+func (w *WebhookWorker) deliver(ctx context.Context, delivery domain.DueWebhookDelivery) domain.WebhookAttempt {
+	code, err := w.sender.Send(ctx, delivery.URL, delivery.Secret, webhook.Event{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		AccountID: delivery.AccountID,
+		Data:      delivery.Payload,
+	})
+	if err == nil {
+		return domain.WebhookAttempt{
+			Status:         domain.WebhookDeliverySucceeded,
+			ResponseStatus: code,
+			NextAttemptAt:  delivery.NextAttemptAt,
+		}
+	}
+
+	attempt := domain.WebhookAttempt{
+		Status:         domain.WebhookDeliveryPending,
+		ResponseStatus: code,
+		Error:          err.Error(),
+		NextAttemptAt:  time.Now().Add(webhook.Backoff(delivery.Attempts+1, webhookRetryBase, webhookRetryMax)),
+	}
+	if delivery.Attempts+1 >= maxWebhookAttempts {
+		attempt.Status = domain.WebhookDeliveryDead
+		attempt.NextAttemptAt = delivery.NextAttemptAt
+	}
+	return attempt
+}
@@ -0,0 +1,11 @@
+CREATE TABLE "idempotency_keys" (
+  "key" varchar PRIMARY KEY,
+  "request_hash" varchar NOT NULL,
+  "transfer_id" bigint,
+  "response" jsonb,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMENT ON COLUMN "idempotency_keys"."response" IS 'original transfer result, replayed on retry';
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- entries written before this migration keep a NULL transfer_id
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
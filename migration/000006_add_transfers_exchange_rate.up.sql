@@ -0,0 +1,11 @@
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+UPDATE "transfers" SET "to_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" numeric NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive, in the currency of the from account';
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited, in the currency of the to account';
@@ -0,0 +1,3 @@
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "external_ref";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "kind";
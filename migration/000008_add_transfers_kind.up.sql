@@ -0,0 +1,9 @@
+ALTER TABLE "transfers" ADD COLUMN "kind" varchar NOT NULL DEFAULT 'transfer';
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_kind_check" CHECK ("kind" IN ('transfer', 'deposit', 'withdrawal'));
+
+ALTER TABLE "transfers" ADD COLUMN "external_ref" varchar;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_external_ref_key" UNIQUE ("external_ref");
+
+COMMENT ON COLUMN "transfers"."external_ref" IS 'reference of the deposit or withdrawal at the external processor';
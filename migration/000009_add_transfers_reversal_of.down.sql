@@ -0,0 +1,9 @@
+ALTER TABLE "transfers" DROP CONSTRAINT "transfers_kind_check";
+
+-- a reversal moves money back like any other transfer; its entries reference
+-- the row, so it is kept as a plain transfer instead of being deleted
+UPDATE "transfers" SET "kind" = 'transfer' WHERE "kind" = 'reversal';
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "reversal_of";
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_kind_check" CHECK ("kind" IN ('transfer', 'deposit', 'withdrawal'));
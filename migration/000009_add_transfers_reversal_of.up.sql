@@ -0,0 +1,9 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("reversal_of");
+
+ALTER TABLE "transfers" DROP CONSTRAINT "transfers_kind_check";
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_kind_check" CHECK ("kind" IN ('transfer', 'deposit', 'withdrawal', 'reversal'));
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'transfer this one compensates, fully or in part';
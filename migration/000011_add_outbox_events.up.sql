@@ -0,0 +1,12 @@
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "published_at" timestamptz
+);
+
+CREATE INDEX ON "outbox_events" ("id") WHERE "published_at" IS NULL;
+
+COMMENT ON COLUMN "outbox_events"."account_id" IS 'events of one account are published in id order';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "account_status_changes";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "status";
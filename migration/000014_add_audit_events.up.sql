@@ -0,0 +1,43 @@
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL DEFAULT '',
+  "action" varchar NOT NULL,
+  "targets" jsonb NOT NULL DEFAULT '{}',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "outcome" varchar NOT NULL,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "audit_events" ADD CONSTRAINT "audit_events_outcome_check" CHECK ("outcome" IN ('success', 'failure'));
+
+CREATE INDEX ON "audit_events" ("actor", "created_at");
+
+CREATE INDEX ON "audit_events" ("action", "created_at");
+
+CREATE INDEX ON "audit_events" USING gin ("targets" jsonb_path_ops);
+
+CREATE FUNCTION "audit_events_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only, % is not allowed', TG_OP
+    USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_no_update_delete"
+  BEFORE UPDATE OR DELETE ON "audit_events"
+  FOR EACH ROW EXECUTE FUNCTION "audit_events_append_only"();
+
+CREATE TRIGGER "audit_events_no_truncate"
+  BEFORE TRUNCATE ON "audit_events"
+  FOR EACH STATEMENT EXECUTE FUNCTION "audit_events_append_only"();
+
+REVOKE UPDATE, DELETE, TRUNCATE ON "audit_events" FROM PUBLIC;
+
+COMMENT ON TABLE "audit_events" IS 'append-only, rows can neither be updated nor deleted';
+
+COMMENT ON COLUMN "audit_events"."actor" IS 'username of the caller, empty when unknown';
+
+COMMENT ON COLUMN "audit_events"."targets" IS 'ids of the touched objects by kind, e.g. {"account": [1, 2]}';
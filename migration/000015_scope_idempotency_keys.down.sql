@@ -0,0 +1,13 @@
+ALTER TABLE "idempotency_keys" DROP COLUMN IF EXISTS "expires_at";
+
+ALTER TABLE "idempotency_keys" DROP CONSTRAINT "idempotency_keys_pkey";
+
+-- several users may hold the same key, the latest one keeps it
+DELETE FROM "idempotency_keys" k
+USING "idempotency_keys" newer
+WHERE newer."key" = k."key"
+  AND (newer."created_at", newer."owner") > (k."created_at", k."owner");
+
+ALTER TABLE "idempotency_keys" ADD PRIMARY KEY ("key");
+
+ALTER TABLE "idempotency_keys" DROP COLUMN IF EXISTS "owner";
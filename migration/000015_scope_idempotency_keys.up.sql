@@ -0,0 +1,23 @@
+ALTER TABLE "idempotency_keys" ADD COLUMN "owner" varchar;
+
+-- a key that booked a transfer belongs to the owner of its from account,
+-- the others never finished and are dropped
+UPDATE "idempotency_keys" k
+SET "owner" = a."owner"
+FROM "transfers" t
+JOIN "accounts" a ON a."id" = t."from_account_id"
+WHERE t."id" = k."transfer_id";
+
+DELETE FROM "idempotency_keys" WHERE "owner" IS NULL;
+
+ALTER TABLE "idempotency_keys" ALTER COLUMN "owner" SET NOT NULL;
+
+ALTER TABLE "idempotency_keys" DROP CONSTRAINT "idempotency_keys_pkey";
+ALTER TABLE "idempotency_keys" ADD PRIMARY KEY ("owner", "key");
+
+ALTER TABLE "idempotency_keys" ADD COLUMN "expires_at" timestamptz NOT NULL DEFAULT (now() + interval '24 hours');
+
+CREATE INDEX ON "idempotency_keys" ("expires_at");
+
+COMMENT ON COLUMN "idempotency_keys"."owner" IS 'user the key belongs to, keys of different users never collide';
+COMMENT ON COLUMN "idempotency_keys"."expires_at" IS 'after this the key can be reused and is swept';
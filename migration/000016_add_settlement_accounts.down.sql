@@ -0,0 +1,13 @@
+-- settlement accounts that were booked against are part of the ledger and
+-- stay, together with their owner
+DELETE FROM "accounts" a
+WHERE a."owner" = 'bank_settlement'
+  AND NOT EXISTS (SELECT 1 FROM "entries" WHERE "account_id" = a."id")
+  AND NOT EXISTS (SELECT 1 FROM "transfers" WHERE "from_account_id" = a."id" OR "to_account_id" = a."id")
+  AND NOT EXISTS (SELECT 1 FROM "scheduled_transfers" WHERE "from_account_id" = a."id" OR "to_account_id" = a."id")
+  AND NOT EXISTS (SELECT 1 FROM "webhook_deliveries" WHERE "account_id" = a."id")
+  AND NOT EXISTS (SELECT 1 FROM "account_status_changes" WHERE "account_id" = a."id");
+
+DELETE FROM "users" u
+WHERE u."username" = 'bank_settlement'
+  AND NOT EXISTS (SELECT 1 FROM "accounts" WHERE "owner" = u."username");
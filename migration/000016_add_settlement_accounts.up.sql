@@ -0,0 +1,12 @@
+-- the underscore keeps the name out of reach of sign ups, which only accept
+-- alphanumeric usernames, and no password hashes to '!'
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "role")
+VALUES ('bank_settlement', '!', 'Settlement', 'settlement@simplebank.invalid', 'system')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "accounts" ("owner", "balance", "currency")
+VALUES
+  ('bank_settlement', 0, 'USD'),
+  ('bank_settlement', 0, 'EUR'),
+  ('bank_settlement', 0, 'CAD')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+-- fails while an owner has a closed and an open account in the same currency
+DROP INDEX IF EXISTS "accounts_owner_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_owner_currency_idx" ON "accounts" ("owner", "currency");
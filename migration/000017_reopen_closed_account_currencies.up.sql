@@ -0,0 +1,5 @@
+-- an owner holds one open account per currency; closed ones keep their row
+-- for the history and no longer take up the slot
+DROP INDEX IF EXISTS "accounts_owner_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_owner_currency_idx" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
@@ -0,0 +1,20 @@
+package auth
+
+import "context"
+
+// Identity describes the authenticated caller of a request.
+type Identity struct {
+	Username string
+	Role     string
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
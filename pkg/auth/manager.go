@@ -1,17 +1,17 @@
 package auth
 
 import (
+	"crypto/rand"
 	"errors"
 	"fmt"
-	"math/rand"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 type TokenManager interface {
-	NewJWT(adminID string, ttl time.Duration) (string, error)
-	Parse(accessToken string) (string, error)
+	NewJWT(username string, role string, ttl time.Duration) (string, error)
+	Parse(accessToken string) (Identity, error)
 	NewRefreshToken() (string, error)
 }
 
@@ -19,6 +19,11 @@ type Manager struct {
 	signInKey string
 }
 
+type claims struct {
+	jwt.StandardClaims
+	Role string `json:"role"`
+}
+
 func NewManager(signInKey string) (*Manager, error) {
 	if signInKey == "" {
 		return nil, errors.New("empty signing key")
@@ -28,12 +33,15 @@ func NewManager(signInKey string) (*Manager, error) {
 	}, nil
 }
 
-func (m *Manager) NewJWT(adminID string, ttl time.Duration) (string, error) {
+func (m *Manager) NewJWT(username string, role string, ttl time.Duration) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
 
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(ttl).Unix(),
-			Subject:   adminID,
+		claims{
+			StandardClaims: jwt.StandardClaims{
+				ExpiresAt: time.Now().Add(ttl).Unix(),
+				Subject:   username,
+			},
+			Role: role,
 		},
 	)
 	tokenRes, err := token.SignedString([]byte(m.signInKey))
@@ -44,9 +52,9 @@ func (m *Manager) NewJWT(adminID string, ttl time.Duration) (string, error) {
 	return tokenRes, nil
 }
 
-func (m *Manager) Parse(accessToken string) (string, error) {
+func (m *Manager) Parse(accessToken string) (Identity, error) {
 
-	token, err := jwt.Parse(accessToken, func(t *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaims(accessToken, &claims{}, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
@@ -54,21 +62,22 @@ func (m *Manager) Parse(accessToken string) (string, error) {
 	})
 
 	if err != nil {
-		return "", err
+		return Identity{}, err
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
+	c, ok := token.Claims.(*claims)
 	if !ok {
-		return "", fmt.Errorf("error get user claims from token")
+		return Identity{}, fmt.Errorf("error get user claims from token")
 	}
-	return claims["sub"].(string), nil
+	return Identity{
+		Username: c.Subject,
+		Role:     c.Role,
+	}, nil
 }
 
 func (m *Manager) NewRefreshToken() (string, error) {
 	b := make([]byte, 32)
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
 
-	if _, err := r.Read(b); err != nil {
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("%x", b), nil
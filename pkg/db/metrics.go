@@ -0,0 +1,15 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// NewStatsCollector exposes db.Stats() as go_sql_* metrics: open, in use and
+// idle connections, the pool limit, and how often and how long callers
+// waited for a connection. dbName tells pools apart.
+func NewStatsCollector(db *sql.DB, dbName string) prometheus.Collector {
+	return collectors.NewDBStatsCollector(db, dbName)
+}
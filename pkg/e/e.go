@@ -15,10 +15,52 @@ const (
 
 var ErrRecordNotFound = pgx.ErrNoRows
 var (
-	ErrPassword     = fmt.Errorf("incorrect password")
-	ErrExpiredToken = fmt.Errorf("token has expired")
-	ErrInvalidToken = fmt.Errorf("token is invalid")
+	ErrPassword       = fmt.Errorf("incorrect password")
+	ErrExpiredToken   = fmt.Errorf("token has expired")
+	ErrInvalidToken   = fmt.Errorf("token is invalid")
+	ErrBlockedSession = fmt.Errorf("session is blocked")
 )
+var (
+	ErrIdempotencyKeyConflict   = fmt.Errorf("idempotency key was used with a different request")
+	ErrIdempotencyKeyInProgress = fmt.Errorf("request with this idempotency key is still in progress")
+)
+var (
+	ErrRateNotFound   = fmt.Errorf("exchange rate not found")
+	ErrAmountTooSmall = fmt.Errorf("amount is too small to convert")
+)
+var ErrInsufficientFunds = fmt.Errorf("insufficient funds")
+var (
+	ErrDuplicateExternalRef = fmt.Errorf("external reference was already booked")
+	ErrNoSettlementAccount  = fmt.Errorf("no settlement account for the currency")
+	ErrSettlementAccount    = fmt.Errorf("money can't be transferred out of a settlement account")
+)
+var (
+	ErrTransferNotReversible = fmt.Errorf("only transfers between accounts can be reversed")
+	ErrReversalExceedsAmount = fmt.Errorf("reversal exceeds the amount left to reverse")
+)
+var ErrInvalidSchedule = fmt.Errorf("invalid schedule")
+var (
+	ErrAccountFrozen           = fmt.Errorf("account is frozen")
+	ErrAccountClosed           = fmt.Errorf("account is closed")
+	ErrInvalidStatusTransition = fmt.Errorf("account status can't change that way")
+	ErrNonZeroBalance          = fmt.Errorf("account balance must be zero to close it")
+)
+var ErrLockOutsideTx = fmt.Errorf("row locks can only be taken inside a transaction")
+
+// BatchTransferError tells which transfer made an atomic batch fail.
+type BatchTransferError struct {
+	Index int
+	Err   error
+}
+
+func (b *BatchTransferError) Error() string {
+	return fmt.Sprintf("transfer %d: %v", b.Index, b.Err)
+}
+
+func (b *BatchTransferError) Unwrap() error {
+	return b.Err
+}
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
@@ -0,0 +1,80 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/begenov/backend/pkg/e"
+)
+
+// RateProvider returns how many units of the to currency one unit of the
+// from currency buys.
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// StaticProvider serves fixed rates quoted against a base currency.
+type StaticProvider struct {
+	base  string
+	rates map[string]float64
+}
+
+func NewStaticProvider(base string, rates map[string]float64) *StaticProvider {
+	return &StaticProvider{
+		base:  base,
+		rates: rates,
+	}
+}
+
+type ratesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// NewFileProvider loads the rates from a JSON file like
+// {"base": "USD", "rates": {"EUR": 0.92, "CAD": 1.36}}.
+func NewFileProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ratesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse rates file %s: %w", path, err)
+	}
+	if file.Base == "" {
+		return nil, fmt.Errorf("rates file %s has no base currency", path)
+	}
+
+	return NewStaticProvider(file.Base, file.Rates), nil
+}
+
+func (p *StaticProvider) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, ok := p.baseRate(from)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", e.ErrRateNotFound, from)
+	}
+	toRate, ok := p.baseRate(to)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", e.ErrRateNotFound, to)
+	}
+
+	// cross rates are kept to 6 decimal places, like the quoted ones
+	return math.Round(toRate/fromRate*1e6) / 1e6, nil
+}
+
+func (p *StaticProvider) baseRate(currency string) (float64, bool) {
+	if currency == p.base {
+		return 1, true
+	}
+	rate, ok := p.rates[currency]
+	return rate, ok && rate > 0
+}
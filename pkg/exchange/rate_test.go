@@ -0,0 +1,49 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/begenov/backend/pkg/e"
+	"github.com/begenov/backend/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	provider, err := NewFileProvider("testdata/rates.json")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		from string
+		to   string
+		rate float64
+	}{
+		{from: util.USD, to: util.USD, rate: 1},
+		{from: util.USD, to: util.EUR, rate: 0.9},
+		{from: util.EUR, to: util.USD, rate: 1.111111},
+		{from: util.EUR, to: util.CAD, rate: 1.5},
+		{from: util.CAD, to: util.EUR, rate: 0.666667},
+	}
+
+	for _, tc := range testCases {
+		rate, err := provider.Rate(context.Background(), tc.from, tc.to)
+		require.NoError(t, err)
+		require.Equal(t, tc.rate, rate, "%s -> %s", tc.from, tc.to)
+	}
+}
+
+func TestStaticProviderUnknownCurrency(t *testing.T) {
+	provider := NewStaticProvider(util.USD, map[string]float64{util.EUR: 0.9})
+
+	_, err := provider.Rate(context.Background(), util.USD, util.CAD)
+	require.True(t, errors.Is(err, e.ErrRateNotFound))
+
+	_, err = provider.Rate(context.Background(), util.CAD, util.EUR)
+	require.True(t, errors.Is(err, e.ErrRateNotFound))
+}
+
+func TestNewFileProviderMissingFile(t *testing.T) {
+	_, err := NewFileProvider("testdata/missing.json")
+	require.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	// RequestIDKey is the attribute every log line of a request carries.
+	RequestIDKey = "request_id"
+)
+
+// New returns a logger writing to w at the given level ("debug", "info",
+// "warn" or "error") in the given format ("json" or "text").
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, want %s or %s", format, FormatJSON, FormatText)
+	}
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// WithContext stores l in ctx, FromContext gives it back to the service and
+// repository layers.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger of the request, or the default one for
+// work that doesn't belong to a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// WithRequestID stores the request id in ctx together with a logger derived
+// from l that adds it to every line.
+func WithRequestID(ctx context.Context, l *slog.Logger, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return WithContext(ctx, l.With(RequestIDKey, id))
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random id for requests that came without one.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, "warn", FormatJSON)
+	require.NoError(t, err)
+
+	l.Info("dropped")
+	l.Warn("kept", "n", 1)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "kept", line["msg"])
+	require.Equal(t, "WARN", line["level"])
+	require.EqualValues(t, 1, line["n"])
+
+	_, err = New(&buf, "loud", FormatJSON)
+	require.Error(t, err)
+
+	_, err = New(&buf, "info", "xml")
+	require.Error(t, err)
+}
+
+func TestWithRequestID(t *testing.T) {
+	ctx := context.Background()
+	require.Empty(t, RequestIDFromContext(ctx))
+	require.Equal(t, slog.Default(), FromContext(ctx))
+
+	var buf bytes.Buffer
+	l, err := New(&buf, "info", FormatJSON)
+	require.NoError(t, err)
+
+	ctx = WithRequestID(ctx, l, "req-1")
+	require.Equal(t, "req-1", RequestIDFromContext(ctx))
+
+	FromContext(ctx).Info("hello")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "req-1", line[RequestIDKey])
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	require.Len(t, a, 32)
+	require.NotEqual(t, a, b)
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric served on /metrics. It is kept apart from the
+// global registry so that only what is registered here gets exposed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by route and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	GRPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_requests_total",
+		Help: "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_request_duration_seconds",
+		Help:    "Time taken to handle gRPC calls, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	TransfersCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bank_transfers_created_total",
+		Help: "Transfers booked, by the currency of the from account.",
+	}, []string{"currency"})
+
+	// amounts are in the minor units of the currency, like the balances
+	AmountMoved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bank_transfer_amount_total",
+		Help: "Amount moved by booked transfers, in minor units of the from account currency.",
+	}, []string{"currency"})
+
+	TransfersFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bank_transfers_failed_total",
+		Help: "Transfers that could not be booked, by reason.",
+	}, []string{"reason"})
+
+	TransferRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "bank_transfer_tx_retries_total",
+		Help: "Transfer transactions rerun after a serialization failure or a deadlock.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		GRPCRequests,
+		GRPCRequestDuration,
+		TransfersCreated,
+		AmountMoved,
+		TransfersFailed,
+		TransferRetries,
+	)
+}
+
+// Handler serves the metrics in Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
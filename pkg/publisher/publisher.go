@@ -0,0 +1,53 @@
+package publisher
+
+import (
+	"context"
+	"sync"
+)
+
+// Message is an event handed to downstream services.
+type Message struct {
+	ID int64
+	// messages with the same key are published in order
+	Key     string
+	Type    string
+	Payload []byte
+}
+
+// Publisher delivers messages to a broker. Publish returns once the broker
+// has accepted the message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// MemoryPublisher keeps published messages in memory, for tests and for
+// running locally without a broker.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of everything published so far, in order.
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Message(nil), p.messages...)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
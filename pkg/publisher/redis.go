@@ -0,0 +1,137 @@
+package publisher
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultDialTimeout = 5 * time.Second
+
+// RedisPublisher appends messages to a Redis stream with XADD. It speaks the
+// plain Redis protocol, so it works with any compatible server. Messages go
+// out one at a time over a single connection, so the stream keeps the order
+// they were published in.
+type RedisPublisher struct {
+	addr   string
+	stream string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func NewRedisPublisher(addr, stream string) *RedisPublisher {
+	return &RedisPublisher{
+		addr:   addr,
+		stream: stream,
+	}
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.connect(ctx); err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultDialTimeout)
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		p.reset()
+		return err
+	}
+
+	err := p.do("XADD", p.stream, "*",
+		"id", strconv.FormatInt(msg.ID, 10),
+		"key", msg.Key,
+		"type", msg.Type,
+		"payload", string(msg.Payload),
+	)
+	if err != nil {
+		// the connection may be half way through a reply, start over
+		p.reset()
+		return fmt.Errorf("publish %d: %w", msg.ID, err)
+	}
+	return nil
+}
+
+func (p *RedisPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn, p.r = nil, nil
+	return err
+}
+
+func (p *RedisPublisher) connect(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: defaultDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	p.conn, p.r = conn, bufio.NewReader(conn)
+	return nil
+}
+
+func (p *RedisPublisher) reset() {
+	p.conn.Close()
+	p.conn, p.r = nil, nil
+}
+
+// do sends a command as an array of bulk strings and reads a single reply.
+func (p *RedisPublisher) do(args ...string) error {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := p.conn.Write(buf); err != nil {
+		return err
+	}
+
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if len(line) < 3 {
+		return fmt.Errorf("malformed reply %q", line)
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+', ':':
+		return nil
+	case '-':
+		return fmt.Errorf("redis: %s", line[1:])
+	case '$':
+		// XADD replies with the id of the new entry
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return fmt.Errorf("malformed reply %q", line)
+		}
+		if n < 0 {
+			return fmt.Errorf("redis: entry was not added")
+		}
+		_, err = p.r.Discard(n + 2)
+		return err
+	default:
+		return fmt.Errorf("unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,154 @@
+package publisher
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis accepts XADD commands and keeps their arguments. It fails the
+// command when the payload is "fail".
+type fakeRedis struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeRedis{listener: listener}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		if args[len(args)-1] == "fail" {
+			io.WriteString(conn, "-ERR rejected\r\n")
+			continue
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		id := strconv.Itoa(len(s.commands)) + "-0"
+		s.mu.Unlock()
+
+		io.WriteString(conn, "$"+strconv.Itoa(len(id))+"\r\n"+id+"\r\n")
+	}
+}
+
+func (s *fakeRedis) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.commands...)
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func TestRedisPublisher(t *testing.T) {
+	server := newFakeRedis(t)
+
+	publisher := NewRedisPublisher(server.listener.Addr().String(), "bank.events")
+	defer publisher.Close()
+
+	ctx := context.Background()
+	require.NoError(t, publisher.Publish(ctx, Message{ID: 1, Key: "7", Type: "account.created", Payload: []byte(`{"id":7}`)}))
+
+	err := publisher.Publish(ctx, Message{ID: 2, Key: "7", Type: "transfer.completed", Payload: []byte("fail")})
+	require.ErrorContains(t, err, "rejected")
+
+	// the failed publish dropped the connection, the next one redials
+	require.NoError(t, publisher.Publish(ctx, Message{ID: 3, Key: "8", Type: "transfer.completed", Payload: []byte(`{"id":1}`)}))
+
+	commands := server.Commands()
+	require.Len(t, commands, 2)
+	require.Equal(t, []string{
+		"XADD", "bank.events", "*",
+		"id", "1",
+		"key", "7",
+		"type", "account.created",
+		"payload", `{"id":7}`,
+	}, commands[0])
+	require.Equal(t, "3", commands[1][4])
+}
+
+func TestRedisPublisherUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	publisher := NewRedisPublisher(addr, "bank.events")
+	err = publisher.Publish(context.Background(), Message{ID: 1})
+	require.Error(t, err)
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher()
+
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(t, publisher.Publish(context.Background(), Message{ID: i}))
+	}
+
+	messages := publisher.Messages()
+	require.Len(t, messages, 3)
+	for i, msg := range messages {
+		require.Equal(t, int64(i+1), msg.ID)
+	}
+}
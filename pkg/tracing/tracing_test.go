@@ -0,0 +1,31 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInit(t *testing.T) {
+	for _, exporter := range []string{ExporterNone, ExporterStdout} {
+		shutdown, err := Init(context.Background(), exporter, "", "simple-bank")
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+	}
+
+	_, err := Init(context.Background(), "zipkin", "", "simple-bank")
+	require.Error(t, err)
+}
+
+func TestInitInMemory(t *testing.T) {
+	exporter := InitInMemory()
+
+	_, span := otel.Tracer("test").Start(context.Background(), "work")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "work", spans[0].Name)
+}
@@ -0,0 +1,8 @@
+package util
+
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+	// the external payment processor booking deposits and withdrawals
+	ProcessorRole = "processor"
+)
@@ -14,3 +14,10 @@ func IsSupportedCurrency(currency string) bool {
 		return false
 	}
 }
+
+// MinorUnits is the number of decimal places between the minor unit the
+// amounts are kept in and the currency itself, 2 for cents.
+func MinorUnits(currency string) int {
+	// every supported currency is split in cents
+	return 2
+}
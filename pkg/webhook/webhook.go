@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// sha256=<hex hmac of "<timestamp>.<body>">
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// Event is the body posted to the receiver.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	AccountID int             `json:"account_id"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign returns the signature of body sent at timestamp, prefixed the way it
+// goes into the signature header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature was made for body and timestamp with
+// secret. Receivers should also reject timestamps that are too old.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// ErrForbiddenAddress is returned when a receiver resolves to an address
+// that isn't reachable from the internet, e.g. the metadata service of the
+// cloud provider or a database next to the bank.
+var ErrForbiddenAddress = errors.New("webhook receiver address is not public")
+
+// carrier-grade NAT, shared with the provider and not covered by IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Sender posts signed events to receivers.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender returns a sender that refuses to connect to loopback, private
+// and link-local addresses. The check runs when the connection is dialed,
+// after DNS resolution and for every redirect, so a receiver can't point its
+// name at an internal address after the URL was accepted.
+func NewSender(timeout time.Duration) *Sender {
+	return newSender(timeout, denyPrivate)
+}
+
+// NewPrivateSender returns a sender that connects to any address. It is
+// meant for receivers on the local network, e.g. in tests.
+func NewPrivateSender(timeout time.Duration) *Sender {
+	return newSender(timeout, nil)
+}
+
+func newSender(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *Sender {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	transport := &http.Transport{
+		// a proxy would dial the receiver on our behalf, past the check
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Sender{
+		client: &http.Client{Timeout: timeout, Transport: transport},
+		now:    time.Now,
+	}
+}
+
+// denyPrivate is the dialer control of NewSender. address is the resolved
+// ip and port about to be connected to.
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !public(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
+
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// Timeout is how long a single Send may take at most.
+func (s *Sender) Timeout() time.Duration {
+	return s.client.Timeout
+}
+
+// Send posts event to url and returns the status code of the response. Any
+// status outside 2xx is returned together with an error.
+func (s *Sender) Send(ctx context.Context, url, secret string, event Event) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+	// drain so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(rsp.Body, 64<<10))
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return rsp.StatusCode, fmt.Errorf("receiver responded with %s", rsp.Status)
+	}
+	return rsp.StatusCode, nil
+}
+
+// Backoff returns how long to wait before the next attempt once attempts
+// have failed, doubling from base and capped at max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+	return wait
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSigned(t *testing.T) {
+	secret := "s3cret"
+	event := Event{
+		ID:        42,
+		Type:      "transfer.completed",
+		AccountID: 7,
+		Data:      json.RawMessage(`{"amount":10}`),
+	}
+
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		if !Verify(secret, timestamp, body, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, "42", r.Header.Get(HeaderID))
+		require.Equal(t, event.Type, r.Header.Get(HeaderEvent))
+
+		var got Event
+		require.NoError(t, json.Unmarshal(body, &got))
+		received <- got
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sender := NewPrivateSender(time.Second)
+
+	code, err := sender.Send(context.Background(), srv.URL, secret, event)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, code)
+	got := <-received
+	require.Equal(t, event.ID, got.ID)
+	require.Equal(t, event.AccountID, got.AccountID)
+	require.JSONEq(t, string(event.Data), string(got.Data))
+
+	// a receiver with another secret rejects the delivery
+	code, err = sender.Send(context.Background(), srv.URL, "wrong", event)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestSendUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	code, err := NewPrivateSender(time.Second).Send(context.Background(), url, "secret", Event{ID: 1})
+	require.Error(t, err)
+	require.Zero(t, code)
+}
+
+func TestSendPrivateAddress(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	code, err := NewSender(time.Second).Send(context.Background(), srv.URL, "secret", Event{ID: 1})
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	require.Zero(t, code)
+	require.False(t, called)
+}
+
+func TestPublic(t *testing.T) {
+	testCases := []struct {
+		ip     string
+		public bool
+	}{
+		{ip: "93.184.216.34", public: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.1.2.3"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "fd00::1"},
+		{ip: "100.64.0.1"},
+		{ip: "0.0.0.0"},
+		{ip: "::ffff:127.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			require.Equal(t, tc.public, public(netip.MustParseAddr(tc.ip)))
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	signature := Sign("secret", 100, body)
+
+	require.True(t, Verify("secret", 100, body, signature))
+	require.False(t, Verify("secret", 101, body, signature))
+	require.False(t, Verify("secret", 100, []byte(`{"id":2}`), signature))
+	require.False(t, Verify("other", 100, body, signature))
+	require.False(t, Verify("secret", 100, body, signature[len(signaturePrefix):]))
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 30*time.Second, time.Hour
+
+	require.Equal(t, 30*time.Second, Backoff(1, base, max))
+	require.Equal(t, time.Minute, Backoff(2, base, max))
+	require.Equal(t, 4*time.Minute, Backoff(4, base, max))
+	require.Equal(t, max, Backoff(10, base, max))
+	require.Equal(t, max, Backoff(100, base, max))
+}